@@ -8,6 +8,7 @@ import (
 	"github.com/airmassxpress/backend/internal/models"
 	"github.com/airmassxpress/backend/internal/utils"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -66,37 +67,37 @@ func seedTasks(db *gorm.DB, users []models.User) []models.Task {
 		{
 			ID: uuid.New(), PosterID: users[0].ID, Title: "Fix leaking bathroom pipe",
 			Description: "I have a pipe under my bathroom sink that's been leaking. Need someone to fix it urgently.",
-			Category:    "Plumbing", Budget: 150, Location: "Borrowdale, Harare", DateType: "flexible", Status: "open",
+			Category:    "Plumbing", Budget: decimal.NewFromInt(150), Location: "Borrowdale, Harare", DateType: "flexible", Status: "open",
 			CreatedAt: now.Add(-5 * time.Hour),
 		},
 		{
 			ID: uuid.New(), PosterID: users[1].ID, Title: "House painting - 3 bedrooms",
 			Description: "Need a professional painter for 3 bedrooms. Paint and supplies provided.",
-			Category:    "Painting", Budget: 500, Location: "Avondale, Harare", DateType: "on_date", Status: "open",
+			Category:    "Painting", Budget: decimal.NewFromInt(500), Location: "Avondale, Harare", DateType: "on_date", Status: "open",
 			CreatedAt: now.Add(-12 * time.Hour),
 		},
 		{
 			ID: uuid.New(), PosterID: users[2].ID, Title: "Install ceiling fan in living room",
 			Description: "Need help installing a new ceiling fan. The electrical box is already in place.",
-			Category:    "Electrical Service", Budget: 120, Location: "Mount Pleasant, Harare", DateType: "before_date", Status: "open",
+			Category:    "Electrical Service", Budget: decimal.NewFromInt(120), Location: "Mount Pleasant, Harare", DateType: "before_date", Status: "open",
 			CreatedAt: now.Add(-8 * time.Hour),
 		},
 		{
 			ID: uuid.New(), PosterID: users[3].ID, Title: "Deep clean 4-bedroom house",
 			Description: "Looking for professional cleaning service. Prefer eco-friendly products.",
-			Category:    "Other", Budget: 300, Location: "Greendale, Harare", DateType: "flexible", Status: "open",
+			Category:    "Other", Budget: decimal.NewFromInt(300), Location: "Greendale, Harare", DateType: "flexible", Status: "open",
 			CreatedAt: now.Add(-24 * time.Hour),
 		},
 		{
 			ID: uuid.New(), PosterID: users[4].ID, Title: "Garden maintenance - lawn mowing",
 			Description: "Need regular garden maintenance. About 200 square meters of lawn.",
-			Category:    "Landscaping", Budget: 80, Location: "Newlands, Harare", DateType: "flexible", Status: "open",
+			Category:    "Landscaping", Budget: decimal.NewFromInt(80), Location: "Newlands, Harare", DateType: "flexible", Status: "open",
 			CreatedAt: now.Add(-18 * time.Hour),
 		},
 		{
 			ID: uuid.New(), PosterID: users[5].ID, Title: "Assemble IKEA furniture",
 			Description: "Need help assembling wardrobe and desk from IKEA. All parts included.",
-			Category:    "Carpentry", Budget: 100, Location: "Alexandra Park, Harare", DateType: "flexible", Status: "open",
+			Category:    "Carpentry", Budget: decimal.NewFromInt(100), Location: "Alexandra Park, Harare", DateType: "flexible", Status: "open",
 			CreatedAt: now.Add(-36 * time.Hour),
 		},
 	}
@@ -114,16 +115,16 @@ func seedOffers(db *gorm.DB, tasks []models.Task, users []models.User) []models.
 	now := time.Now()
 
 	offers := []models.Offer{
-		{ID: uuid.New(), TaskID: tasks[0].ID, TaskerID: users[3].ID, Amount: 140, Description: "I can fix this today! Licensed plumber with 8 years experience.", EstimatedDuration: "2 hours", Availability: "Available today after 2pm", Status: "pending", CreatedAt: now.Add(-3 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[0].ID, TaskerID: users[0].ID, Amount: 130, Description: "I've handled many similar plumbing issues. Can come tomorrow.", EstimatedDuration: "2 hours", Availability: "Tomorrow 9am-12pm", Status: "pending", CreatedAt: now.Add(-2 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[1].ID, TaskerID: users[5].ID, Amount: 480, Description: "Professional interior painter with smooth finish guarantee.", EstimatedDuration: "2 days", Availability: "Can start next Monday", Status: "pending", CreatedAt: now.Add(-10 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[1].ID, TaskerID: users[0].ID, Amount: 450, Description: "Experienced in residential painting. Can provide references.", EstimatedDuration: "2 days", Availability: "Flexible", Status: "pending", CreatedAt: now.Add(-6 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[2].ID, TaskerID: users[2].ID, Amount: 100, Description: "Licensed electrician. Will provide electrical certificate.", EstimatedDuration: "1.5 hours", Availability: "Tomorrow afternoon", Status: "pending", CreatedAt: now.Add(-4 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[3].ID, TaskerID: users[1].ID, Amount: 280, Description: "Professional cleaning with eco-friendly products.", EstimatedDuration: "6-7 hours", Availability: "This weekend", Status: "pending", CreatedAt: now.Add(-20 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[3].ID, TaskerID: users[5].ID, Amount: 290, Description: "Detailed cleaning service. Can provide references.", EstimatedDuration: "6-8 hours", Availability: "Weekdays", Status: "pending", CreatedAt: now.Add(-15 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[4].ID, TaskerID: users[4].ID, Amount: 70, Description: "Garden maintenance specialist. Can set up weekly schedule.", EstimatedDuration: "2-3 hours", Availability: "Every Saturday morning", Status: "pending", CreatedAt: now.Add(-12 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[5].ID, TaskerID: users[0].ID, Amount: 90, Description: "I've assembled many IKEA pieces. Fast and efficient.", EstimatedDuration: "2-3 hours", Availability: "This evening", Status: "pending", CreatedAt: now.Add(-30 * time.Hour)},
-		{ID: uuid.New(), TaskID: tasks[5].ID, TaskerID: users[2].ID, Amount: 95, Description: "Experienced with furniture assembly. Perfect fit guaranteed.", EstimatedDuration: "3 hours", Availability: "Tomorrow morning", Status: "pending", CreatedAt: now.Add(-25 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[0].ID, TaskerID: users[3].ID, Amount: decimal.NewFromInt(140), Description: "I can fix this today! Licensed plumber with 8 years experience.", EstimatedDuration: "2 hours", Availability: "Available today after 2pm", Status: "pending", CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[0].ID, TaskerID: users[0].ID, Amount: decimal.NewFromInt(130), Description: "I've handled many similar plumbing issues. Can come tomorrow.", EstimatedDuration: "2 hours", Availability: "Tomorrow 9am-12pm", Status: "pending", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[1].ID, TaskerID: users[5].ID, Amount: decimal.NewFromInt(480), Description: "Professional interior painter with smooth finish guarantee.", EstimatedDuration: "2 days", Availability: "Can start next Monday", Status: "pending", CreatedAt: now.Add(-10 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[1].ID, TaskerID: users[0].ID, Amount: decimal.NewFromInt(450), Description: "Experienced in residential painting. Can provide references.", EstimatedDuration: "2 days", Availability: "Flexible", Status: "pending", CreatedAt: now.Add(-6 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[2].ID, TaskerID: users[2].ID, Amount: decimal.NewFromInt(100), Description: "Licensed electrician. Will provide electrical certificate.", EstimatedDuration: "1.5 hours", Availability: "Tomorrow afternoon", Status: "pending", CreatedAt: now.Add(-4 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[3].ID, TaskerID: users[1].ID, Amount: decimal.NewFromInt(280), Description: "Professional cleaning with eco-friendly products.", EstimatedDuration: "6-7 hours", Availability: "This weekend", Status: "pending", CreatedAt: now.Add(-20 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[3].ID, TaskerID: users[5].ID, Amount: decimal.NewFromInt(290), Description: "Detailed cleaning service. Can provide references.", EstimatedDuration: "6-8 hours", Availability: "Weekdays", Status: "pending", CreatedAt: now.Add(-15 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[4].ID, TaskerID: users[4].ID, Amount: decimal.NewFromInt(70), Description: "Garden maintenance specialist. Can set up weekly schedule.", EstimatedDuration: "2-3 hours", Availability: "Every Saturday morning", Status: "pending", CreatedAt: now.Add(-12 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[5].ID, TaskerID: users[0].ID, Amount: decimal.NewFromInt(90), Description: "I've assembled many IKEA pieces. Fast and efficient.", EstimatedDuration: "2-3 hours", Availability: "This evening", Status: "pending", CreatedAt: now.Add(-30 * time.Hour)},
+		{ID: uuid.New(), TaskID: tasks[5].ID, TaskerID: users[2].ID, Amount: decimal.NewFromInt(95), Description: "Experienced with furniture assembly. Perfect fit guaranteed.", EstimatedDuration: "3 hours", Availability: "Tomorrow morning", Status: "pending", CreatedAt: now.Add(-25 * time.Hour)},
 	}
 
 	for i := range offers {
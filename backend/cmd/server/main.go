@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/airmassxpress/backend/internal/api"
 	"github.com/airmassxpress/backend/internal/config"
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/payments"
+	"github.com/airmassxpress/backend/internal/scheduler"
 	"github.com/airmassxpress/backend/internal/services"
+	"github.com/airmassxpress/backend/internal/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -37,6 +41,7 @@ func main() {
 		&models.User{},
 		&models.TaskerProfile{},
 		&models.Task{},
+		&models.TaskStatusHistory{},
 		&models.Comment{},
 		&models.TaskAttachment{},
 		&models.Offer{},
@@ -50,12 +55,51 @@ func main() {
 		&models.Profession{},
 		&models.FCMToken{},
 		&models.InventoryItem{},
+		&models.EquipmentType{},
+		&models.EquipmentCapacity{},
+		&models.SavedSearch{},
+		&models.SavedSearchMatch{},
+		&models.JobRun{},
+		&models.IdempotencyKey{},
+		&models.OfferCounter{},
+		&models.OfferRevision{},
+		&models.EquipmentBooking{},
+		&models.HireEvent{},
+		&models.HireSettlement{},
+		&models.MaintenanceRecord{},
+		&models.EquipmentCertificate{},
+		&models.PaymentTransaction{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.LedgerPosting{},
+		&models.FeeRule{},
+		&models.Invoice{},
+		&models.InvoiceSequence{},
+		&models.ExchangeRate{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 	log.Println("Database migrations completed successfully")
 
+	if err := services.EnsureTaskSearchSchema(db); err != nil {
+		log.Printf("Warning: Failed to set up task search index: %v", err)
+	}
+
+	if err := services.EnsureLedgerSchema(db); err != nil {
+		log.Printf("Warning: Failed to make the ledger append-only: %v", err)
+	}
+	if err := services.EnsureInvoiceSchema(db); err != nil {
+		log.Printf("Warning: Failed to make invoices immutable: %v", err)
+	}
+
+	// Equipment types and the default capacity tiers for a fresh database
+	if seeded, err := services.SeedEquipmentCatalogue(db); err != nil {
+		log.Printf("Warning: Failed to seed equipment catalogue: %v", err)
+	} else if seeded.TypesCreated > 0 || seeded.Created > 0 {
+		log.Printf("Seeded equipment catalogue: %d types, %d capacity tiers", seeded.TypesCreated, seeded.Created)
+	}
+
 	// Initialize services
 	// CREDENTIALS: Use env var or default locations.
 	// For dev, if no creds, it might fail or we should handle gracefully.
@@ -71,8 +115,34 @@ func main() {
 		log.Printf("Warning: Failed to initialize FCM service: %v", err)
 	}
 
+	taskLifecycle := services.NewTaskLifecycle(db, fcmService, hub)
+	savedSearchMatcher := services.NewSavedSearchMatcher(db, fcmService)
+
+	providers, err := payments.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize payment providers:", err)
+	}
+	log.Printf("Payment methods enabled: %v", providers.Methods())
+	paymentService := services.NewPaymentService(db, fcmService, hub, providers)
+	invoiceService := services.NewInvoiceService(db, fcmService, cfg.Invoices)
+
+	// Background jobs: task expiry, reminders, stale-state cleanup, digests and payments
+	jobs := scheduler.New(db)
+	scheduler.RegisterMarketplaceJobs(jobs, db, fcmService, taskLifecycle, savedSearchMatcher, cfg.Scheduler)
+	scheduler.RegisterPaymentJobs(jobs, paymentService)
+	if cfg.Scheduler.Enabled {
+		jobs.Start(context.Background())
+	}
+
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+	log.Printf("Using %s storage for uploads", cfg.Storage.Driver)
+	imagePipeline := services.NewImagePipeline(db, store)
+
 	// Initialize router
-	router := api.SetupRouter(cfg, db, fcmService, hub)
+	router := api.SetupRouter(cfg, db, fcmService, hub, taskLifecycle, savedSearchMatcher, jobs, store, imagePipeline, paymentService, invoiceService)
 
 	// Start server
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
@@ -25,17 +25,35 @@ func NewChatHandler(db *gorm.DB, hub *services.Hub) *ChatHandler {
 func (h *ChatHandler) GetConversations(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	var conversations []models.Conversation
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Complex query to join participants
-	err := h.db.Model(&models.Conversation{}).
-		Joins("JOIN conversation_participants cp ON cp.conversation_id = conversations.id").
-		Where("cp.user_id = ?", userID).
-		Preload("Participants").
-		Preload("Messages", func(db *gorm.DB) *gorm.DB {
-			return db.Order("created_at desc").Limit(1) // Get latest message
-		}).
-		Preload("Task"). // Load associated task if exists
-		Find(&conversations).Error
+	filter := func(query *gorm.DB) *gorm.DB {
+		return query.
+			Joins("JOIN conversation_participants cp ON cp.conversation_id = conversations.id").
+			Where("cp.user_id = ?", userID)
+	}
+
+	order := keyset{Expr: "conversations.created_at", Cast: "timestamptz", IDColumn: "conversations.id", Desc: true}
+	if err := order.checkCursor(page.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conversations, nextCursor, err := fetchPage(
+		filter(h.db.Model(&models.Conversation{})).
+			Preload("Participants").
+			Preload("Messages", func(db *gorm.DB) *gorm.DB {
+				return db.Order("created_at desc").Limit(1) // Get latest message
+			}).
+			Preload("Task"), // Load associated task if exists
+		page, order,
+		func(conv *models.Conversation) (string, uuid.UUID) {
+			return conv.CreatedAt.Format(time.RFC3339Nano), conv.ID
+		})
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
@@ -116,10 +134,15 @@ func (h *ChatHandler) GetConversations(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, responses)
+	c.JSON(http.StatusOK, PageResponse{
+		Items:         responses,
+		NextCursor:    nextCursor,
+		TotalEstimate: estimateTotal(h.db, filter(h.db.Model(&models.Conversation{}))),
+	})
 }
 
-// GetMessages returns messages for a specific conversation
+// GetMessages returns messages for a specific conversation. Pages are read newest
+// first; next_cursor loads older messages. Items within a page are in chronological order.
 func (h *ChatHandler) GetMessages(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	conversationID, err := uuid.Parse(c.Param("conversationId"))
@@ -128,6 +151,12 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Verify participation
 	var count int64
 	h.db.Model(&models.ConversationParticipant{}).
@@ -139,8 +168,14 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
-	var messages []models.Message
-	if err := h.db.Preload("Sender").Where("conversation_id = ?", conversationID).Order("created_at asc").Find(&messages).Error; err != nil {
+	order := keyset{Expr: "created_at", Cast: "timestamptz", IDColumn: "id", Desc: true}
+	if err := order.checkCursor(page.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	messages, nextCursor, err := fetchPage(h.db.Preload("Sender").Where("conversation_id = ?", conversationID), page, order,
+		func(m *models.Message) (string, uuid.UUID) { return m.CreatedAt.Format(time.RFC3339Nano), m.ID })
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
 		return
 	}
@@ -162,7 +197,8 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 
 	responses := make([]MessageResponse, len(messages))
 	for i, msg := range messages {
-		responses[i] = MessageResponse{
+		// Fetched newest first; reverse into chronological order
+		responses[len(messages)-1-i] = MessageResponse{
 			ID:             msg.ID.String(),
 			ConversationID: msg.ConversationID.String(),
 			SenderID:       msg.SenderID.String(),
@@ -172,7 +208,11 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, responses)
+	c.JSON(http.StatusOK, PageResponse{
+		Items:         responses,
+		NextCursor:    nextCursor,
+		TotalEstimate: estimateTotal(h.db, h.db.Model(&models.Message{}).Where("conversation_id = ?", conversationID)),
+	})
 }
 
 // MarkConversationAsRead marks all messages in a conversation as read for the user
@@ -16,12 +16,13 @@ func NewEquipmentCapacityHandler(db *gorm.DB) *EquipmentCapacityHandler {
 	return &EquipmentCapacityHandler{db: db}
 }
 
-// GetAllCapacities returns all equipment capacities grouped by type
+// GetAllCapacities returns all equipment capacities grouped by type. Retired tiers
+// are left out unless ?include_retired=true.
 // GET /api/equipment-capacities
 func (h *EquipmentCapacityHandler) GetAllCapacities(c *gin.Context) {
 	var capacities []models.EquipmentCapacity
 
-	if err := h.db.Order("equipment_type, sort_order").Find(&capacities).Error; err != nil {
+	if err := h.activeCapacities(c).Order("equipment_type, sort_order").Find(&capacities).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capacities"})
 		return
 	}
@@ -38,13 +39,14 @@ func (h *EquipmentCapacityHandler) GetAllCapacities(c *gin.Context) {
 	})
 }
 
-// GetCapacitiesByType returns capacities for a specific equipment type
+// GetCapacitiesByType returns capacities for a specific equipment type, leaving out
+// retired tiers unless ?include_retired=true
 // GET /api/equipment-capacities/:type
 func (h *EquipmentCapacityHandler) GetCapacitiesByType(c *gin.Context) {
 	equipmentType := c.Param("type")
 
 	var capacities []models.EquipmentCapacity
-	if err := h.db.Where("equipment_type = ?", equipmentType).Order("sort_order").Find(&capacities).Error; err != nil {
+	if err := h.activeCapacities(c).Where("equipment_type = ?", equipmentType).Order("sort_order").Find(&capacities).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capacities"})
 		return
 	}
@@ -52,14 +54,24 @@ func (h *EquipmentCapacityHandler) GetCapacitiesByType(c *gin.Context) {
 	c.JSON(http.StatusOK, capacities)
 }
 
-// GetEquipmentTypes returns a list of unique equipment types
+// GetEquipmentTypes returns the names of the active equipment types in display order
 // GET /api/equipment-types
 func (h *EquipmentCapacityHandler) GetEquipmentTypes(c *gin.Context) {
-	var types []string
-	if err := h.db.Model(&models.EquipmentCapacity{}).Distinct().Pluck("equipment_type", &types).Error; err != nil {
+	types := []string{}
+	if err := h.db.Model(&models.EquipmentType{}).Where("retired_at IS NULL").
+		Order("sort_order, name").Pluck("name", &types).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch equipment types"})
 		return
 	}
 
 	c.JSON(http.StatusOK, types)
 }
+
+// activeCapacities scopes a capacity query to tiers that have not been retired,
+// unless the caller asks for ?include_retired=true
+func (h *EquipmentCapacityHandler) activeCapacities(c *gin.Context) *gorm.DB {
+	if c.Query("include_retired") == "true" {
+		return h.db
+	}
+	return h.db.Where("retired_at IS NULL")
+}
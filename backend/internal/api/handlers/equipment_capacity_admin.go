@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// maxCatalogueImportBytes bounds a bulk import upload
+const maxCatalogueImportBytes = 5 << 20
+
+type EquipmentTypeRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Description string `json:"description" binding:"max=1000"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+type UpdateEquipmentTypeRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=50"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	SortOrder   *int    `json:"sort_order"`
+}
+
+type CapacityRequest struct {
+	EquipmentType string   `json:"equipment_type" binding:"required"`
+	CapacityCode  string   `json:"capacity_code" binding:"required"`
+	DisplayName   string   `json:"display_name" binding:"required"`
+	MinWeightTons *float64 `json:"min_weight_tons"`
+	MaxWeightTons *float64 `json:"max_weight_tons"`
+	SortOrder     int      `json:"sort_order"`
+}
+
+type UpdateCapacityRequest struct {
+	CapacityCode  *string  `json:"capacity_code"`
+	DisplayName   *string  `json:"display_name"`
+	MinWeightTons *float64 `json:"min_weight_tons"`
+	MaxWeightTons *float64 `json:"max_weight_tons"`
+	SortOrder     *int     `json:"sort_order"`
+}
+
+// AdminListEquipmentTypes returns every equipment type, retired ones included
+// GET /admin/equipment-types
+func (h *EquipmentCapacityHandler) AdminListEquipmentTypes(c *gin.Context) {
+	var types []models.EquipmentType
+	if err := h.db.Order("sort_order, name").Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch equipment types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+// AdminCreateEquipmentType adds an equipment type
+// POST /admin/equipment-types
+func (h *EquipmentCapacityHandler) AdminCreateEquipmentType(c *gin.Context) {
+	var req EquipmentTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	equipmentType := models.EquipmentType{Name: req.Name, Description: req.Description, SortOrder: req.SortOrder}
+	if err := services.CreateEquipmentType(h.db, &equipmentType); err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, equipmentType)
+}
+
+// AdminUpdateEquipmentType renames or reorders an equipment type
+// PATCH /admin/equipment-types/:id
+func (h *EquipmentCapacityHandler) AdminUpdateEquipmentType(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid equipment type ID"})
+		return
+	}
+
+	var req UpdateEquipmentTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	equipmentType, err := services.UpdateEquipmentType(h.db, id, req.Name, req.Description, req.SortOrder)
+	if err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, equipmentType)
+}
+
+// AdminRetireEquipmentType retires an equipment type and its tiers
+// POST /admin/equipment-types/:id/retire
+func (h *EquipmentCapacityHandler) AdminRetireEquipmentType(c *gin.Context) {
+	h.setEquipmentTypeRetired(c, true)
+}
+
+// AdminRestoreEquipmentType restores a retired equipment type
+// POST /admin/equipment-types/:id/restore
+func (h *EquipmentCapacityHandler) AdminRestoreEquipmentType(c *gin.Context) {
+	h.setEquipmentTypeRetired(c, false)
+}
+
+func (h *EquipmentCapacityHandler) setEquipmentTypeRetired(c *gin.Context, retired bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid equipment type ID"})
+		return
+	}
+
+	equipmentType, err := services.SetEquipmentTypeRetired(h.db, id, retired)
+	if err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, equipmentType)
+}
+
+// AdminCreateCapacity adds a capacity tier to an equipment type
+// POST /admin/equipment-capacities
+func (h *EquipmentCapacityHandler) AdminCreateCapacity(c *gin.Context) {
+	var req CapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	capacity := models.EquipmentCapacity{
+		EquipmentType: req.EquipmentType,
+		CapacityCode:  req.CapacityCode,
+		DisplayName:   req.DisplayName,
+		MinWeightTons: optionalDecimal(req.MinWeightTons),
+		MaxWeightTons: optionalDecimal(req.MaxWeightTons),
+		SortOrder:     req.SortOrder,
+	}
+	if err := services.CreateCapacity(h.db, &capacity); err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, capacity)
+}
+
+// AdminUpdateCapacity edits a capacity tier
+// PATCH /admin/equipment-capacities/:id
+func (h *EquipmentCapacityHandler) AdminUpdateCapacity(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid capacity ID"})
+		return
+	}
+
+	var req UpdateCapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	capacity, err := services.UpdateCapacity(h.db, id, services.CapacityPatch{
+		CapacityCode:  req.CapacityCode,
+		DisplayName:   req.DisplayName,
+		MinWeightTons: optionalDecimal(req.MinWeightTons),
+		MaxWeightTons: optionalDecimal(req.MaxWeightTons),
+		SortOrder:     req.SortOrder,
+	})
+	if err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, capacity)
+}
+
+// AdminRetireCapacity stops a tier being offered for new tasks and items
+// POST /admin/equipment-capacities/:id/retire
+func (h *EquipmentCapacityHandler) AdminRetireCapacity(c *gin.Context) {
+	h.setCapacityRetired(c, true)
+}
+
+// AdminRestoreCapacity restores a retired tier
+// POST /admin/equipment-capacities/:id/restore
+func (h *EquipmentCapacityHandler) AdminRestoreCapacity(c *gin.Context) {
+	h.setCapacityRetired(c, false)
+}
+
+func (h *EquipmentCapacityHandler) setCapacityRetired(c *gin.Context, retired bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid capacity ID"})
+		return
+	}
+
+	capacity, err := services.SetCapacityRetired(h.db, id, retired)
+	if err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, capacity)
+}
+
+// AdminImportCapacities upserts capacity tiers from a CSV body (text/csv, or a
+// multipart "file") or a JSON array of rows
+// POST /admin/equipment-capacities/import
+func (h *EquipmentCapacityHandler) AdminImportCapacities(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogueImportBytes)
+
+	var rows []services.CapacityRow
+	var err error
+	switch contentType := c.ContentType(); {
+	case strings.HasPrefix(contentType, "multipart/"):
+		file, fileErr := c.FormFile("file")
+		if fileErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+			return
+		}
+		f, openErr := file.Open()
+		if openErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer f.Close()
+		rows, err = services.ParseCapacityCSV(f)
+	case contentType == "text/csv":
+		rows, err = services.ParseCapacityCSV(c.Request.Body)
+	default:
+		err = json.NewDecoder(c.Request.Body).Decode(&rows)
+	}
+	if err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No rows to import"})
+		return
+	}
+
+	result, err := services.ImportCapacities(h.db, rows)
+	if err != nil {
+		respondCatalogueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AdminExportCapacities returns every tier as JSON, or CSV with ?format=csv, in
+// the format the import accepts
+// GET /admin/equipment-capacities/export
+func (h *EquipmentCapacityHandler) AdminExportCapacities(c *gin.Context) {
+	rows, err := services.ExportCapacities(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export capacities"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, rows)
+		return
+	}
+	var buf bytes.Buffer
+	if err := services.WriteCapacityCSV(&buf, rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export capacities"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="equipment_capacities.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// respondCatalogueError maps equipment catalogue errors onto HTTP responses
+func respondCatalogueError(c *gin.Context, err error) {
+	var importErr *services.ImportError
+	var entryErr *services.CatalogueEntryError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &importErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Import rejected: " + err.Error(), "code": "IMPORT_INVALID", "rows": importErr.Rows})
+	case errors.As(err, &entryErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import is too large"})
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.Is(err, services.ErrInvalidCapacityCSV):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEquipmentTypeNotFound), errors.Is(err, services.ErrCapacityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEquipmentTypeExists), errors.Is(err, services.ErrCapacityExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEquipmentTypeRetired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "EQUIPMENT_TYPE_RETIRED"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update equipment catalogue"})
+	}
+}
+
+// requireActiveCapacity rejects a new reference to a missing or retired tier,
+// reporting whether the request may go ahead
+func requireActiveCapacity(c *gin.Context, db *gorm.DB, capacityID uuid.UUID) bool {
+	err := services.RequireActiveCapacity(db, capacityID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrCapacityNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown capacity tier"})
+	case errors.Is(err, services.ErrCapacityRetired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "CAPACITY_RETIRED"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check capacity tier"})
+	}
+	return false
+}
+
+func optionalDecimal(v *float64) *decimal.Decimal {
+	if v == nil {
+		return nil
+	}
+	d := decimal.NewFromFloat(*v)
+	return &d
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type ExchangeHandler struct {
+	db *gorm.DB
+}
+
+func NewExchangeHandler(db *gorm.DB) *ExchangeHandler {
+	return &ExchangeHandler{db: db}
+}
+
+type ExchangeRateRequest struct {
+	Base  string          `json:"base" binding:"required"`
+	Quote string          `json:"quote" binding:"required"`
+	Rate  decimal.Decimal `json:"rate"`
+	// EffectiveAt defaults to now
+	EffectiveAt *time.Time `json:"effective_at"`
+	Source      string     `json:"source" binding:"max=100"`
+}
+
+// GetExchangeRates returns the latest rate from ?base= (default USD) to each
+// other supported currency
+// GET /exchange-rates
+func (h *ExchangeHandler) GetExchangeRates(c *gin.Context) {
+	base, ok := currencyQuery(c, "base")
+	if !ok {
+		return
+	}
+	if base == "" {
+		base = money.Default
+	}
+
+	rates, err := services.LatestExchangeRates(h.db, base)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"base": base, "currencies": money.Currencies, "rates": rates})
+}
+
+// ConvertAmount converts an amount for display at the latest rate
+// GET /exchange-rates/convert?amount=&from=&to=
+func (h *ExchangeHandler) ConvertAmount(c *gin.Context) {
+	amount, err := decimal.NewFromString(c.Query("amount"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be a number"})
+		return
+	}
+	from, err := money.ParseCurrency(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := money.ParseCurrency(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := services.ExchangeRateAt(h.db, from, to, time.Now())
+	if err != nil {
+		respondExchangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":         money.New(amount, from),
+		"to":           money.New(amount, from).Convert(rate.Rate, to),
+		"rate":         rate.Rate,
+		"effective_at": rate.EffectiveAt,
+	})
+}
+
+// AdminListExchangeRates returns recorded rates newest first, optionally for one
+// ?base= and ?quote= pair
+// GET /admin/exchange-rates
+func (h *ExchangeHandler) AdminListExchangeRates(c *gin.Context) {
+	query := h.db.Order("effective_at DESC, created_at DESC").Limit(200)
+	if base := c.Query("base"); base != "" {
+		query = query.Where("base = ?", base)
+	}
+	if quote := c.Query("quote"); quote != "" {
+		query = query.Where("quote = ?", quote)
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// AdminCreateExchangeRate records a rate for a currency pair
+// POST /admin/exchange-rates
+func (h *ExchangeHandler) AdminCreateExchangeRate(c *gin.Context) {
+	var req ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate := models.ExchangeRate{Base: req.Base, Quote: req.Quote, Rate: req.Rate, Source: req.Source}
+	if req.EffectiveAt != nil {
+		rate.EffectiveAt = *req.EffectiveAt
+	}
+	if err := services.RecordExchangeRate(h.db, &rate); err != nil {
+		respondExchangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// currencyQuery reads an optional currency query parameter, answering 400 and
+// returning false when it is not a supported currency
+func currencyQuery(c *gin.Context, name string) (string, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return "", true
+	}
+	currency, err := money.ParseCurrency(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return currency, true
+}
+
+// setDisplayBudgets converts each task's budget into currency for display. Tasks
+// whose currency has no exchange rate to it are left without one.
+func setDisplayBudgets(db *gorm.DB, currency string, tasks ...*models.Task) {
+	if currency == "" {
+		return
+	}
+	now := time.Now()
+	rates := map[string]*models.ExchangeRate{}
+	for _, task := range tasks {
+		rate, seen := rates[task.Currency]
+		if !seen {
+			rate, _ = services.ExchangeRateAt(db, task.Currency, currency, now)
+			rates[task.Currency] = rate
+		}
+		if rate != nil {
+			display := task.BudgetMoney().Convert(rate.Rate, currency)
+			task.DisplayBudget = &display
+		}
+	}
+}
+
+func respondExchangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrExchangeRateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "EXCHANGE_RATE_NOT_FOUND"})
+	case errors.Is(err, services.ErrInvalidExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_EXCHANGE_RATE"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process exchange rate"})
+	}
+}
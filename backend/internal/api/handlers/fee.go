@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type FeeHandler struct {
+	db *gorm.DB
+}
+
+func NewFeeHandler(db *gorm.DB) *FeeHandler {
+	return &FeeHandler{db: db}
+}
+
+type FeeRuleRequest struct {
+	Name     string           `json:"name" binding:"required,max=100"`
+	Side     string           `json:"side" binding:"required,oneof=poster tasker"`
+	Category string           `json:"category" binding:"max=100"`
+	TaskType string           `json:"task_type"`
+	Currency string           `json:"currency"`
+	Percent  decimal.Decimal  `json:"percent"`
+	Flat     decimal.Decimal  `json:"flat"`
+	MinFee   *decimal.Decimal `json:"min_fee"`
+	MaxFee   *decimal.Decimal `json:"max_fee"`
+	Promo    bool             `json:"promo"`
+	StartsAt *time.Time       `json:"starts_at"`
+	EndsAt   *time.Time       `json:"ends_at"`
+	Active   *bool            `json:"active"`
+}
+
+// UpdateFeeRuleRequest changes a fee rule; the clear flags remove a cap or window
+// bound, since a null cannot be told apart from an omitted field
+type UpdateFeeRuleRequest struct {
+	Name          *string          `json:"name" binding:"omitempty,max=100"`
+	Category      *string          `json:"category" binding:"omitempty,max=100"`
+	TaskType      *string          `json:"task_type"`
+	Currency      *string          `json:"currency"`
+	Percent       *decimal.Decimal `json:"percent"`
+	Flat          *decimal.Decimal `json:"flat"`
+	MinFee        *decimal.Decimal `json:"min_fee"`
+	MaxFee        *decimal.Decimal `json:"max_fee"`
+	Promo         *bool            `json:"promo"`
+	StartsAt      *time.Time       `json:"starts_at"`
+	EndsAt        *time.Time       `json:"ends_at"`
+	Active        *bool            `json:"active"`
+	ClearMinFee   bool             `json:"clear_min_fee"`
+	ClearMaxFee   bool             `json:"clear_max_fee"`
+	ClearStartsAt bool             `json:"clear_starts_at"`
+	ClearEndsAt   bool             `json:"clear_ends_at"`
+}
+
+// AdminListFeeRules returns every fee rule, inactive and expired ones included
+// GET /admin/fee-rules
+func (h *FeeHandler) AdminListFeeRules(c *gin.Context) {
+	var rules []models.FeeRule
+	if err := h.db.Order("side, promo DESC, category, task_type, created_at").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fee rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// AdminCreateFeeRule adds a fee rule, which prices offers from then on
+// POST /admin/fee-rules
+func (h *FeeHandler) AdminCreateFeeRule(c *gin.Context) {
+	var req FeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.FeeRule{
+		Name:     req.Name,
+		Side:     req.Side,
+		Category: req.Category,
+		TaskType: req.TaskType,
+		Currency: req.Currency,
+		Percent:  req.Percent,
+		Flat:     req.Flat,
+		MinFee:   req.MinFee,
+		MaxFee:   req.MaxFee,
+		Promo:    req.Promo,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Active:   req.Active == nil || *req.Active,
+	}
+	if err := services.CreateFeeRule(h.db, &rule); err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// AdminUpdateFeeRule changes a fee rule's pricing, scope, window or active flag
+// PATCH /admin/fee-rules/:id
+func (h *FeeHandler) AdminUpdateFeeRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee rule ID"})
+		return
+	}
+
+	var req UpdateFeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.UpdateFeeRule(h.db, id, services.FeeRulePatch{
+		Name:          req.Name,
+		Category:      req.Category,
+		TaskType:      req.TaskType,
+		Currency:      req.Currency,
+		Percent:       req.Percent,
+		Flat:          req.Flat,
+		MinFee:        req.MinFee,
+		MaxFee:        req.MaxFee,
+		Promo:         req.Promo,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		Active:        req.Active,
+		ClearMinFee:   req.ClearMinFee,
+		ClearMaxFee:   req.ClearMaxFee,
+		ClearStartsAt: req.ClearStartsAt,
+		ClearEndsAt:   req.ClearEndsAt,
+	})
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// AdminDeleteFeeRule removes a fee rule; escrow it priced keeps its fees
+// DELETE /admin/fee-rules/:id
+func (h *FeeHandler) AdminDeleteFeeRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee rule ID"})
+		return
+	}
+
+	if err := services.DeleteFeeRule(h.db, id); err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fee rule deleted"})
+}
+
+// AdminPreviewFees prices an amount for a category and task type with the rules
+// in force, or at ?at= (RFC 3339) to check a scheduled promotion
+// GET /admin/fee-rules/preview?amount=&currency=&category=&task_type=
+func (h *FeeHandler) AdminPreviewFees(c *gin.Context) {
+	amount, err := decimal.NewFromString(c.Query("amount"))
+	if err != nil || amount.IsNegative() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be a non-negative number"})
+		return
+	}
+	currency, err := money.ParseCurrency(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	at := time.Now()
+	if raw := c.Query("at"); raw != "" {
+		if at, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at. Use RFC 3339"})
+			return
+		}
+	}
+
+	task := models.Task{Category: c.Query("category"), TaskType: c.DefaultQuery("task_type", "service"), Currency: currency}
+	quote, err := services.QuoteFees(h.db, &task, amount, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to price fees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func respondFeeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFeeRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_FEE_RULE"})
+	case errors.Is(err, services.ErrFeeRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update fee rules"})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchRadiusKm = 25.0
+	maxSearchRadiusKm     = 500.0
+)
+
+// parseGeoFilter reads lat, lng, radius_km and bbox (min_lng,min_lat,max_lng,max_lat)
+// query parameters. Both lat and lng must be given together.
+func parseGeoFilter(c *gin.Context) (services.GeoFilter, error) {
+	var filter services.GeoFilter
+
+	latStr, lngStr := c.Query("lat"), c.Query("lng")
+	if (latStr == "") != (lngStr == "") {
+		return filter, errors.New("lat and lng must be provided together")
+	}
+	if latStr != "" {
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return filter, errors.New("invalid lat")
+		}
+		lng, err := strconv.ParseFloat(lngStr, 64)
+		if err != nil || lng < -180 || lng > 180 {
+			return filter, errors.New("invalid lng")
+		}
+		filter.Lat = &lat
+		filter.Lng = &lng
+		filter.RadiusKm = defaultSearchRadiusKm
+	}
+
+	if radiusStr := c.Query("radius_km"); radiusStr != "" {
+		if !filter.HasPoint() {
+			return filter, errors.New("radius_km requires lat and lng")
+		}
+		radius, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 || radius > maxSearchRadiusKm {
+			return filter, errors.New("radius_km must be between 0 and 500")
+		}
+		filter.RadiusKm = radius
+	}
+
+	if bboxStr := c.Query("bbox"); bboxStr != "" {
+		parts := strings.Split(bboxStr, ",")
+		if len(parts) != 4 {
+			return filter, errors.New("bbox must be min_lng,min_lat,max_lng,max_lat")
+		}
+		var coords [4]float64
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return filter, errors.New("bbox must contain numbers")
+			}
+			coords[i] = v
+		}
+		box := services.BoundingBox{MinLng: coords[0], MinLat: coords[1], MaxLng: coords[2], MaxLat: coords[3]}
+		if box.MinLat > box.MaxLat || box.MinLng > box.MaxLng || box.MinLat < -90 || box.MaxLat > 90 || box.MinLng < -180 || box.MaxLng > 180 {
+			return filter, errors.New("bbox is out of range")
+		}
+		filter.BBox = &box
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type HireHandler struct {
+	hire *services.HireService
+}
+
+func NewHireHandler(hire *services.HireService) *HireHandler {
+	return &HireHandler{hire: hire}
+}
+
+type HireEventRequest struct {
+	OccurredAt  *time.Time `json:"occurred_at"`
+	Lat         *float64   `json:"lat" binding:"omitempty,min=-90,max=90"`
+	Lng         *float64   `json:"lng" binding:"omitempty,min=-180,max=180"`
+	EngineHours *float64   `json:"engine_hours" binding:"omitempty,min=0"`
+	Photos      []string   `json:"photos" binding:"max=10,dive,url"`
+	Note        string     `json:"note" binding:"max=1000"`
+}
+
+// hireEventKinds maps the :kind route segment onto hire event kinds
+var hireEventKinds = map[string]string{
+	"check-in":  models.HireEventCheckIn,
+	"check-out": models.HireEventCheckOut,
+}
+
+// CheckIn records the equipment arriving on site
+func (h *HireHandler) CheckIn(c *gin.Context) {
+	h.record(c, models.HireEventCheckIn)
+}
+
+// CheckOut records the equipment leaving site
+func (h *HireHandler) CheckOut(c *gin.Context) {
+	h.record(c, models.HireEventCheckOut)
+}
+
+func (h *HireHandler) record(c *gin.Context, kind string) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req HireEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.OccurredAt != nil && req.OccurredAt.After(time.Now().Add(5*time.Minute)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "occurred_at must not be in the future"})
+		return
+	}
+
+	reading := services.HireReading{
+		OccurredAt: req.OccurredAt,
+		Lat:        req.Lat,
+		Lng:        req.Lng,
+		Photos:     req.Photos,
+		Note:       req.Note,
+	}
+	if req.EngineHours != nil {
+		hours := decimal.NewFromFloat(*req.EngineHours).Round(1)
+		reading.EngineHours = &hours
+	}
+
+	event, err := h.hire.Record(taskID, userID.(uuid.UUID), kind, reading)
+	if err != nil {
+		respondHireError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// ConfirmEvent lets the other party confirm a recorded check-in or check-out.
+// Confirming the check-out returns the metered settlement as well.
+func (h *HireHandler) ConfirmEvent(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	kind, ok := hireEventKinds[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be check-in or check-out"})
+		return
+	}
+
+	event, settlement, err := h.hire.Confirm(taskID, userID.(uuid.UUID), kind)
+	if err != nil {
+		respondHireError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event, "settlement": settlement})
+}
+
+// GetHire returns the task's check-in, check-out and metered settlement
+func (h *HireHandler) GetHire(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	events, settlement, err := h.hire.Events(taskID, userID.(uuid.UUID))
+	if err != nil {
+		respondHireError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events, "settlement": settlement})
+}
+
+// respondHireError maps hire service errors onto HTTP responses
+func respondHireError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotHireParty), errors.Is(err, services.ErrConfirmOwnHireEvent):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrHireEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEquipmentHire):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "NOT_EQUIPMENT_HIRE"})
+	case errors.Is(err, services.ErrHireEventConfirmed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "HIRE_EVENT_CONFIRMED"})
+	case errors.Is(err, services.ErrCheckInRequired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "CHECK_IN_REQUIRED"})
+	case errors.Is(err, services.ErrInvalidHireReading):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		respondLifecycleError(c, err)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InstantBookRequest struct {
+	StartsAt         time.Time `json:"starts_at" binding:"required"`
+	HireDurationType string    `json:"hire_duration_type" binding:"required,oneof=hourly daily weekly monthly"`
+	Duration         int       `json:"duration" binding:"required,min=1,max=365"`
+	WithOperator     bool      `json:"with_operator"`
+	FuelIncluded     bool      `json:"fuel_included"`
+	Location         string    `json:"location" binding:"required"`
+	Lat              *float64  `json:"lat"`
+	Lng              *float64  `json:"lng"`
+	Notes            string    `json:"notes" binding:"max=2000"`
+}
+
+// InstantBook hires an instant-book item at its published rates, creating the
+// task, accepted offer, booking, escrow and conversation in one go. Clients should
+// send an Idempotency-Key header so a retried request returns the original result.
+func (h *OfferHandler) InstantBook(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	var req InstantBookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.StartsAt.Before(time.Now().Add(-5 * time.Minute)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "starts_at must not be in the past"})
+		return
+	}
+
+	key := c.GetHeader("Idempotency-Key")
+	if len(key) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+		return
+	}
+
+	result, err := h.offers.InstantBook(itemID, userID.(uuid.UUID), services.InstantBookRequest{
+		StartsAt:         req.StartsAt,
+		HireDurationType: req.HireDurationType,
+		Duration:         req.Duration,
+		WithOperator:     req.WithOperator,
+		FuelIncluded:     req.FuelIncluded,
+		Location:         req.Location,
+		Lat:              req.Lat,
+		Lng:              req.Lng,
+		Notes:            req.Notes,
+	}, key)
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if result.Replayed {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
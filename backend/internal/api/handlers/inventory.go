@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
-	"path/filepath"
-	"time"
 
 	"github.com/airmassxpress/backend/internal/config"
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
 	"github.com/airmassxpress/backend/internal/services"
+	"github.com/airmassxpress/backend/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -16,32 +15,38 @@ import (
 )
 
 type InventoryHandler struct {
-	cfg      *config.Config
-	db       *gorm.DB
-	supabase *services.SupabaseService
+	cfg     *config.Config
+	db      *gorm.DB
+	storage storage.Storage
+	geo     *services.GeoService
+	images  *services.ImagePipeline
 }
 
-func NewInventoryHandler(cfg *config.Config, db *gorm.DB, supabase *services.SupabaseService) *InventoryHandler {
-	return &InventoryHandler{cfg: cfg, db: db, supabase: supabase}
+func NewInventoryHandler(cfg *config.Config, db *gorm.DB, store storage.Storage, geo *services.GeoService, images *services.ImagePipeline) *InventoryHandler {
+	return &InventoryHandler{cfg: cfg, db: db, storage: store, geo: geo, images: images}
 }
 
 type CreateInventoryItemRequest struct {
-	Name            string   `json:"name" binding:"required"`
-	Category        string   `json:"category" binding:"required"`
-	Capacity        string   `json:"capacity"`
-	CapacityID      *string  `json:"capacity_id"`
-	Location        string   `json:"location"`
-	Photos          []string `json:"photos"`
-	IsAvailable     bool     `json:"is_available"`
-	WithOperator    bool     `json:"with_operator"`
-	OperatorBundled bool     `json:"operator_bundled"`
-	HourlyRate      *float64 `json:"hourly_rate"`
-	DailyRate       *float64 `json:"daily_rate"`
-	WeeklyRate      *float64 `json:"weekly_rate"`
-	DeliveryFee     *float64 `json:"delivery_fee"`
-	OperatorFee     *float64 `json:"operator_fee"`
-	Lat             *float64 `json:"lat"`
-	Lng             *float64 `json:"lng"`
+	Name            string           `json:"name" binding:"required"`
+	Category        string           `json:"category" binding:"required"`
+	Capacity        string           `json:"capacity"`
+	CapacityID      *string          `json:"capacity_id"`
+	Location        string           `json:"location"`
+	Photos          []string         `json:"photos"`
+	IsAvailable     bool             `json:"is_available"`
+	WithOperator    bool             `json:"with_operator"`
+	OperatorBundled bool             `json:"operator_bundled"`
+	HourlyRate      *decimal.Decimal `json:"hourly_rate"`
+	DailyRate       *decimal.Decimal `json:"daily_rate"`
+	WeeklyRate      *decimal.Decimal `json:"weekly_rate"`
+	DeliveryFee     *decimal.Decimal `json:"delivery_fee"`
+	OperatorFee     *decimal.Decimal `json:"operator_fee"`
+	FuelRate        *decimal.Decimal `json:"fuel_rate"`
+	// Currency the rates and fees are in; USD when first listed, kept on update if omitted
+	Currency    string   `json:"currency"`
+	BookingMode string   `json:"booking_mode" binding:"omitempty,oneof=request instant"`
+	Lat         *float64 `json:"lat"`
+	Lng         *float64 `json:"lng"`
 }
 
 // GetMyInventory list items for current user
@@ -85,34 +90,45 @@ func (h *InventoryHandler) CreateInventoryItem(c *gin.Context) {
 		IsAvailable:     req.IsAvailable,
 		WithOperator:    req.WithOperator,
 		OperatorBundled: req.OperatorBundled,
+		BookingMode:     models.BookingModeRequest,
 	}
+	if req.BookingMode != "" {
+		item.BookingMode = req.BookingMode
+	}
+	currency, err := money.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item.Currency = currency
 
 	// V2 optional fields
 	if req.CapacityID != nil {
 		capID, err := uuid.Parse(*req.CapacityID)
 		if err == nil {
+			if !requireActiveCapacity(c, h.db, capID) {
+				return
+			}
 			item.CapacityID = &capID
 		}
 	}
 	if req.HourlyRate != nil {
-		d := decimal.NewFromFloat(*req.HourlyRate)
-		item.HourlyRate = &d
+		item.HourlyRate = req.HourlyRate
 	}
 	if req.DailyRate != nil {
-		d := decimal.NewFromFloat(*req.DailyRate)
-		item.DailyRate = &d
+		item.DailyRate = req.DailyRate
 	}
 	if req.WeeklyRate != nil {
-		d := decimal.NewFromFloat(*req.WeeklyRate)
-		item.WeeklyRate = &d
+		item.WeeklyRate = req.WeeklyRate
 	}
 	if req.DeliveryFee != nil {
-		d := decimal.NewFromFloat(*req.DeliveryFee)
-		item.DeliveryFee = &d
+		item.DeliveryFee = req.DeliveryFee
 	}
 	if req.OperatorFee != nil {
-		d := decimal.NewFromFloat(*req.OperatorFee)
-		item.OperatorFee = &d
+		item.OperatorFee = req.OperatorFee
+	}
+	if req.FuelRate != nil {
+		item.FuelRate = req.FuelRate
 	}
 	if req.Lat != nil {
 		item.Lat = req.Lat
@@ -125,6 +141,9 @@ func (h *InventoryHandler) CreateInventoryItem(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create item"})
 		return
 	}
+	if len(item.Photos) > 0 {
+		h.images.ProcessInventoryItem(item.ID)
+	}
 
 	c.JSON(http.StatusCreated, item)
 }
@@ -173,8 +192,25 @@ func (h *InventoryHandler) UpdateInventoryItem(c *gin.Context) {
 	item.Capacity = req.Capacity
 	item.Location = req.Location
 	item.IsAvailable = req.IsAvailable
+	if item.ComplianceHold {
+		// An expired certificate keeps the item off the market until it is renewed.
+		// Marking it unavailable drops the hold, so the renewal leaves it unavailable.
+		item.ComplianceHold = req.IsAvailable
+		item.IsAvailable = false
+	}
 	item.WithOperator = req.WithOperator
 	item.OperatorBundled = req.OperatorBundled
+	if req.BookingMode != "" {
+		item.BookingMode = req.BookingMode
+	}
+	if req.Currency != "" {
+		currency, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		item.Currency = currency
+	}
 
 	if len(req.Photos) > 0 {
 		item.Photos = req.Photos
@@ -184,6 +220,10 @@ func (h *InventoryHandler) UpdateInventoryItem(c *gin.Context) {
 	if req.CapacityID != nil {
 		capID, err := uuid.Parse(*req.CapacityID)
 		if err == nil {
+			// Items keep a tier retired since they chose it, but cannot move to one
+			if (item.CapacityID == nil || *item.CapacityID != capID) && !requireActiveCapacity(c, h.db, capID) {
+				return
+			}
 			item.CapacityID = &capID
 		}
 	} else {
@@ -194,24 +234,22 @@ func (h *InventoryHandler) UpdateInventoryItem(c *gin.Context) {
 	}
 
 	if req.HourlyRate != nil {
-		d := decimal.NewFromFloat(*req.HourlyRate)
-		item.HourlyRate = &d
+		item.HourlyRate = req.HourlyRate
 	}
 	if req.DailyRate != nil {
-		d := decimal.NewFromFloat(*req.DailyRate)
-		item.DailyRate = &d
+		item.DailyRate = req.DailyRate
 	}
 	if req.WeeklyRate != nil {
-		d := decimal.NewFromFloat(*req.WeeklyRate)
-		item.WeeklyRate = &d
+		item.WeeklyRate = req.WeeklyRate
 	}
 	if req.DeliveryFee != nil {
-		d := decimal.NewFromFloat(*req.DeliveryFee)
-		item.DeliveryFee = &d
+		item.DeliveryFee = req.DeliveryFee
 	}
 	if req.OperatorFee != nil {
-		d := decimal.NewFromFloat(*req.OperatorFee)
-		item.OperatorFee = &d
+		item.OperatorFee = req.OperatorFee
+	}
+	if req.FuelRate != nil {
+		item.FuelRate = req.FuelRate
 	}
 	if req.Lat != nil {
 		item.Lat = req.Lat
@@ -220,10 +258,12 @@ func (h *InventoryHandler) UpdateInventoryItem(c *gin.Context) {
 		item.Lng = req.Lng
 	}
 
-	if err := h.db.Save(&item).Error; err != nil {
+	// Variants are written by the image pipeline, which may be running concurrently
+	if err := h.db.Omit("photo_variants").Save(&item).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
 		return
 	}
+	h.images.ProcessInventoryItem(item.ID)
 
 	c.JSON(http.StatusOK, item)
 }
@@ -236,19 +276,12 @@ func (h *InventoryHandler) UploadImage(c *gin.Context) {
 		return
 	}
 
-	// Generate unique path: inventory/{timestamp}-{uniqueId}-{filename}
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%d-%s%s", time.Now().UnixNano(), uuid.New().String(), ext)
-	path := fmt.Sprintf("inventory/%s", filename)
-
-	// Upload using Supabase Service
-	publicURL, err := h.supabase.UploadFile(file, "uploads", path)
+	userID, _ := c.Get("user_id")
+	object, err := storage.UploadFile(c.Request.Context(), h.storage, storage.Images, "inventory/"+userID.(uuid.UUID).String(), file)
 	if err != nil {
-		// Log error internally
-		fmt.Printf("Supabase upload error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file to storage"})
+		respondStorageError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"url": publicURL})
+	c.JSON(http.StatusOK, gin.H{"url": object.URL})
 }
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAvailabilityDays = 90
+	maxAvailabilityDays     = 366
+)
+
+type CreateBlockoutRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason" binding:"max=500"`
+}
+
+type BookingModeRequest struct {
+	BookingMode string `json:"booking_mode" binding:"required,oneof=request instant"`
+}
+
+// busyPeriod is a booking as shown to anyone other than the item's owner
+type busyPeriod struct {
+	Kind     string    `json:"kind"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// GetAvailability lists the item's active bookings between ?from and ?to (RFC 3339
+// or YYYY-MM-DD; the next 90 days by default). Owners see the full bookings, others
+// only when the item is busy.
+func (h *InventoryHandler) GetAvailability(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	from := time.Now()
+	if raw := c.Query("from"); raw != "" {
+		if from, err = parseAvailabilityTime(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return
+		}
+	}
+	to := from.AddDate(0, 0, defaultAvailabilityDays)
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseAvailabilityTime(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return
+		}
+	}
+	if !to.After(from) || to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from and at most a year later"})
+		return
+	}
+
+	var item models.InventoryItem
+	if err := h.db.Select("id", "user_id").First(&item, "id = ?", itemID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	bookings, err := services.OverlappingBookings(h.db, itemID, from, to, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	response := gin.H{"inventory_id": itemID, "from": from, "to": to}
+	if item.UserID == userID.(uuid.UUID) {
+		response["bookings"] = bookings
+	} else {
+		busy := make([]busyPeriod, len(bookings))
+		for i, booking := range bookings {
+			busy[i] = busyPeriod{Kind: booking.Kind, StartsAt: booking.StartsAt, EndsAt: booking.EndsAt}
+		}
+		response["bookings"] = busy
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateBlockout sets aside a maintenance or other window on the caller's item
+func (h *InventoryHandler) CreateBlockout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	var req CreateBlockoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	blockout, err := services.CreateBlockout(h.db, itemID, userID.(uuid.UUID), req.StartsAt, req.EndsAt, req.Reason)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, blockout)
+}
+
+// DeleteBlockout lifts one of the caller's blockouts
+func (h *InventoryHandler) DeleteBlockout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+	bookingID, err := uuid.Parse(c.Param("bookingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	if err := services.ReleaseBlockout(h.db, itemID, bookingID, userID.(uuid.UUID)); err != nil {
+		respondBookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Blockout removed"})
+}
+
+// SetBookingMode switches the caller's item between request-to-book and instant-book
+func (h *InventoryHandler) SetBookingMode(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req BookingModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var item models.InventoryItem
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	if err := h.db.Model(&item).Update("booking_mode", req.BookingMode).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking mode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// respondBookingError maps booking service errors onto HTTP responses
+func respondBookingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInventoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case errors.Is(err, services.ErrInventoryNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBookingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Blockout not found"})
+	case errors.Is(err, services.ErrInvalidBookingWindow):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBlockoutOverlapsHire):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "EQUIPMENT_BOOKED"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bookings"})
+	}
+}
+
+func parseAvailabilityTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
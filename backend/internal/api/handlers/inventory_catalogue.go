@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Public listings only reveal coordinates to two decimal places (about 1 km)
+const catalogueCoordinatePrecision = 100
+
+// catalogueRateColumns maps the rate_type filter onto the rate it compares
+var catalogueRateColumns = map[string]string{
+	"hourly": "inventory_items.hourly_rate",
+	"daily":  "inventory_items.daily_rate",
+	"weekly": "inventory_items.weekly_rate",
+}
+
+// CatalogueOwner is the public view of an item's owner
+type CatalogueOwner struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	AvatarURL      string    `json:"avatar_url,omitempty"`
+	IsVerified     bool      `json:"is_verified"`
+	Rating         float64   `json:"rating"`
+	ReviewCount    int       `json:"review_count"`
+	TasksCompleted int       `json:"tasks_completed"`
+}
+
+// CatalogueItem is the public view of an inventory item. Coordinates are rounded
+// and the distance is given to the nearest kilometre.
+type CatalogueItem struct {
+	ID                uuid.UUID                 `json:"id"`
+	Name              string                    `json:"name"`
+	Category          string                    `json:"category"`
+	Capacity          string                    `json:"capacity,omitempty"`
+	EquipmentCapacity *models.EquipmentCapacity `json:"equipment_capacity,omitempty"`
+	Location          string                    `json:"location,omitempty"`
+	Photos            []string                  `json:"photos,omitempty"`
+	PhotoVariants     []models.PhotoVariant     `json:"photo_variants,omitempty"`
+	IsAvailable       bool                      `json:"is_available"`
+	WithOperator      bool                      `json:"with_operator"`
+	OperatorBundled   bool                      `json:"operator_bundled"`
+	BookingMode       string                    `json:"booking_mode"`
+	Currency          string                    `json:"currency"`
+	HourlyRate        *decimal.Decimal          `json:"hourly_rate,omitempty"`
+	DailyRate         *decimal.Decimal          `json:"daily_rate,omitempty"`
+	WeeklyRate        *decimal.Decimal          `json:"weekly_rate,omitempty"`
+	DeliveryFee       *decimal.Decimal          `json:"delivery_fee,omitempty"`
+	OperatorFee       *decimal.Decimal          `json:"operator_fee,omitempty"`
+	FuelRate          *decimal.Decimal          `json:"fuel_rate,omitempty"`
+	ApproxLat         *float64                  `json:"approx_lat,omitempty"`
+	ApproxLng         *float64                  `json:"approx_lng,omitempty"`
+	DistanceKm        *float64                  `json:"distance_km,omitempty"`
+	Compliance        *ComplianceSummary        `json:"compliance,omitempty"`
+	Owner             *CatalogueOwner           `json:"owner,omitempty"`
+}
+
+// SearchInventory is the public equipment catalogue, filtered by equipment_type,
+// category, capacity_id, with_operator, booking_mode, currency, available_from/available_to,
+// rate_type with min_rate/max_rate, and lat/lng/radius_km or bbox
+// GET /inventory/search
+func (h *InventoryHandler) SearchInventory(c *gin.Context) {
+	geoFilter, err := parseGeoFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.db.Model(&models.InventoryItem{}).
+		Preload("EquipmentCapacity").Preload("User").
+		Joins("JOIN users ON users.id = inventory_items.user_id AND users.deleted_at IS NULL").
+		Where("inventory_items.is_available = ?", true)
+
+	if equipmentType := c.Query("equipment_type"); equipmentType != "" {
+		query = query.Where("(inventory_items.category = ? OR inventory_items.capacity_id IN (?))", equipmentType,
+			h.db.Model(&models.EquipmentCapacity{}).Select("id").Where("equipment_type = ?", equipmentType))
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("inventory_items.category = ?", category)
+	}
+	if capacityID := c.Query("capacity_id"); capacityID != "" {
+		query = query.Where("inventory_items.capacity_id = ?", capacityID)
+	}
+	if raw := c.Query("with_operator"); raw != "" {
+		withOperator, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "with_operator must be true or false"})
+			return
+		}
+		query = query.Where("inventory_items.with_operator = ?", withOperator)
+	}
+	if bookingMode := c.Query("booking_mode"); bookingMode != "" {
+		query = query.Where("inventory_items.booking_mode = ?", bookingMode)
+	}
+	currency, ok := currencyQuery(c, "currency")
+	if !ok {
+		return
+	}
+	if currency != "" {
+		query = query.Where("inventory_items.currency = ?", currency)
+	}
+
+	query, err = applyAvailabilityFilter(c, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query, err = applyRateFilter(c, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query = h.geo.Apply(query, "inventory_items", geoFilter)
+	if geoFilter.HasPoint() {
+		expr, args := h.geo.DistanceExpr("inventory_items", *geoFilter.Lat, *geoFilter.Lng)
+		query = selectComputed(query, "inventory_items", []computedColumn{{Expr: expr, Args: args, As: "distance_km"}}).
+			Order("distance_km asc")
+	} else {
+		query = query.Select("inventory_items.*").Order("inventory_items.created_at desc")
+	}
+
+	var items []models.InventoryItem
+	if err := query.Limit(100).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search inventory"})
+		return
+	}
+
+	results := make([]CatalogueItem, len(items))
+	for i := range items {
+		results[i] = catalogueItem(&items[i])
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GetCatalogueItem returns the public view of a single item
+func (h *InventoryHandler) GetCatalogueItem(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	var item models.InventoryItem
+	if err := h.db.Preload("EquipmentCapacity").Preload("User").First(&item, "id = ?", itemID).Error; err != nil || item.User == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, catalogueItem(&item))
+}
+
+// applyAvailabilityFilter keeps items with no active booking overlapping
+// [available_from, available_to)
+func applyAvailabilityFilter(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	fromStr, toStr := c.Query("available_from"), c.Query("available_to")
+	if fromStr == "" && toStr == "" {
+		return query, nil
+	}
+	if fromStr == "" || toStr == "" {
+		return nil, errors.New("available_from and available_to must be provided together")
+	}
+	from, err := parseAvailabilityTime(fromStr)
+	if err != nil {
+		return nil, errors.New("invalid available_from")
+	}
+	to, err := parseAvailabilityTime(toStr)
+	if err != nil {
+		return nil, errors.New("invalid available_to")
+	}
+	if !to.After(from) || to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		return nil, errors.New("available_to must be after available_from and at most a year later")
+	}
+
+	return query.Where(`NOT EXISTS (SELECT 1 FROM equipment_bookings
+		WHERE equipment_bookings.inventory_id = inventory_items.id
+		AND equipment_bookings.status = ? AND equipment_bookings.starts_at < ? AND equipment_bookings.ends_at > ?)`,
+		models.BookingStatusActive, to, from), nil
+}
+
+// applyRateFilter bounds the rate chosen by rate_type (daily by default) with
+// min_rate and max_rate; items without that rate are excluded
+func applyRateFilter(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	minStr, maxStr := c.Query("min_rate"), c.Query("max_rate")
+	if minStr == "" && maxStr == "" {
+		return query, nil
+	}
+
+	rateType := c.DefaultQuery("rate_type", "daily")
+	column, ok := catalogueRateColumns[rateType]
+	if !ok {
+		return nil, errors.New("rate_type must be hourly, daily or weekly")
+	}
+
+	query = query.Where(column + " IS NOT NULL")
+	if minStr != "" {
+		minRate, err := strconv.ParseFloat(minStr, 64)
+		if err != nil || minRate < 0 {
+			return nil, errors.New("invalid min_rate")
+		}
+		query = query.Where(column+" >= ?", minRate)
+	}
+	if maxStr != "" {
+		maxRate, err := strconv.ParseFloat(maxStr, 64)
+		if err != nil || maxRate < 0 {
+			return nil, errors.New("invalid max_rate")
+		}
+		query = query.Where(column+" <= ?", maxRate)
+	}
+	return query, nil
+}
+
+func catalogueItem(item *models.InventoryItem) CatalogueItem {
+	result := CatalogueItem{
+		ID:                item.ID,
+		Name:              item.Name,
+		Category:          item.Category,
+		Capacity:          item.Capacity,
+		EquipmentCapacity: item.EquipmentCapacity,
+		Location:          item.Location,
+		Photos:            item.Photos,
+		PhotoVariants:     item.PhotoVariants,
+		IsAvailable:       item.IsAvailable,
+		WithOperator:      item.WithOperator,
+		OperatorBundled:   item.OperatorBundled,
+		BookingMode:       item.BookingMode,
+		Currency:          item.Currency,
+		HourlyRate:        item.HourlyRate,
+		DailyRate:         item.DailyRate,
+		WeeklyRate:        item.WeeklyRate,
+		DeliveryFee:       item.DeliveryFee,
+		OperatorFee:       item.OperatorFee,
+		FuelRate:          item.FuelRate,
+		ApproxLat:         roundCoordinate(item.Lat),
+		ApproxLng:         roundCoordinate(item.Lng),
+		Compliance:        complianceSummary(item),
+	}
+	if item.DistanceKm != nil {
+		km := math.Max(1, math.Round(*item.DistanceKm))
+		result.DistanceKm = &km
+	}
+	if item.User != nil {
+		result.Owner = &CatalogueOwner{
+			ID:             item.User.ID,
+			Name:           item.User.Name,
+			AvatarURL:      item.User.AvatarURL,
+			IsVerified:     item.User.IsVerified,
+			Rating:         item.User.Rating,
+			ReviewCount:    item.User.ReviewCount,
+			TasksCompleted: item.User.TasksCompleted,
+		}
+	}
+	return result
+}
+
+func roundCoordinate(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	rounded := math.Round(*v*catalogueCoordinatePrecision) / catalogueCoordinatePrecision
+	return &rounded
+}
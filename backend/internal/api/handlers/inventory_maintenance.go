@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type MaintenancePartRequest struct {
+	Name       string `json:"name" binding:"required,max=200"`
+	PartNumber string `json:"part_number" binding:"max=100"`
+	Quantity   int    `json:"quantity" binding:"required,min=1"`
+}
+
+type MaintenanceRecordRequest struct {
+	Kind        string                   `json:"kind" binding:"required,oneof=service repair inspection"`
+	PerformedAt time.Time                `json:"performed_at" binding:"required"`
+	HourMeter   *float64                 `json:"hour_meter" binding:"omitempty,min=0"`
+	Description string                   `json:"description" binding:"required,max=2000"`
+	Parts       []MaintenancePartRequest `json:"parts" binding:"max=50,dive"`
+	Cost        *float64                 `json:"cost" binding:"omitempty,min=0"`
+	PerformedBy string                   `json:"performed_by" binding:"max=200"`
+}
+
+type CertificateRequest struct {
+	Kind        string     `json:"kind" binding:"required,oneof=inspection insurance other"`
+	Issuer      string     `json:"issuer" binding:"max=200"`
+	Number      string     `json:"number" binding:"max=100"`
+	DocumentURL string     `json:"document_url" binding:"required,url"`
+	IssuedAt    *time.Time `json:"issued_at"`
+	ExpiresAt   time.Time  `json:"expires_at" binding:"required"`
+}
+
+// ComplianceSummary is the public maintenance and certificate summary of an item
+type ComplianceSummary struct {
+	LastInspectedAt *time.Time `json:"last_inspected_at,omitempty"`
+	LastServicedAt  *time.Time `json:"last_serviced_at,omitempty"`
+	// Status is valid, expiring or expired, and empty when no certificate was uploaded
+	Status    string     `json:"status,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Warning is set while a certificate is expiring or has expired
+	Warning bool `json:"warning"`
+}
+
+// GetMaintenance returns the caller's maintenance log and certificates for an item
+func (h *InventoryHandler) GetMaintenance(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var item models.InventoryItem
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	var records []models.MaintenanceRecord
+	if err := h.db.Where("inventory_id = ?", item.ID).Order("performed_at DESC").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance records"})
+		return
+	}
+	var certificates []models.EquipmentCertificate
+	if err := h.db.Where("inventory_id = ?", item.ID).Order("expires_at DESC").Find(&certificates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"records":         records,
+		"certificates":    certificates,
+		"compliance":      complianceSummary(&item),
+		"compliance_hold": item.ComplianceHold,
+	})
+}
+
+// AddMaintenanceRecord logs a service, repair or inspection on the caller's item
+func (h *InventoryHandler) AddMaintenanceRecord(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	var req MaintenanceRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PerformedAt.After(time.Now().Add(5 * time.Minute)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "performed_at must not be in the future"})
+		return
+	}
+
+	record := models.MaintenanceRecord{
+		Kind:        req.Kind,
+		PerformedAt: req.PerformedAt,
+		Description: req.Description,
+		PerformedBy: req.PerformedBy,
+	}
+	for _, part := range req.Parts {
+		record.Parts = append(record.Parts, models.MaintenancePart{Name: part.Name, PartNumber: part.PartNumber, Quantity: part.Quantity})
+	}
+	if req.HourMeter != nil {
+		d := decimal.NewFromFloat(*req.HourMeter).Round(1)
+		record.HourMeter = &d
+	}
+	if req.Cost != nil {
+		d := decimal.NewFromFloat(*req.Cost).Round(2)
+		record.Cost = &d
+	}
+
+	if err := services.AddMaintenanceRecord(h.db, itemID, userID.(uuid.UUID), &record); err != nil {
+		respondMaintenanceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, record)
+}
+
+// DeleteMaintenanceRecord removes an entry from the caller's maintenance log
+func (h *InventoryHandler) DeleteMaintenanceRecord(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+	recordID, err := uuid.Parse(c.Param("recordId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record ID"})
+		return
+	}
+
+	if err := services.DeleteMaintenanceRecord(h.db, itemID, recordID, userID.(uuid.UUID)); err != nil {
+		respondMaintenanceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance record removed"})
+}
+
+// AddCertificate attaches an inspection, insurance or other certificate, uploaded
+// beforehand as a document, to the caller's item
+func (h *InventoryHandler) AddCertificate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	var req CertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.IssuedAt != nil && !req.ExpiresAt.After(*req.IssuedAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be after issued_at"})
+		return
+	}
+
+	certificate := models.EquipmentCertificate{
+		Kind:        req.Kind,
+		Issuer:      req.Issuer,
+		Number:      req.Number,
+		DocumentURL: req.DocumentURL,
+		IssuedAt:    req.IssuedAt,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	change, err := services.AddCertificate(h.db, itemID, userID.(uuid.UUID), &certificate)
+	if err != nil {
+		respondMaintenanceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"certificate": certificate, "released": change.Released})
+}
+
+// DeleteCertificate removes a certificate from the caller's item
+func (h *InventoryHandler) DeleteCertificate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+	certificateID, err := uuid.Parse(c.Param("certificateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid certificate ID"})
+		return
+	}
+
+	change, err := services.DeleteCertificate(h.db, itemID, certificateID, userID.(uuid.UUID))
+	if err != nil {
+		respondMaintenanceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Certificate removed", "held": change.Held})
+}
+
+// respondMaintenanceError maps maintenance service errors onto HTTP responses
+func respondMaintenanceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInventoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case errors.Is(err, services.ErrInventoryNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMaintenanceRecordNotFound), errors.Is(err, services.ErrCertificateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCertificateExpired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance records"})
+	}
+}
+
+func complianceSummary(item *models.InventoryItem) *ComplianceSummary {
+	if item.LastInspectedAt == nil && item.LastServicedAt == nil && item.ComplianceStatus == "" {
+		return nil
+	}
+	return &ComplianceSummary{
+		LastInspectedAt: item.LastInspectedAt,
+		LastServicedAt:  item.LastServicedAt,
+		Status:          item.ComplianceStatus,
+		ExpiresAt:       item.CertificatesExpireAt,
+		Warning:         item.ComplianceStatus == models.ComplianceExpiring || item.ComplianceStatus == models.ComplianceExpired,
+	}
+}
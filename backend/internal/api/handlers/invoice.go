@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type InvoiceHandler struct {
+	db       *gorm.DB
+	invoices *services.InvoiceService
+}
+
+func NewInvoiceHandler(db *gorm.DB, invoices *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{db: db, invoices: invoices}
+}
+
+type CreditNoteRequest struct {
+	// Amount defaults to whatever remains uncredited on the invoice
+	Amount *decimal.Decimal `json:"amount"`
+	Reason string           `json:"reason" binding:"required,max=1000"`
+}
+
+// ListInvoices returns the caller's invoices and credit notes, as poster or
+// tasker, newest first, one cursor page at a time. ?kind= limits it to invoice or
+// credit_note.
+func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kind := c.Query("kind")
+	if kind != "" && kind != models.InvoiceKindInvoice && kind != models.InvoiceKindCreditNote {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be invoice or credit_note"})
+		return
+	}
+
+	query := func() *gorm.DB {
+		return services.InvoiceListQuery(h.db, userID.(uuid.UUID), kind)
+	}
+	order := keyset{Expr: "issued_at", Cast: "timestamptz", IDColumn: "id", Desc: true}
+	if err := order.checkCursor(page.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	invoices, nextCursor, err := fetchPage(query(), page, order,
+		func(i *models.Invoice) (string, uuid.UUID) { return i.IssuedAt.Format(time.RFC3339Nano), i.ID })
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PageResponse{
+		Items:         invoices,
+		NextCursor:    nextCursor,
+		TotalEstimate: estimateTotal(h.db, query()),
+	})
+}
+
+// GetInvoice returns an invoice or credit note to its poster or tasker
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	invoice, ok := h.loadInvoice(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// DownloadInvoicePDF renders an invoice or credit note as a PDF download
+func (h *InvoiceHandler) DownloadInvoicePDF(c *gin.Context) {
+	invoice, ok := h.loadInvoice(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+invoice.Number+`.pdf"`)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Data(http.StatusOK, "application/pdf", services.RenderInvoicePDF(invoice))
+}
+
+// GetTaskInvoices lists a task's invoice and credit notes for its poster or tasker
+func (h *InvoiceHandler) GetTaskInvoices(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	invoices, err := h.invoices.TaskInvoices(taskID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+// AdminIssueCreditNote corrects an invoice with a credit note
+// POST /admin/invoices/:id/credit-notes
+func (h *InvoiceHandler) AdminIssueCreditNote(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	var req CreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.invoices.IssueCreditNote(invoiceID, req.Amount, req.Reason)
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+func (h *InvoiceHandler) loadInvoice(c *gin.Context) (*models.Invoice, bool) {
+	userID, _ := c.Get("user_id")
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return nil, false
+	}
+
+	invoice, err := h.invoices.Get(invoiceID, userID.(uuid.UUID))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return nil, false
+	}
+	return invoice, true
+}
+
+func respondInvoiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotInvoiceParty):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotCreditable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "NOT_CREDITABLE"})
+	case errors.Is(err, services.ErrInvalidCredit):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "INVALID_CREDIT"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process invoice"})
+	}
+}
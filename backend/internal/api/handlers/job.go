@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/scheduler"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const jobRunHistoryLimit = 50
+
+type JobHandler struct {
+	db        *gorm.DB
+	scheduler *scheduler.Scheduler
+}
+
+func NewJobHandler(db *gorm.DB, scheduler *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{db: db, scheduler: scheduler}
+}
+
+// ListJobs returns this instance's metrics for every scheduled job
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.scheduler.Stats())
+}
+
+// GetJobRuns returns the most recent recorded runs of a job across all instances
+func (h *JobHandler) GetJobRuns(c *gin.Context) {
+	var runs []models.JobRun
+	if err := h.db.Where("job_name = ?", c.Param("name")).
+		Order("started_at desc").
+		Limit(jobRunHistoryLimit).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// RunJob runs a job immediately, unless another instance is already running it,
+// and returns its updated metrics
+func (h *JobHandler) RunJob(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := h.jobStats(name); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	// Detach from the request so a client disconnect does not abort the job
+	if !h.scheduler.RunNow(context.Background(), name) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job is already running", "code": "JOB_RUNNING"})
+		return
+	}
+
+	stats, _ := h.jobStats(name)
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *JobHandler) jobStats(name string) (scheduler.JobStats, bool) {
+	for _, st := range h.scheduler.Stats() {
+		if st.Name == name {
+			return st, true
+		}
+	}
+	return scheduler.JobStats{}, false
+}
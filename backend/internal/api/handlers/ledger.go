@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LedgerHandler struct {
+	db *gorm.DB
+}
+
+func NewLedgerHandler(db *gorm.DB) *LedgerHandler {
+	return &LedgerHandler{db: db}
+}
+
+// GetWallet returns the caller's wallet balance in each currency
+func (h *LedgerHandler) GetWallet(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	balances, err := services.WalletBalances(h.db, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wallet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"balances": balances})
+}
+
+// GetWalletStatement returns the movements on the caller's wallet, newest first,
+// one cursor page at a time. ?currency= limits it to one currency.
+func (h *LedgerHandler) GetWalletStatement(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currency := strings.ToUpper(c.Query("currency"))
+
+	statement := func() *gorm.DB {
+		return services.WalletStatementQuery(h.db, userID.(uuid.UUID), currency)
+	}
+	order := keyset{Expr: "created_at", Cast: "timestamptz", IDColumn: "id", Desc: true}
+	if err := order.checkCursor(page.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	lines, nextCursor, err := fetchPage(statement(), page, order,
+		func(l *services.StatementLine) (string, uuid.UUID) { return l.CreatedAt.Format(time.RFC3339Nano), l.ID })
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PageResponse{
+		Items:         lines,
+		NextCursor:    nextCursor,
+		TotalEstimate: estimateTotal(h.db, statement()),
+	})
+}
+
+// AdminReconciliation checks that the ledger balances and agrees with escrow and
+// payment records
+func (h *LedgerHandler) AdminReconciliation(c *gin.Context) {
+	report, err := services.Reconcile(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile ledger"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/airmassxpress/backend/internal/models"
 
@@ -18,16 +19,41 @@ func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
 	return &NotificationHandler{db: db}
 }
 
+// ListNotifications returns the user's notifications, newest first, one cursor page at a time
 func (h *NotificationHandler) ListNotifications(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	var notifications []models.Notification
-	if err := h.db.Where("user_id = ?", userID).Order("created_at desc").Limit(50).Find(&notifications).Error; err != nil {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := func(query *gorm.DB) *gorm.DB {
+		query = query.Where("user_id = ?", userID)
+		if c.Query("unread") == "true" {
+			query = query.Where("read = ?", false)
+		}
+		return query
+	}
+
+	order := keyset{Expr: "created_at", Cast: "timestamptz", IDColumn: "id", Desc: true}
+	if err := order.checkCursor(page.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	notifications, nextCursor, err := fetchPage(filter(h.db.Model(&models.Notification{})), page, order,
+		func(n *models.Notification) (string, uuid.UUID) { return n.CreatedAt.Format(time.RFC3339Nano), n.ID })
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
 		return
 	}
 
-	c.JSON(http.StatusOK, notifications)
+	c.JSON(http.StatusOK, PageResponse{
+		Items:         notifications,
+		NextCursor:    nextCursor,
+		TotalEstimate: estimateTotal(h.db, filter(h.db.Model(&models.Notification{}))),
+	})
 }
 
 func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
@@ -2,36 +2,53 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/airmassxpress/backend/internal/config"
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
 	"github.com/airmassxpress/backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 type OfferHandler struct {
-	cfg *config.Config
-	db  *gorm.DB
-	fcm *services.FCMService
-	hub *services.Hub
+	cfg         *config.Config
+	db          *gorm.DB
+	fcm         *services.FCMService
+	hub         *services.Hub
+	lifecycle   *services.TaskLifecycle
+	offers      *services.OfferService
+	eligibility *services.EligibilityService
 }
 
-func NewOfferHandler(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub *services.Hub) *OfferHandler {
-	return &OfferHandler{cfg: cfg, db: db, fcm: fcm, hub: hub}
+func NewOfferHandler(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub *services.Hub, lifecycle *services.TaskLifecycle, offers *services.OfferService, eligibility *services.EligibilityService) *OfferHandler {
+	return &OfferHandler{cfg: cfg, db: db, fcm: fcm, hub: hub, lifecycle: lifecycle, offers: offers, eligibility: eligibility}
 }
 
 type CreateOfferRequest struct {
-	TaskID            string  `json:"task_id" binding:"required"`
-	Amount            float64 `json:"amount" binding:"required,gt=0"`
-	Description       string  `json:"description" binding:"required"`
+	TaskID      string          `json:"task_id" binding:"required"`
+	Amount      decimal.Decimal `json:"amount"`
+	Description string          `json:"description" binding:"required"`
+	// Currency defaults to the task's; any other currency is rejected
+	Currency string `json:"currency"`
+	// InventoryID makes a structured equipment quote and is required on equipment
+	// tasks; the amount is then computed from the item's rates and any amount sent is ignored
+	InventoryID       *string `json:"inventory_id"`
 	EstimatedDuration string  `json:"estimated_duration"`
 	Availability      string  `json:"availability"`
 }
 
+type QuotePreviewRequest struct {
+	TaskID      string `json:"task_id" binding:"required"`
+	InventoryID string `json:"inventory_id" binding:"required"`
+}
+
 func (h *OfferHandler) CreateOffer(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
@@ -71,43 +88,68 @@ func (h *OfferHandler) CreateOffer(c *gin.Context) {
 		return
 	}
 
-	if task.Status != "open" {
+	if task.Status != models.TaskStatusOpen {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Task is not open for offers"})
 		return
 	}
 
-	// Check Inventory for Equipment Tasks
-	if task.TaskType == "equipment" {
-		var itemCount int64
-		// Check if user has ANY item in inventory that matches the task category
-		if err := h.db.Model(&models.InventoryItem{}).
-			Where("user_id = ? AND category = ?", userID, task.Category).
-			Count(&itemCount).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check inventory"})
+	if req.Currency != "" {
+		currency, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		if itemCount == 0 {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":    "You must have registered equipment in this category to bid.",
-				"code":     "INVENTORY_REQUIRED",
-				"category": task.Category,
-			})
+		if currency != task.Currency {
+			respondOfferError(c, services.ErrOfferCurrency)
 			return
 		}
 	}
 
+	// Equipment offers must quote one of the tasker's qualifying items
+	if task.TaskType == "equipment" && req.InventoryID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Select one of your eligible equipment items to bid.",
+			"code":     "INVENTORY_REQUIRED",
+			"category": task.Category,
+		})
+		return
+	}
+
 	offer := models.Offer{
 		TaskID:            taskID,
 		TaskerID:          userID.(uuid.UUID),
-		Amount:            req.Amount,
+		Amount:            req.Amount.Round(2),
+		Currency:          task.Currency,
 		Description:       req.Description,
 		EstimatedDuration: req.EstimatedDuration,
 		Availability:      req.Availability,
 		Status:            "pending",
 	}
 
-	if err := h.db.Create(&offer).Error; err != nil {
+	if req.InventoryID != nil {
+		inventoryID, err := uuid.Parse(*req.InventoryID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+			return
+		}
+		quote, err := h.offers.QuoteTask(&task, inventoryID, userID.(uuid.UUID))
+		if err != nil {
+			respondOfferError(c, err)
+			return
+		}
+		quote.Apply(&offer)
+	} else if !offer.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount is required unless the offer quotes an inventory item"})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&offer).Error; err != nil {
+			return err
+		}
+		return services.RecordOfferRevision(tx, &offer, models.OfferRevisionCreated, &offer.TaskerID, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create offer"})
 		return
 	}
@@ -121,18 +163,14 @@ func (h *OfferHandler) CreateOffer(c *gin.Context) {
 		UserID:  task.PosterID,
 		Type:    "new_offer",
 		Title:   "New Offer Received",
-		Message: fmt.Sprintf("You received a new offer of $%.2f for %s", req.Amount, task.Title),
+		Message: fmt.Sprintf("You received a new offer of %s for %s", offer.AmountMoney(), task.Title),
 		Data:    dataJSON,
 	}
-	h.db.Create(&posterNotification)
-	if posterNotification.ID == uuid.Nil {
-		fmt.Printf("ERROR: Failed to create poster notification for offer creation. DB Error: %v\n", h.db.Error)
-	} else {
-		fmt.Printf("SUCCESS: Created poster notification %s for new offer\n", posterNotification.ID)
+	if err := h.db.Create(&posterNotification).Error; err != nil {
+		log.Printf("[Offers] Failed to notify poster %s of new offer %s: %v", task.PosterID, offer.ID, err)
+	} else if h.fcm != nil {
 		// Broadcast to WebSocket
-		if h.fcm != nil {
-			h.fcm.BroadcastNotification(&posterNotification)
-		}
+		h.fcm.BroadcastNotification(&posterNotification)
 	}
 
 	// Send push notification to task poster
@@ -141,7 +179,7 @@ func (h *OfferHandler) CreateOffer(c *gin.Context) {
 			err := h.fcm.SendNotification(
 				task.PosterID,
 				"New Offer Received",
-				fmt.Sprintf("You received a new offer of $%.2f for %s", req.Amount, task.Title),
+				fmt.Sprintf("You received a new offer of %s for %s", offer.AmountMoney(), task.Title),
 				map[string]string{
 					"type":     "new_offer",
 					"task_id":  task.ID.String(),
@@ -149,13 +187,14 @@ func (h *OfferHandler) CreateOffer(c *gin.Context) {
 				},
 			)
 			if err != nil {
-				fmt.Printf("Failed to send push notification to poster: %v\n", err)
+				log.Printf("[Offers] Failed to send push notification to poster %s: %v", task.PosterID, err)
 			}
 		}()
 	}
 
 	// Reload with relationships
 	h.db.Preload("Tasker").First(&offer, "id = ?", offer.ID)
+	attachOfferFees(h.db, &task, &offer)
 
 	// Broadcast to Task Room
 	h.hub.BroadcastToRoom("task_updates:"+taskID.String(), map[string]interface{}{
@@ -166,6 +205,65 @@ func (h *OfferHandler) CreateOffer(c *gin.Context) {
 	c.JSON(http.StatusCreated, offer)
 }
 
+// PreviewQuote computes the structured quote an offer with this inventory item
+// would carry, without creating the offer
+func (h *OfferHandler) PreviewQuote(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req QuotePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	taskID, err := uuid.Parse(req.TaskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	inventoryID, err := uuid.Parse(req.InventoryID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		return
+	}
+
+	quote, err := h.offers.Quote(taskID, inventoryID, userID.(uuid.UUID))
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// GetEligibleInventory lists the caller's inventory checked against an equipment
+// task, with the reasons each ineligible item cannot be offered
+func (h *OfferHandler) GetEligibleInventory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", taskID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if task.TaskType != "equipment" {
+		respondOfferError(c, services.ErrNotEquipmentTask)
+		return
+	}
+
+	items, err := h.eligibility.ForTask(&task, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check inventory"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
 func (h *OfferHandler) GetOffer(c *gin.Context) {
 	offerID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -179,9 +277,29 @@ func (h *OfferHandler) GetOffer(c *gin.Context) {
 		return
 	}
 
+	// The first time the poster opens an offer its amount becomes fixed
+	if userID, ok := c.Get("user_id"); ok {
+		if err := h.offers.MarkViewed(&offer, userID.(uuid.UUID)); err != nil {
+			log.Printf("[Offers] Failed to mark offer %s as viewed: %v", offer.ID, err)
+		}
+	}
+	if offer.Task != nil {
+		attachOfferFees(h.db, offer.Task, &offer)
+	}
+
 	c.JSON(http.StatusOK, offer)
 }
 
+// UpdateOfferRequest lists the offer fields a tasker may edit
+type UpdateOfferRequest struct {
+	Amount            *decimal.Decimal `json:"amount"`
+	Description       *string          `json:"description" binding:"omitempty,min=1"`
+	EstimatedDuration *string          `json:"estimated_duration"`
+	Availability      *string          `json:"availability"`
+}
+
+// UpdateOffer edits a pending offer (its tasker only). Each edit is kept as an offer
+// revision; the amount is locked once the poster has viewed the offer.
 func (h *OfferHandler) UpdateOffer(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	offerID, err := uuid.Parse(c.Param("id"))
@@ -190,33 +308,45 @@ func (h *OfferHandler) UpdateOffer(c *gin.Context) {
 		return
 	}
 
-	var offer models.Offer
-	if err := h.db.First(&offer, "id = ?", offerID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+	var req UpdateOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Verify ownership
-	if offer.TaskerID != userID.(uuid.UUID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+	if req.Amount != nil && !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than 0"})
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	offer, err := h.offers.Edit(offerID, userID.(uuid.UUID), services.OfferEdit{
+		Amount:            req.Amount,
+		Description:       req.Description,
+		EstimatedDuration: req.EstimatedDuration,
+		Availability:      req.Availability,
+	})
+	if err != nil {
+		respondOfferError(c, err)
 		return
 	}
-
-	if err := h.db.Model(&offer).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update offer"})
-		return
+	var task models.Task
+	if err := h.db.Select("id", "category", "task_type").First(&task, "id = ?", offer.TaskID).Error; err == nil {
+		attachOfferFees(h.db, &task, offer)
 	}
 
 	c.JSON(http.StatusOK, offer)
 }
 
-func (h *OfferHandler) WithdrawOffer(c *gin.Context) {
+// attachOfferFees prices the platform's fees on offers for display; a failure
+// leaves them unpriced rather than failing the request
+func attachOfferFees(db *gorm.DB, task *models.Task, offers ...*models.Offer) {
+	if err := services.AttachOfferFees(db, task, offers...); err != nil {
+		log.Printf("[Offers] Failed to price fees for task %s: %v", task.ID, err)
+	}
+}
+
+// MarkOfferViewed records that the poster has seen an offer, for clients that show
+// offers from the task payload rather than GET /offers/:id
+func (h *OfferHandler) MarkOfferViewed(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	offerID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -225,27 +355,25 @@ func (h *OfferHandler) WithdrawOffer(c *gin.Context) {
 	}
 
 	var offer models.Offer
-	if err := h.db.First(&offer, "id = ?", offerID).Error; err != nil {
+	if err := h.db.Preload("Task").First(&offer, "id = ?", offerID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
 		return
 	}
-
-	// Verify ownership
-	if offer.TaskerID != userID.(uuid.UUID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+	if offer.Task == nil || offer.Task.PosterID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only task poster can mark offers as viewed"})
 		return
 	}
-
-	offer.Status = "withdrawn"
-	if err := h.db.Save(&offer).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw offer"})
+	if err := h.offers.MarkViewed(&offer, userID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark offer as viewed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, offer)
+	c.JSON(http.StatusOK, gin.H{"viewed_by_poster_at": offer.ViewedByPosterAt})
 }
 
-func (h *OfferHandler) AcceptOffer(c *gin.Context) {
+// WithdrawOffer withdraws a pending offer (its tasker only); accepted offers are
+// released by cancelling or reopening the task instead
+func (h *OfferHandler) WithdrawOffer(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	offerID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -253,140 +381,91 @@ func (h *OfferHandler) AcceptOffer(c *gin.Context) {
 		return
 	}
 
-	var offer models.Offer
-	if err := h.db.Preload("Task").First(&offer, "id = ?", offerID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+	offer, err := h.offers.Withdraw(offerID, userID.(uuid.UUID))
+	if err != nil {
+		respondOfferError(c, err)
 		return
 	}
 
-	// Verify task ownership
-	if offer.Task.PosterID != userID.(uuid.UUID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only task poster can accept offers"})
+	c.JSON(http.StatusOK, offer)
+}
+
+// AcceptOffer accepts a pending offer on the caller's open task, rejecting the
+// competing offers. Clients should send an Idempotency-Key header so a retried
+// request returns the original result.
+func (h *OfferHandler) AcceptOffer(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	offerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
 		return
 	}
 
-	// Update offer status
-	offer.Status = "accepted"
-	h.db.Save(&offer)
-
-	// Create conversation between poster and tasker
-	conversation := models.Conversation{
-		TaskID: &offer.TaskID, // Link conversation to task
-	}
-	if err := h.db.Create(&conversation).Error; err != nil {
-		// Log error but continue
-		fmt.Printf("Failed to create conversation: %v\n", err)
-	} else {
-		// Add participants
-		participants := []models.ConversationParticipant{
-			{ConversationID: conversation.ID, UserID: userID.(uuid.UUID)},
-			{ConversationID: conversation.ID, UserID: offer.TaskerID},
-		}
-		h.db.Create(&participants)
-	}
-
-	// Update task status and link accepted offer and conversation
-	updates := map[string]interface{}{
-		"status":            "assigned",
-		"accepted_offer_id": offer.ID,
-		"conversation_id":   conversation.ID,
-	}
-	h.db.Model(&offer.Task).Updates(updates)
-
-	// Create escrow transaction (Invoice Placeholder)
-	escrow := models.EscrowTransaction{
-		TaskID:   offer.TaskID,
-		OfferID:  offer.ID,
-		PosterID: userID.(uuid.UUID),
-		TaskerID: offer.TaskerID,
-		Amount:   offer.Amount,
-		Status:   "held", // Initial status
-	}
-	h.db.Create(&escrow)
-
-	// Create notification for tasker
-	taskerDataJSON, _ := json.Marshal(map[string]interface{}{"task_id": offer.TaskID.String(), "conversation_id": conversation.ID.String()})
-	notification := models.Notification{
-		UserID:  offer.TaskerID,
-		Type:    "offer_accepted",
-		Title:   "Offer Accepted!",
-		Message: "Your offer for " + offer.Task.Title + " has been accepted.",
-		Data:    taskerDataJSON,
-	}
-	h.db.Create(&notification)
-	if notification.ID == uuid.Nil {
-		fmt.Printf("ERROR: Failed to create tasker notification. DB Error: %v\n", h.db.Error)
-	} else {
-		fmt.Printf("SUCCESS: Created tasker notification %s\n", notification.ID)
-		if h.fcm != nil {
-			h.fcm.BroadcastNotification(&notification)
-		}
+	key := c.GetHeader("Idempotency-Key")
+	if len(key) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+		return
 	}
 
-	// Create confirmation notification for task poster
-	posterDataJSON, _ := json.Marshal(map[string]interface{}{
-		"task_id":         offer.TaskID.String(),
-		"conversation_id": conversation.ID.String(),
-		"amount":          offer.Amount,
-	})
-	posterNotification := models.Notification{
-		UserID:  userID.(uuid.UUID),
-		Type:    "offer_accepted_by_you",
-		Title:   "Offer Accepted",
-		Message: fmt.Sprintf("You accepted an offer for %s. Your task is now assigned.", offer.Task.Title),
-		Data:    posterDataJSON,
-	}
-	h.db.Create(&posterNotification)
-	if posterNotification.ID == uuid.Nil {
-		fmt.Printf("ERROR: Failed to create poster notification for offer acceptance. DB Error: %v\n", h.db.Error)
-	} else {
-		fmt.Printf("SUCCESS: Created poster notification %s for offer acceptance\n", posterNotification.ID)
-		if h.fcm != nil {
-			h.fcm.BroadcastNotification(&posterNotification)
-		}
+	result, err := h.offers.Accept(offerID, userID.(uuid.UUID), key)
+	if err != nil {
+		respondOfferError(c, err)
+		return
 	}
 
-	// Send Push Notification to tasker
-	if h.fcm != nil {
-		go func() {
-			err := h.fcm.SendNotification(
-				offer.TaskerID,
-				"Offer Accepted!",
-				"Your offer for "+offer.Task.Title+" has been accepted.",
-				map[string]string{
-					"type":            "offer_accepted",
-					"task_id":         offer.TaskID.String(),
-					"conversation_id": conversation.ID.String(),
-				},
-			)
-			if err != nil {
-				fmt.Printf("Failed to send push notification to tasker: %v\n", err)
-			}
-		}()
+	c.JSON(http.StatusOK, result)
+}
 
-		// Send push notification to task poster (confirmation)
-		go func() {
-			err := h.fcm.SendNotification(
-				userID.(uuid.UUID),
-				"Offer Accepted",
-				fmt.Sprintf("You accepted an offer for %s. Your task is now assigned.", offer.Task.Title),
-				map[string]string{
-					"type":            "offer_accepted_by_you",
-					"task_id":         offer.TaskID.String(),
-					"conversation_id": conversation.ID.String(),
-				},
-			)
-			if err != nil {
-				fmt.Printf("Failed to send push notification to poster: %v\n", err)
-			}
-		}()
+// respondOfferError maps offer service errors onto HTTP responses
+func respondOfferError(c *gin.Context, err error) {
+	var ineligible *services.IneligibleError
+	switch {
+	case errors.Is(err, services.ErrOfferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+	case errors.Is(err, services.ErrNotTaskPoster):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only task poster can accept offers"})
+	case errors.Is(err, services.ErrOfferNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "OFFER_NOT_PENDING"})
+	case errors.Is(err, services.ErrNotOfferParty), errors.Is(err, services.ErrOwnCounter), errors.Is(err, services.ErrNotOfferTasker):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCounterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Counter-offer not found"})
+	case errors.Is(err, services.ErrEmptyCounter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCounterNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "COUNTER_NOT_PENDING"})
+	case errors.Is(err, services.ErrStructuredOfferAmount):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "STRUCTURED_QUOTE"})
+	case errors.Is(err, services.ErrOfferAmountLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "OFFER_ALREADY_VIEWED"})
+	case errors.Is(err, services.ErrTaskNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "TASK_NOT_OPEN"})
+	case errors.Is(err, services.ErrNotEquipmentTask), errors.Is(err, services.ErrInventoryNotOwned):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInventoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory item not found"})
+	case errors.As(err, &ineligible):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   err.Error(),
+			"code":    "INVENTORY_INELIGIBLE",
+			"reasons": ineligible.Reasons,
+		})
+	case errors.Is(err, services.ErrNoRateForDuration), errors.Is(err, services.ErrOperatorUnavailable),
+		errors.Is(err, services.ErrFuelRateMissing):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "QUOTE_INVALID"})
+	case errors.Is(err, services.ErrOfferCurrency):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "CURRENCY_MISMATCH"})
+	case errors.Is(err, services.ErrOwnEquipment):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInstantBookUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "INSTANT_BOOK_UNAVAILABLE"})
+	case errors.Is(err, services.ErrEquipmentBooked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "EQUIPMENT_BOOKED"})
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "IDEMPOTENCY_KEY_REUSED"})
+	default:
+		respondLifecycleError(c, err)
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"offer":           offer,
-		"conversation_id": conversation.ID,
-		"escrow":          escrow,
-	})
 }
 
 func (h *OfferHandler) AddReply(c *gin.Context) {
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type CounterOfferRequest struct {
+	Amount            *decimal.Decimal `json:"amount"`
+	EstimatedDuration *string          `json:"estimated_duration"`
+	Availability      *string          `json:"availability"`
+	Message           string           `json:"message" binding:"max=2000"`
+}
+
+// CreateCounter proposes new terms for a pending offer (task poster or offer's tasker)
+func (h *OfferHandler) CreateCounter(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	offerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return
+	}
+
+	var req CounterOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount != nil && !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than 0"})
+		return
+	}
+
+	counter, err := h.offers.ProposeCounter(offerID, userID.(uuid.UUID), services.CounterProposal{
+		Amount:            req.Amount,
+		EstimatedDuration: req.EstimatedDuration,
+		Availability:      req.Availability,
+		Message:           req.Message,
+	})
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, counter)
+}
+
+// AcceptCounter applies a counter-offer's terms to the offer (the other party only)
+func (h *OfferHandler) AcceptCounter(c *gin.Context) {
+	h.respondToCounter(c, true)
+}
+
+// DeclineCounter rejects a counter-offer, leaving the offer unchanged (the other party only)
+func (h *OfferHandler) DeclineCounter(c *gin.Context) {
+	h.respondToCounter(c, false)
+}
+
+func (h *OfferHandler) respondToCounter(c *gin.Context, accept bool) {
+	userID, _ := c.Get("user_id")
+	offerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return
+	}
+	counterID, err := uuid.Parse(c.Param("counterId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid counter-offer ID"})
+		return
+	}
+
+	counter, offer, err := h.offers.RespondToCounter(offerID, counterID, userID.(uuid.UUID), accept)
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"counter": counter, "offer": offer})
+}
+
+// GetCounters returns the negotiation on an offer, oldest first (task poster or offer's tasker)
+func (h *OfferHandler) GetCounters(c *gin.Context) {
+	offerID, ok := h.negotiatedOffer(c)
+	if !ok {
+		return
+	}
+
+	var counters []models.OfferCounter
+	if err := h.db.Preload("ProposedBy").Where("offer_id = ?", offerID).Order("created_at asc").Find(&counters).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch counter-offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, counters)
+}
+
+// GetRevisions returns every version of an offer's terms (task poster or offer's tasker)
+func (h *OfferHandler) GetRevisions(c *gin.Context) {
+	offerID, ok := h.negotiatedOffer(c)
+	if !ok {
+		return
+	}
+
+	var revisions []models.OfferRevision
+	if err := h.db.Where("offer_id = ?", offerID).Order("version asc").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offer history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// negotiatedOffer parses the :id offer and checks the caller is a party to it,
+// writing the error response otherwise
+func (h *OfferHandler) negotiatedOffer(c *gin.Context) (uuid.UUID, bool) {
+	userID, _ := c.Get("user_id")
+	offerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return uuid.Nil, false
+	}
+
+	var offer models.Offer
+	if err := h.db.Preload("Task").First(&offer, "id = ?", offerID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		return uuid.Nil, false
+	}
+	if offer.TaskerID != userID.(uuid.UUID) && (offer.Task == nil || offer.Task.PosterID != userID.(uuid.UUID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrNotOfferParty.Error()})
+		return uuid.Nil, false
+	}
+	return offerID, true
+}
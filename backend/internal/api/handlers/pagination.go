@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	// totalEstimateCap bounds the count query so deep result sets stay cheap
+	totalEstimateCap = 10000
+)
+
+// errInvalidCursor is returned for a cursor that is malformed or does not fit the
+// list's ordering
+var errInvalidCursor = errors.New("invalid cursor")
+
+// PageResponse is the envelope returned by every cursor-paginated list endpoint
+type PageResponse struct {
+	Items         interface{} `json:"items"`
+	NextCursor    *string     `json:"next_cursor"`
+	TotalEstimate int64       `json:"total_estimate"`
+}
+
+// pageCursor is the decoded form of the opaque cursor handed to clients. It holds
+// the sort key and ID of the last row on the previous page.
+type pageCursor struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+type pageRequest struct {
+	Limit  int
+	Cursor *pageCursor
+}
+
+func encodeCursor(value string, id uuid.UUID) string {
+	raw, _ := json.Marshal(pageCursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// parsePageRequest reads the limit and cursor query parameters
+func parsePageRequest(c *gin.Context) (pageRequest, error) {
+	page := pageRequest{Limit: defaultPageLimit}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := decodeCursor(cursorStr)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
+
+// keyset describes a stable ordering on (sort key, id) used for cursor pagination.
+type keyset struct {
+	// Expr is the SQL for the sort key, with Args bound to its placeholders
+	Expr string
+	Args []interface{}
+	// OrderBy optionally replaces Expr in ORDER BY, e.g. a selected alias
+	OrderBy string
+	// Cast is the Postgres type the cursor value is converted to
+	Cast     string
+	IDColumn string
+	Desc     bool
+}
+
+// checkCursor reports errInvalidCursor when the cursor's value does not convert
+// to k.Cast, which Postgres would otherwise fail on
+func (k keyset) checkCursor(cursor *pageCursor) error {
+	if cursor == nil {
+		return nil
+	}
+	var err error
+	switch k.Cast {
+	case "timestamptz":
+		if cursor.Value != "infinity" {
+			_, err = time.Parse(time.RFC3339Nano, cursor.Value)
+		}
+	case "numeric":
+		_, err = decimal.NewFromString(cursor.Value)
+	case "float8":
+		var f float64
+		if f, err = strconv.ParseFloat(cursor.Value, 64); err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			err = errInvalidCursor
+		}
+	case "int":
+		_, err = strconv.ParseInt(cursor.Value, 10, 32)
+	default:
+		err = errInvalidCursor
+	}
+	if err != nil {
+		return errInvalidCursor
+	}
+	return nil
+}
+
+// apply adds the "after cursor" condition and the ordering to query
+func (k keyset) apply(query *gorm.DB, cursor *pageCursor) *gorm.DB {
+	dir, cmp := "ASC", ">"
+	if k.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if cursor != nil {
+		args := append(append([]interface{}{}, k.Args...), cursor.Value, cursor.ID)
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?::text::%s, ?)", k.Expr, k.IDColumn, cmp, k.Cast), args...)
+	}
+
+	if k.OrderBy != "" {
+		return query.Order(fmt.Sprintf("%s %s, %s %s", k.OrderBy, dir, k.IDColumn, dir))
+	}
+	return query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                fmt.Sprintf("%s %s, %s %s", k.Expr, dir, k.IDColumn, dir),
+		Vars:               k.Args,
+		WithoutParentheses: true,
+	}})
+}
+
+// fetchPage reads one page of rows ordered by k. cursorOf extracts the sort key and ID
+// of a row so the next page can resume after it.
+func fetchPage[T any](query *gorm.DB, page pageRequest, k keyset, cursorOf func(*T) (string, uuid.UUID)) ([]T, *string, error) {
+	rows := []T{}
+	if err := k.apply(query, page.Cursor).Limit(page.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var next *string
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		value, id := cursorOf(&rows[len(rows)-1])
+		encoded := encodeCursor(value, id)
+		next = &encoded
+	}
+
+	return rows, next, nil
+}
+
+// estimateTotal counts the rows matched by filtered, stopping at totalEstimateCap
+func estimateTotal(db *gorm.DB, filtered *gorm.DB) int64 {
+	var total int64
+	if err := db.Table("(?) AS page_rows", filtered.Select("1").Limit(totalEstimateCap)).Count(&total).Error; err != nil {
+		return 0
+	}
+	return total
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	id := uuid.New()
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    *pageCursor
+		wantErr error
+	}{
+		{"round trip", encodeCursor("2026-10-18T12:00:00Z", id), &pageCursor{Value: "2026-10-18T12:00:00Z", ID: id}, nil},
+		{"empty value", encodeCursor("", id), &pageCursor{ID: id}, nil},
+		{"not base64", "not a cursor!", nil, errInvalidCursor},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"v":"1","id":"` + id.String() + `"}`)), nil, errInvalidCursor},
+		{"not json", encode("v=1"), nil, errInvalidCursor},
+		{"bad id", encode(`{"v":"1","id":"42"}`), nil, errInvalidCursor},
+		{"missing id", encode(`{"v":"1"}`), nil, errInvalidCursor},
+		{"nil id", encodeCursor("1", uuid.Nil), nil, errInvalidCursor},
+	}
+	for _, tt := range tests {
+		got, err := decodeCursor(tt.cursor)
+		if !errors.Is(err, tt.wantErr) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decodeCursor = %+v, %v; want %+v, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestKeysetCheckCursor(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		cast    string
+		value   string
+		wantErr error
+	}{
+		{"timestamptz", "2026-10-18T12:00:00.123456Z", nil},
+		{"timestamptz", "2026-10-18T14:00:00+02:00", nil},
+		{"timestamptz", "infinity", nil},
+		{"timestamptz", "2026-10-18", errInvalidCursor},
+		{"timestamptz", "-infinity", errInvalidCursor},
+		{"numeric", "125.50", nil},
+		{"numeric", "-3", nil},
+		{"numeric", "12.5.0", errInvalidCursor},
+		{"numeric", "", errInvalidCursor},
+		{"float8", "3.25", nil},
+		{"float8", "1e3", nil},
+		{"float8", "NaN", errInvalidCursor},
+		{"float8", "Inf", errInvalidCursor},
+		{"float8", "near", errInvalidCursor},
+		{"int", "42", nil},
+		{"int", "2147483648", errInvalidCursor},
+		{"int", "4.2", errInvalidCursor},
+		{"text", "anything", errInvalidCursor},
+	}
+	for _, tt := range tests {
+		order := keyset{Expr: "sort_key", Cast: tt.cast, IDColumn: "id"}
+		if err := order.checkCursor(&pageCursor{Value: tt.value, ID: id}); !errors.Is(err, tt.wantErr) {
+			t.Errorf("checkCursor(%s %q) = %v, want %v", tt.cast, tt.value, err, tt.wantErr)
+		}
+	}
+
+	if err := (keyset{Cast: "int"}).checkCursor(nil); err != nil {
+		t.Errorf("checkCursor(nil) = %v, want nil", err)
+	}
+}
+
+func TestKeysetApply(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dry_run"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		order    keyset
+		cursor   *pageCursor
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{"first page", keyset{Expr: "created_at", Cast: "timestamptz", IDColumn: "id", Desc: true}, nil,
+			`SELECT * FROM "rows" ORDER BY created_at DESC, id DESC`, nil},
+		{"descending after cursor", keyset{Expr: "created_at", Cast: "timestamptz", IDColumn: "id", Desc: true},
+			&pageCursor{Value: "2026-10-18T12:00:00Z", ID: id},
+			`SELECT * FROM "rows" WHERE (created_at, id) < ($1::text::timestamptz, $2) ORDER BY created_at DESC, id DESC`,
+			[]interface{}{"2026-10-18T12:00:00Z", id}},
+		{"ascending after cursor", keyset{Expr: "budget", Cast: "numeric", IDColumn: "id"},
+			&pageCursor{Value: "125.50", ID: id},
+			`SELECT * FROM "rows" WHERE (budget, id) > ($1::text::numeric, $2) ORDER BY budget ASC, id ASC`,
+			[]interface{}{"125.50", id}},
+		{"expression args bound before cursor", keyset{Expr: "distance(lat, lng, ?, ?)", Args: []interface{}{-17.8, 31.0},
+			OrderBy: "distance_km", Cast: "float8", IDColumn: "id"},
+			&pageCursor{Value: "3.25", ID: id},
+			`SELECT * FROM "rows" WHERE (distance(lat, lng, $1, $2), id) > ($3::text::float8, $4) ORDER BY distance_km ASC, id ASC`,
+			[]interface{}{-17.8, 31.0, "3.25", id}},
+		{"expression args in order by", keyset{Expr: "similarity(title, ?)", Args: []interface{}{"plumber"}, Cast: "float8", IDColumn: "id", Desc: true},
+			nil, `SELECT * FROM "rows" ORDER BY similarity(title, $1) DESC, id DESC`, []interface{}{"plumber"}},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		stmt := tt.order.apply(db.Table("rows"), tt.cursor).Find(&rows).Statement
+		if got := stmt.SQL.String(); got != tt.wantSQL {
+			t.Errorf("%s:\n got SQL %s\nwant SQL %s", tt.name, got, tt.wantSQL)
+		}
+		if len(stmt.Vars) != len(tt.wantVars) || (len(tt.wantVars) > 0 && !reflect.DeepEqual(stmt.Vars, tt.wantVars)) {
+			t.Errorf("%s: vars = %v, want %v", tt.name, stmt.Vars, tt.wantVars)
+		}
+	}
+}
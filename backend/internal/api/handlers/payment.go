@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/payments"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxWebhookBody bounds a provider callback
+const maxWebhookBody = 64 << 10
+
+type PaymentHandler struct {
+	cfg      *config.Config
+	payments *services.PaymentService
+}
+
+func NewPaymentHandler(cfg *config.Config, payments *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{cfg: cfg, payments: payments}
+}
+
+type PayRequest struct {
+	Method string `json:"method" binding:"required"`
+	// Phone is the mobile-money number charged; not needed for cash
+	Phone string `json:"phone" binding:"max=20"`
+}
+
+// PayTask pays the escrow of an assigned task. Mobile-money payments are pending
+// until the poster approves the prompt on their phone; the outcome arrives as a
+// payment_updated event and notification.
+func (h *PaymentHandler) PayTask(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req PayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Method != payments.MethodCash && req.Phone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "phone is required for mobile money"})
+		return
+	}
+
+	payment, err := h.payments.Pay(c.Request.Context(), taskID, userID.(uuid.UUID), req.Method, req.Phone)
+	if err != nil {
+		respondPaymentError(c, err, payment)
+		return
+	}
+
+	status := http.StatusCreated
+	if payment.Status == models.PaymentPending {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, payment)
+}
+
+// GetTaskPayments returns a task's escrow and its payments, with the payment
+// methods on offer
+func (h *PaymentHandler) GetTaskPayments(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	escrow, list, err := h.payments.TaskPayments(taskID, userID.(uuid.UUID))
+	if err != nil {
+		respondPaymentError(c, err, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"escrow":   escrow,
+		"payments": list,
+		"methods":  h.payments.Methods(),
+	})
+}
+
+// RefreshPayment asks the provider for the outcome of a pending payment
+func (h *PaymentHandler) RefreshPayment(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	payment, err := h.payments.Refresh(c.Request.Context(), paymentID, userID.(uuid.UUID))
+	if err != nil {
+		respondPaymentError(c, err, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Webhook receives a provider's callback. The body must be signed with the
+// payments webhook secret; see payments.Sign.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	if err := payments.VerifySignature(h.cfg.Payments.WebhookSecret,
+		c.GetHeader(payments.SignatureHeader), c.GetHeader(payments.TimestampHeader), body, time.Now()); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.payments.HandleWebhook(c.Param("provider"), body)
+	if err != nil {
+		if errors.Is(err, services.ErrPaymentNotFound) || errors.Is(err, payments.ErrUnknownMethod) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[Payments] Rejected %s webhook: %v", c.Param("provider"), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true, "status": payment.Status})
+}
+
+// respondPaymentError maps payment service errors onto HTTP responses. A payment
+// the provider declined outright is returned alongside the error.
+func respondPaymentError(c *gin.Context, err error, payment *models.PaymentTransaction) {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, services.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotTaskPayer), errors.Is(err, services.ErrNotPaymentParty):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, payments.ErrUnknownMethod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "PAYMENT_METHOD_UNAVAILABLE"})
+	case errors.Is(err, payments.ErrInvalidPhone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_PHONE", "payment": payment})
+	case errors.Is(err, services.ErrEscrowNotPayable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "ESCROW_NOT_PAYABLE"})
+	case errors.Is(err, services.ErrPaymentInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "PAYMENT_IN_PROGRESS"})
+	case payment != nil:
+		log.Printf("[Payments] Provider rejected payment %s: %v", payment.Reference, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "The payment provider declined the request", "code": "PAYMENT_PROVIDER_ERROR", "payment": payment})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment"})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// computedColumn is an SQL expression selected alongside a table's own columns,
+// such as distance_km or a search rank.
+type computedColumn struct {
+	Expr string
+	Args []interface{}
+	As   string
+}
+
+// selectComputed selects table.* plus the given computed columns
+func selectComputed(query *gorm.DB, table string, columns []computedColumn) *gorm.DB {
+	if len(columns) == 0 {
+		return query
+	}
+
+	exprs := []string{table + ".*"}
+	var args []interface{}
+	for _, col := range columns {
+		exprs = append(exprs, col.Expr+" AS "+col.As)
+		args = append(args, col.Args...)
+	}
+	return query.Select(strings.Join(exprs, ", "), args...)
+}
@@ -8,9 +8,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/airmassxpress/backend/internal/services"
 )
 
 type ReviewHandler struct {
@@ -86,9 +89,9 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	// 2. Calculate Review Weight
 	// Base weight = 1.0
 	// Verified Job = 1.2x (Assuming all jobs here are verified)
-	// Larger Job (> $100) = 1.1x
+	// Larger Job (> $100, converted at the latest rate) = 1.1x
 	weight := 1.2 // Verified base
-	if task.Budget > 100 {
+	if budget, err := services.ConvertMoney(h.db, task.BudgetMoney(), money.USD, time.Now()); err == nil && budget.Amount.GreaterThan(decimal.NewFromInt(100)) {
 		weight *= 1.1
 	}
 
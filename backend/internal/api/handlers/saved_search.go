@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const maxSavedSearchesPerUser = 20
+
+type SavedSearchHandler struct {
+	db *gorm.DB
+}
+
+func NewSavedSearchHandler(db *gorm.DB) *SavedSearchHandler {
+	return &SavedSearchHandler{db: db}
+}
+
+// SavedSearchRequest is used for both create and update. On update, omitted
+// fields are left unchanged.
+type SavedSearchRequest struct {
+	Name      *string          `json:"name" binding:"omitempty,max=100"`
+	Category  *string          `json:"category" binding:"omitempty,max=100"`
+	TaskType  *string          `json:"task_type" binding:"omitempty,oneof=service equipment"`
+	Keywords  *string          `json:"keywords" binding:"omitempty,max=255"`
+	MinBudget *decimal.Decimal `json:"min_budget"`
+	MaxBudget *decimal.Decimal `json:"max_budget"`
+	Currency  *string          `json:"currency"`
+	Lat       *float64         `json:"lat" binding:"omitempty,gte=-90,lte=90"`
+	Lng       *float64         `json:"lng" binding:"omitempty,gte=-180,lte=180"`
+	RadiusKm  *float64         `json:"radius_km" binding:"omitempty,gt=0,lte=500"`
+	Frequency *string          `json:"frequency" binding:"omitempty,oneof=instant daily"`
+	Active    *bool            `json:"active"`
+}
+
+// applyTo copies the set fields of req onto search
+func (req *SavedSearchRequest) applyTo(search *models.SavedSearch) {
+	if req.Name != nil {
+		search.Name = *req.Name
+	}
+	if req.Category != nil {
+		search.Category = *req.Category
+	}
+	if req.TaskType != nil {
+		search.TaskType = *req.TaskType
+	}
+	if req.Keywords != nil {
+		search.Keywords = *req.Keywords
+	}
+	if req.MinBudget != nil {
+		search.MinBudget = req.MinBudget
+	}
+	if req.MaxBudget != nil {
+		search.MaxBudget = req.MaxBudget
+	}
+	if req.Currency != nil {
+		search.Currency = *req.Currency
+	}
+	if req.Lat != nil || req.Lng != nil || req.RadiusKm != nil {
+		search.Lat, search.Lng, search.RadiusKm = req.Lat, req.Lng, req.RadiusKm
+	}
+	if req.Frequency != nil {
+		search.Frequency = *req.Frequency
+	}
+	if req.Active != nil {
+		search.Active = *req.Active
+	}
+}
+
+func validateSavedSearch(search *models.SavedSearch) error {
+	if (search.Lat == nil) != (search.Lng == nil) || (search.Lat == nil) != (search.RadiusKm == nil) {
+		return errors.New("lat, lng and radius_km must be provided together")
+	}
+	if (search.MinBudget != nil && search.MinBudget.IsNegative()) || (search.MaxBudget != nil && search.MaxBudget.IsNegative()) {
+		return errors.New("min_budget and max_budget cannot be negative")
+	}
+	if search.MinBudget != nil && search.MaxBudget != nil && search.MinBudget.GreaterThan(*search.MaxBudget) {
+		return errors.New("min_budget cannot exceed max_budget")
+	}
+	currency, err := money.ParseCurrency(search.Currency)
+	if err != nil {
+		return err
+	}
+	search.Currency = currency
+	return nil
+}
+
+// ListSavedSearches returns the current user's saved searches
+func (h *SavedSearchHandler) ListSavedSearches(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var searches []models.SavedSearch
+	if err := h.db.Where("user_id = ?", userID).Order("created_at desc").Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// CreateSavedSearch stores a new search for alerts
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search := models.SavedSearch{
+		UserID:    userID.(uuid.UUID),
+		Frequency: models.SavedSearchInstant,
+		Active:    true,
+	}
+	req.applyTo(&search)
+	if err := validateSavedSearch(&search); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.SavedSearch{}).Where("user_id = ?", search.UserID).Count(&count)
+	if count >= maxSavedSearchesPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Saved search limit reached"})
+		return
+	}
+
+	if err := h.db.Create(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create saved search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// UpdateSavedSearch changes a saved search owned by the current user
+func (h *SavedSearchHandler) UpdateSavedSearch(c *gin.Context) {
+	search, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.applyTo(search)
+	if err := validateSavedSearch(search); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Save(search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch removes a saved search owned by the current user
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	search, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// GetSavedSearchMatches lists tasks that have matched a saved search, newest first
+func (h *SavedSearchHandler) GetSavedSearchMatches(c *gin.Context) {
+	search, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	var matches []models.SavedSearchMatch
+	if err := h.db.Preload("Task").
+		Joins("JOIN tasks ON tasks.id = saved_search_matches.task_id AND tasks.deleted_at IS NULL").
+		Where("saved_search_matches.saved_search_id = ?", search.ID).
+		Order("saved_search_matches.created_at desc").
+		Limit(maxPageLimit).
+		Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch matches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
+
+func (h *SavedSearchHandler) loadOwned(c *gin.Context) (*models.SavedSearch, bool) {
+	userID, _ := c.Get("user_id")
+
+	searchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+		return nil, false
+	}
+
+	var search models.SavedSearch
+	if err := h.db.First(&search, "id = ? AND user_id = ?", searchID, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return nil, false
+	}
+
+	return &search, true
+}
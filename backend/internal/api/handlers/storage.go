@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const signedUploadExpiry = 15 * time.Minute
+
+// uploadPurposes maps the purpose a client declares when requesting a signed
+// upload onto the policy and key prefix it is stored under
+var uploadPurposes = map[string]struct {
+	policy storage.Policy
+	prefix string
+}{
+	"task_image": {storage.Images, "tasks"},
+	"inventory":  {storage.Images, "inventory"},
+	"avatar":     {storage.Avatar, "avatars"},
+	"document":   {storage.Documents, "documents"},
+}
+
+type StorageHandler struct {
+	storage storage.Storage
+}
+
+func NewStorageHandler(store storage.Storage) *StorageHandler {
+	return &StorageHandler{storage: store}
+}
+
+type SignUploadRequest struct {
+	Purpose     string `json:"purpose" binding:"required"`
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+}
+
+// SignUpload issues a short-lived URL for uploading a file directly to storage.
+// The returned public_url is then passed to the endpoint that uses the file.
+func (h *StorageHandler) SignUpload(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req SignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purpose, ok := uploadPurposes[req.Purpose]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "purpose must be one of task_image, inventory, avatar, document"})
+		return
+	}
+	if err := purpose.policy.Check(req.ContentType, req.Size); err != nil {
+		respondStorageError(c, err)
+		return
+	}
+
+	key := purpose.policy.NewKey(purpose.prefix+"/"+userID.(uuid.UUID).String(), req.ContentType)
+	signed, err := h.storage.SignUpload(c.Request.Context(), key, req.ContentType, req.Size, signedUploadExpiry)
+	if err != nil {
+		log.Printf("[Storage] Failed to sign upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload URL"})
+		return
+	}
+
+	c.JSON(http.StatusOK, signed)
+}
+
+// LocalUpload accepts uploads to URLs signed by the local storage driver. The
+// signature authorises the request, so this route sits outside the auth middleware.
+func (h *StorageHandler) LocalUpload(c *gin.Context) {
+	local, ok := h.storage.(*storage.Local)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	contentType, size, err := local.VerifyUpload(key, c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The signed size is the limit; the sniffed type must match the signed one
+	policy := storage.Policy{MaxBytes: size, Allowed: map[string]string{contentType: ""}}
+	data, _, err := policy.Read(c.Request.Body)
+	if err != nil {
+		respondStorageError(c, err)
+		return
+	}
+
+	object, err := local.Put(c.Request.Context(), key, bytes.NewReader(data), int64(len(data)), contentType)
+	if err != nil {
+		log.Printf("[Storage] Failed to store signed upload %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, object)
+}
+
+// respondStorageError maps upload validation errors onto HTTP responses
+func respondStorageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "code": "FILE_TOO_LARGE"})
+	case errors.Is(err, storage.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error(), "code": "UNSUPPORTED_FILE_TYPE"})
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload was interrupted"})
+	default:
+		log.Printf("[Storage] Upload failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+	}
+}
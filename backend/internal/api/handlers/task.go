@@ -1,42 +1,61 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
 	"github.com/airmassxpress/backend/internal/services"
+	"github.com/airmassxpress/backend/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 type TaskHandler struct {
-	db  *gorm.DB
-	fcm *services.FCMService
-	hub *services.Hub
+	db        *gorm.DB
+	fcm       *services.FCMService
+	hub       *services.Hub
+	lifecycle *services.TaskLifecycle
+	geo       *services.GeoService
+	matcher   *services.SavedSearchMatcher
+	storage   storage.Storage
+	images    *services.ImagePipeline
+	invoices  *services.InvoiceService
 }
 
-func NewTaskHandler(db *gorm.DB, fcm *services.FCMService, hub *services.Hub) *TaskHandler {
+func NewTaskHandler(db *gorm.DB, fcm *services.FCMService, hub *services.Hub, lifecycle *services.TaskLifecycle, geo *services.GeoService, matcher *services.SavedSearchMatcher, store storage.Storage, images *services.ImagePipeline, invoices *services.InvoiceService) *TaskHandler {
 	return &TaskHandler{
-		db:  db,
-		fcm: fcm,
-		hub: hub,
+		db:        db,
+		fcm:       fcm,
+		hub:       hub,
+		lifecycle: lifecycle,
+		geo:       geo,
+		matcher:   matcher,
+		storage:   store,
+		images:    images,
+		invoices:  invoices,
 	}
 }
 
 type CreateTaskRequest struct {
-	Title       string   `json:"title" binding:"required"`
-	Description string   `json:"description" binding:"required"`
-	Category    string   `json:"category" binding:"required"`
-	Budget      float64  `json:"budget" binding:"required,gt=0"`
-	Location    string   `json:"location" binding:"required"`
-	Lat         *float64 `json:"lat"`
-	Lng         *float64 `json:"lng"`
-	DateType    string   `json:"date_type"`
-	Date        *string  `json:"date"`
-	TimeOfDay   string   `json:"time_of_day"`
-	TaskType    string   `json:"task_type"`
+	Title       string          `json:"title" binding:"required"`
+	Description string          `json:"description" binding:"required"`
+	Category    string          `json:"category" binding:"required"`
+	Budget      decimal.Decimal `json:"budget"`
+	Currency    string          `json:"currency"` // USD or ZWG (ZiG); defaults to USD
+	Location    string          `json:"location" binding:"required"`
+	Lat         *float64        `json:"lat"`
+	Lng         *float64        `json:"lng"`
+	DateType    string          `json:"date_type"`
+	Date        *string         `json:"date"`
+	TimeOfDay   string          `json:"time_of_day"`
+	TaskType    string          `json:"task_type"`
 
 	// V2 Fields
 	HireDurationType   string  `json:"hire_duration_type"`
@@ -52,46 +71,172 @@ type CreateTaskRequest struct {
 	AddressDetails string `json:"address_details"`
 }
 
+// ListTasks returns a cursor-paginated page of tasks.
+// Supported sort values: newest (default), relevance (default with q), budget_asc,
+// budget_desc, date, distance (default with lat/lng), offer_count. Budget sorts
+// require ?currency=, since amounts in different currencies do not compare;
+// ?display_currency= adds each budget converted at the latest exchange rate.
 func (h *TaskHandler) ListTasks(c *gin.Context) {
-	var tasks []models.Task
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	query := h.db.Preload("Poster").Preload("Attachments")
+	// Geo: radius around lat/lng and/or bbox. A point orders results nearest first by default.
+	geoFilter, err := parseGeoFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Filters
-	if tType := c.Query("task_type"); tType != "" {
-		query = query.Where("task_type = ?", tType)
+	currency, ok := currencyQuery(c, "currency")
+	if !ok {
+		return
 	}
-	if category := c.Query("category"); category != "" {
-		query = query.Where("category = ?", category)
+	displayCurrency, ok := currencyQuery(c, "display_currency")
+	if !ok {
+		return
 	}
-	// ...
 
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
+	// Keyword search over title, description, category and suburb/city
+	search := services.ParseTaskSearch(c.Query("q"))
+
+	sortBy := c.Query("sort")
+	if sortBy == "" {
+		sortBy = "newest"
+		if search != nil {
+			sortBy = "relevance"
+		} else if geoFilter.HasPoint() {
+			sortBy = "distance"
+		}
 	}
-	if location := c.Query("location"); location != "" {
-		query = query.Where("location ILIKE ?", "%"+location+"%")
+	order, cursorOf, err := h.taskKeyset(sortBy, currency, geoFilter, search)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if posterID := c.Query("poster_id"); posterID != "" {
-		query = query.Where("poster_id = ?", posterID)
+	if err := order.checkCursor(page.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if offeredBy := c.Query("offered_by"); offeredBy != "" {
-		// Join with offers to find tasks where this user made an offer
-		query = query.Joins("JOIN offers ON offers.task_id = tasks.id").
-			Where("offers.tasker_id = ?", offeredBy).
-			Group("tasks.id") // Deduplicate in case of multiple offers/replies if any
+
+	var computed []computedColumn
+	if geoFilter.HasPoint() {
+		expr, args := h.geo.DistanceExpr("tasks", *geoFilter.Lat, *geoFilter.Lng)
+		computed = append(computed, computedColumn{Expr: expr, Args: args, As: "distance_km"})
+	}
+	if search != nil {
+		rankExpr, rankArgs := search.RankExpr()
+		snippetExpr, snippetArgs := search.SnippetExpr()
+		titleExpr, titleArgs := search.TitleHighlightExpr()
+		computed = append(computed,
+			computedColumn{Expr: rankExpr, Args: rankArgs, As: "search_rank"},
+			computedColumn{Expr: snippetExpr, Args: snippetArgs, As: "snippet"},
+			computedColumn{Expr: titleExpr, Args: titleArgs, As: "title_highlight"},
+		)
 	}
 
-	// Sorting
-	sortBy := c.DefaultQuery("sort", "created_at desc")
-	query = query.Order(sortBy)
+	// Filters
+	filter := func(query *gorm.DB) *gorm.DB {
+		if search != nil {
+			query = search.Apply(query)
+		}
+		if tType := c.Query("task_type"); tType != "" {
+			query = query.Where("tasks.task_type = ?", tType)
+		}
+		if category := c.Query("category"); category != "" {
+			query = query.Where("tasks.category = ?", category)
+		}
+		if currency != "" {
+			query = query.Where("tasks.currency = ?", currency)
+		}
+		if status := c.Query("status"); status != "" {
+			query = query.Where("tasks.status = ?", status)
+		}
+		if location := c.Query("location"); location != "" {
+			query = query.Where("tasks.location ILIKE ?", "%"+location+"%")
+		}
+		if posterID := c.Query("poster_id"); posterID != "" {
+			query = query.Where("tasks.poster_id = ?", posterID)
+		}
+		if offeredBy := c.Query("offered_by"); offeredBy != "" {
+			// Tasks where this user made an offer
+			query = query.Where("EXISTS (SELECT 1 FROM offers WHERE offers.task_id = tasks.id AND offers.tasker_id = ? AND offers.deleted_at IS NULL)", offeredBy)
+		}
+		return h.geo.Apply(query, "tasks", geoFilter)
+	}
 
-	if err := query.Find(&tasks).Error; err != nil {
+	tasks, nextCursor, err := fetchPage(selectComputed(filter(h.db.Model(&models.Task{}).Preload("Poster").Preload("Attachments", orderedAttachments)), "tasks", computed), page, order, cursorOf)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
 		return
 	}
+	pageTasks := make([]*models.Task, len(tasks))
+	for i := range tasks {
+		pageTasks[i] = &tasks[i]
+	}
+	setDisplayBudgets(h.db, displayCurrency, pageTasks...)
 
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, PageResponse{
+		Items:         tasks,
+		NextCursor:    nextCursor,
+		TotalEstimate: estimateTotal(h.db, filter(h.db.Model(&models.Task{}))),
+	})
+}
+
+// taskKeyset maps the public sort vocabulary onto keyset orderings. Only these
+// values are accepted so client input never reaches ORDER BY.
+func (h *TaskHandler) taskKeyset(sortBy, currency string, geoFilter services.GeoFilter, search *services.TaskSearchQuery) (keyset, func(*models.Task) (string, uuid.UUID), error) {
+	switch sortBy {
+	case "newest":
+		return keyset{Expr: "tasks.created_at", Cast: "timestamptz", IDColumn: "tasks.id", Desc: true},
+			func(t *models.Task) (string, uuid.UUID) { return t.CreatedAt.Format(time.RFC3339Nano), t.ID }, nil
+	case "budget_asc", "budget_desc":
+		if currency == "" {
+			return keyset{}, nil, fmt.Errorf("sort=%s requires currency", sortBy)
+		}
+		return keyset{Expr: "tasks.budget", Cast: "numeric", IDColumn: "tasks.id", Desc: sortBy == "budget_desc"},
+			func(t *models.Task) (string, uuid.UUID) { return t.Budget.String(), t.ID }, nil
+	case "date":
+		// Undated (flexible) tasks sort last
+		return keyset{Expr: "COALESCE(tasks.date, 'infinity'::timestamptz)", Cast: "timestamptz", IDColumn: "tasks.id"},
+			func(t *models.Task) (string, uuid.UUID) {
+				if t.Date == nil {
+					return "infinity", t.ID
+				}
+				return t.Date.Format(time.RFC3339Nano), t.ID
+			}, nil
+	case "distance":
+		if !geoFilter.HasPoint() {
+			return keyset{}, nil, errors.New("sort=distance requires lat and lng")
+		}
+		expr, args := h.geo.DistanceExpr("tasks", *geoFilter.Lat, *geoFilter.Lng)
+		return keyset{Expr: expr, Args: args, OrderBy: "distance_km", Cast: "float8", IDColumn: "tasks.id"},
+			func(t *models.Task) (string, uuid.UUID) {
+				if t.DistanceKm == nil {
+					return "0", t.ID
+				}
+				return strconv.FormatFloat(*t.DistanceKm, 'g', -1, 64), t.ID
+			}, nil
+	case "relevance":
+		if search == nil {
+			return keyset{}, nil, errors.New("sort=relevance requires q")
+		}
+		expr, args := search.RankExpr()
+		return keyset{Expr: expr, Args: args, OrderBy: "search_rank", Cast: "float8", IDColumn: "tasks.id", Desc: true},
+			func(t *models.Task) (string, uuid.UUID) {
+				if t.SearchRank == nil {
+					return "0", t.ID
+				}
+				return strconv.FormatFloat(*t.SearchRank, 'g', -1, 64), t.ID
+			}, nil
+	case "offer_count":
+		return keyset{Expr: "tasks.offer_count", Cast: "int", IDColumn: "tasks.id", Desc: true},
+			func(t *models.Task) (string, uuid.UUID) { return strconv.Itoa(t.OfferCount), t.ID }, nil
+	default:
+		return keyset{}, nil, errors.New("sort must be one of newest, relevance, budget_asc, budget_desc, date, distance, offer_count")
+	}
 }
 
 func (h *TaskHandler) GetTask(c *gin.Context) {
@@ -101,12 +246,22 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
 		return
 	}
+	displayCurrency, ok := currencyQuery(c, "display_currency")
+	if !ok {
+		return
+	}
 
 	var task models.Task
-	if err := h.db.Preload("Poster").Preload("Attachments").Preload("Offers.Tasker").Preload("AcceptedOffer.Tasker").Preload("RequiredCapacity").First(&task, "id = ?", taskID).Error; err != nil {
+	if err := h.db.Preload("Poster").Preload("Attachments", orderedAttachments).Preload("Offers.Tasker").Preload("AcceptedOffer.Tasker").Preload("RequiredCapacity").First(&task, "id = ?", taskID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
+	offers := make([]*models.Offer, len(task.Offers))
+	for i := range task.Offers {
+		offers[i] = &task.Offers[i]
+	}
+	attachOfferFees(h.db, &task, offers...)
+	setDisplayBudgets(h.db, displayCurrency, &task)
 
 	c.JSON(http.StatusOK, task)
 }
@@ -119,15 +274,22 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !req.Budget.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "budget must be greater than 0"})
+		return
+	}
+	currency, err := money.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	var taskDate *time.Time
-	if req.Date != nil && *req.Date != "" {
-		parsedDate, err := time.Parse("2006-01-02", *req.Date)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+	if req.Date != nil {
+		var ok bool
+		if taskDate, ok = parseTaskDate(c, *req.Date); !ok {
 			return
 		}
-		taskDate = &parsedDate
 	}
 
 	taskType := "service"
@@ -139,6 +301,9 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	if req.RequiredCapacityID != nil && *req.RequiredCapacityID != "" {
 		id, err := uuid.Parse(*req.RequiredCapacityID)
 		if err == nil {
+			if !requireActiveCapacity(c, h.db, id) {
+				return
+			}
 			reqCapID = &id
 		}
 	}
@@ -173,14 +338,15 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		Title:              req.Title,
 		Description:        req.Description,
 		Category:           req.Category,
-		Budget:             req.Budget,
+		Budget:             req.Budget.Round(2),
+		Currency:           currency,
 		Location:           locationStr, // Use constructed or provided location
 		Lat:                req.Lat,
 		Lng:                req.Lng,
 		DateType:           req.DateType,
 		Date:               taskDate,
 		TimeOfDay:          req.TimeOfDay,
-		Status:             "open",
+		Status:             models.TaskStatusOpen,
 		TaskType:           taskType,
 		HireDurationType:   req.HireDurationType,
 		EstimatedHours:     req.EstimatedHours,
@@ -209,51 +375,20 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		"task": task,
 	})
 
-	c.JSON(http.StatusCreated, gin.H{"taskId": task.ID})
-}
+	// Alert taskers whose saved searches match, including those not connected right now
+	created := task
+	go h.matcher.MatchTask(&created)
 
-type AddAttachmentsRequest struct {
-	Attachments []struct {
-		URL  string `json:"url" binding:"required"`
-		Type string `json:"type" binding:"required"`
-		Name string `json:"name"`
-	} `json:"attachments" binding:"required"`
+	c.JSON(http.StatusCreated, gin.H{"taskId": task.ID})
 }
 
-func (h *TaskHandler) AddAttachments(c *gin.Context) {
-	id := c.Param("id")
-	taskID, err := uuid.Parse(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
-		return
-	}
-
-	var req AddAttachmentsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Verify task exists
-	var task models.Task
-	if err := h.db.First(&task, "id = ?", taskID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
-	}
-
-	// Create task attachments
-	for i, att := range req.Attachments {
-		attachment := models.TaskAttachment{
-			TaskID:     taskID,
-			URL:        att.URL,
-			Type:       att.Type,
-			Name:       att.Name,
-			OrderIndex: i,
-		}
-		h.db.Create(&attachment)
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Attachments added successfully"})
+// editableTaskFields are the task fields a poster may change with PATCH /tasks/:id.
+// Currency and task type are fixed once offers can arrive in them.
+var editableTaskFields = []string{
+	"title", "description", "category", "budget", "location", "lat", "lng",
+	"date_type", "date", "time_of_day", "hire_duration_type", "estimated_hours",
+	"estimated_duration", "fuel_included", "operator_preference", "required_capacity_id",
+	"city", "suburb", "address_details",
 }
 
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
@@ -276,16 +411,88 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		return
 	}
 
+	// Once assigned, the escrow, quote and bookings are priced from the task
+	if task.Status != models.TaskStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only open tasks can be edited", "code": "TASK_NOT_OPEN"})
+		return
+	}
+
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.db.Model(&task).Updates(updates).Error; err != nil {
+	// Status only changes through the lifecycle endpoints (start, complete, cancel, reopen)
+	if _, ok := updates["status"]; ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Task status cannot be edited directly",
+			"code":  "STATUS_NOT_EDITABLE",
+		})
+		return
+	}
+
+	// Only the poster's own details are editable; everything else is owned by the
+	// offer, payment and lifecycle flows
+	editable := map[string]interface{}{}
+	for _, field := range editableTaskFields {
+		if value, ok := updates[field]; ok {
+			editable[field] = value
+		}
+	}
+	if len(editable) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No editable fields given"})
+		return
+	}
+	if raw, ok := editable["budget"]; ok {
+		var budget decimal.Decimal
+		switch v := raw.(type) {
+		case float64:
+			budget = decimal.NewFromFloat(v)
+		case string:
+			budget, err = decimal.NewFromString(v)
+		default:
+			err = errors.New("not a number")
+		}
+		if err != nil || !budget.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "budget must be greater than 0"})
+			return
+		}
+		editable["budget"] = budget.Round(2)
+	}
+	if raw, ok := editable["date"]; ok {
+		date, isString := raw.(string)
+		if raw != nil && !isString {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		taskDate, ok := parseTaskDate(c, date)
+		if !ok {
+			return
+		}
+		editable["date"] = taskDate
+	}
+	if raw, ok := editable["required_capacity_id"].(string); ok && raw != "" {
+		capacityID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid required_capacity_id"})
+			return
+		}
+		if (task.RequiredCapacityID == nil || *task.RequiredCapacityID != capacityID) && !requireActiveCapacity(c, h.db, capacityID) {
+			return
+		}
+	}
+
+	// The status condition keeps an offer accepted meanwhile from being repriced
+	result := h.db.Model(&task).Where("status = ?", models.TaskStatusOpen).Updates(editable)
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only open tasks can be edited", "code": "TASK_NOT_OPEN"})
+		return
+	}
 
 	// Broadcast update
 	h.hub.BroadcastToRoom("task_updates:"+taskID.String(), map[string]interface{}{
@@ -316,17 +523,40 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Delete(&task).Error; err != nil {
+	// Escrow, bookings and the ledger hang off assigned tasks; those are cancelled
+	// through the lifecycle instead, which settles them
+	result := h.db.Where("status = ? AND accepted_offer_id IS NULL", models.TaskStatusOpen).Delete(&task)
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Only open tasks can be deleted; cancel the task instead",
+			"code":  "TASK_NOT_OPEN",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
 }
 
-func (h *TaskHandler) UploadTaskImages(c *gin.Context) {
-	// TODO: Implement file upload to S3 or local storage
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Image upload not yet implemented"})
+// parseTaskDate reads a YYYY-MM-DD task date, answering 400 when it is malformed
+// and 422 when it has passed. An empty date means none.
+func parseTaskDate(c *gin.Context, raw string) (*time.Time, bool) {
+	if raw == "" {
+		return nil, true
+	}
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return nil, false
+	}
+	if services.TaskDatePassed(&date, time.Now()) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "date must be today or later", "code": "DATE_PASSED"})
+		return nil, false
+	}
+	return &date, true
 }
 
 // GetActiveTasks returns tasks where the current user is the assigned tasker
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxTaskAttachments caps the photos and documents on a single task
+const maxTaskAttachments = 20
+
+// attachmentTypes are the values accepted for TaskAttachment.Type
+var attachmentTypes = map[string]bool{"image": true, "document": true}
+
+var (
+	errTooManyAttachments    = fmt.Errorf("A task can have at most %d attachments", maxTaskAttachments)
+	errAttachmentSetMismatch = errors.New("attachment_ids must list every attachment of the task exactly once")
+	errCoverNotImage         = errors.New("only an image can be the cover")
+)
+
+// orderedAttachments preloads attachments in display order; the first image is the cover
+func orderedAttachments(db *gorm.DB) *gorm.DB {
+	return db.Order("order_index ASC, created_at ASC")
+}
+
+// UploadTaskImages stores images sent as multipart "files" and attaches them to the task (owner only)
+func (h *TaskHandler) UploadTaskImages(c *gin.Context) {
+	task, ok := h.ownedTask(c)
+	if !ok {
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["files"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files uploaded"})
+		return
+	}
+	files := form.File["files"]
+
+	var existing int64
+	h.db.Model(&models.TaskAttachment{}).Where("task_id = ?", task.ID).Count(&existing)
+	if int(existing)+len(files) > maxTaskAttachments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTooManyAttachments.Error()})
+		return
+	}
+
+	prefix := "tasks/" + task.ID.String()
+	attachments := make([]models.TaskAttachment, 0, len(files))
+	var keys []string
+	// Remove already-stored files if the batch fails part way
+	discard := func() {
+		for _, key := range keys {
+			if err := h.storage.Delete(c.Request.Context(), key); err != nil {
+				log.Printf("[Storage] Failed to clean up %s: %v", key, err)
+			}
+		}
+	}
+
+	for i, file := range files {
+		object, err := storage.UploadFile(c.Request.Context(), h.storage, storage.Images, prefix, file)
+		if err != nil {
+			discard()
+			respondStorageError(c, err)
+			return
+		}
+		keys = append(keys, object.Key)
+		attachments = append(attachments, models.TaskAttachment{
+			TaskID:     task.ID,
+			URL:        object.URL,
+			Type:       "image",
+			Name:       file.Filename,
+			OrderIndex: int(existing) + i,
+		})
+	}
+
+	if err := h.db.Create(&attachments).Error; err != nil {
+		discard()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachments"})
+		return
+	}
+
+	h.attachmentsAdded(task.ID, attachments)
+	c.JSON(http.StatusCreated, attachments)
+}
+
+type AddAttachmentsRequest struct {
+	Attachments []struct {
+		URL  string `json:"url" binding:"required"`
+		Type string `json:"type" binding:"required"`
+		Name string `json:"name"`
+	} `json:"attachments" binding:"required,min=1,dive"`
+}
+
+// AddAttachments attaches already-uploaded files to a task (owner only), after any existing ones
+func (h *TaskHandler) AddAttachments(c *gin.Context) {
+	var req AddAttachmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, att := range req.Attachments {
+		if !attachmentTypes[att.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Attachment type must be image or document"})
+			return
+		}
+	}
+
+	task, ok := h.ownedTask(c)
+	if !ok {
+		return
+	}
+
+	var attachments []models.TaskAttachment
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the task so concurrent changes cannot exceed the limit or clash on order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Task{}, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.TaskAttachment{}).Where("task_id = ?", task.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if int(existing)+len(req.Attachments) > maxTaskAttachments {
+			return errTooManyAttachments
+		}
+
+		for i, att := range req.Attachments {
+			attachments = append(attachments, models.TaskAttachment{
+				TaskID:     task.ID,
+				URL:        att.URL,
+				Type:       att.Type,
+				Name:       att.Name,
+				OrderIndex: int(existing) + i,
+			})
+		}
+		return tx.Create(&attachments).Error
+	})
+	if errors.Is(err, errTooManyAttachments) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachments"})
+		return
+	}
+
+	h.attachmentsAdded(task.ID, attachments)
+	c.JSON(http.StatusOK, gin.H{"message": "Attachments added successfully", "attachments": attachments})
+}
+
+// DeleteAttachment removes an attachment and its stored files (owner only)
+func (h *TaskHandler) DeleteAttachment(c *gin.Context) {
+	task, ok := h.ownedTask(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	var attachment models.TaskAttachment
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Task{}, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&attachment, "id = ? AND task_id = ?", attachmentID, task.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+
+		// Close the gap left in the order
+		var remaining []models.TaskAttachment
+		if err := orderedAttachments(tx.Where("task_id = ?", task.ID)).Find(&remaining).Error; err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, len(remaining))
+		for i := range remaining {
+			ids[i] = remaining[i].ID
+		}
+		return setAttachmentOrder(tx, ids)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+
+	// The row is gone, so stored files are removed on a best-effort basis
+	ctx := context.WithoutCancel(c.Request.Context())
+	if key, ok := h.storage.KeyFromURL(attachment.URL); ok {
+		if err := h.storage.Delete(ctx, key); err != nil {
+			log.Printf("[Storage] Failed to delete attachment %s: %v", key, err)
+		}
+	}
+	h.images.DeleteVariants(ctx, attachment.URL, attachment.ImageVariants)
+
+	h.broadcastAttachments(task.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+type ReorderAttachmentsRequest struct {
+	AttachmentIDs []uuid.UUID `json:"attachment_ids" binding:"required"`
+}
+
+// ReorderAttachments sets the display order of a task's attachments (owner only).
+// The request must list every attachment of the task exactly once.
+func (h *TaskHandler) ReorderAttachments(c *gin.Context) {
+	var req ReorderAttachmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, ok := h.ownedTask(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Task{}, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+
+		var current []uuid.UUID
+		if err := tx.Model(&models.TaskAttachment{}).Where("task_id = ?", task.ID).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+		if !sameIDs(current, req.AttachmentIDs) {
+			return errAttachmentSetMismatch
+		}
+		return setAttachmentOrder(tx, req.AttachmentIDs)
+	})
+	if errors.Is(err, errAttachmentSetMismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.broadcastAttachments(task.ID))
+}
+
+// SetCoverAttachment makes an image the task's cover by moving it to the front (owner only)
+func (h *TaskHandler) SetCoverAttachment(c *gin.Context) {
+	task, ok := h.ownedTask(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Task{}, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+
+		var attachments []models.TaskAttachment
+		if err := orderedAttachments(tx.Where("task_id = ?", task.ID)).Find(&attachments).Error; err != nil {
+			return err
+		}
+
+		ids := []uuid.UUID{attachmentID}
+		found := false
+		for _, attachment := range attachments {
+			if attachment.ID == attachmentID {
+				if attachment.Type != "image" {
+					return errCoverNotImage
+				}
+				found = true
+				continue
+			}
+			ids = append(ids, attachment.ID)
+		}
+		if !found {
+			return gorm.ErrRecordNotFound
+		}
+		return setAttachmentOrder(tx, ids)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	case errors.Is(err, errCoverNotImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set cover image"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.broadcastAttachments(task.ID))
+}
+
+// ownedTask loads the task in the :id param and checks the caller posted it,
+// writing the error response otherwise
+func (h *TaskHandler) ownedTask(c *gin.Context) (*models.Task, bool) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return nil, false
+	}
+
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", taskID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return nil, false
+	}
+	if task.PosterID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+		return nil, false
+	}
+	return &task, true
+}
+
+// attachmentsAdded queues new attachments for image processing and notifies watchers
+func (h *TaskHandler) attachmentsAdded(taskID uuid.UUID, attachments []models.TaskAttachment) {
+	ids := make([]uuid.UUID, len(attachments))
+	for i := range attachments {
+		ids[i] = attachments[i].ID
+	}
+	h.images.ProcessTaskAttachments(ids)
+	h.broadcastAttachments(taskID)
+}
+
+// broadcastAttachments sends the task's current attachments to its room and returns them
+func (h *TaskHandler) broadcastAttachments(taskID uuid.UUID) []models.TaskAttachment {
+	attachments := []models.TaskAttachment{}
+	if err := orderedAttachments(h.db.Where("task_id = ?", taskID)).Find(&attachments).Error; err != nil {
+		log.Printf("[Tasks] Failed to load attachments of task %s: %v", taskID, err)
+		return attachments
+	}
+
+	h.hub.BroadcastToRoom("task_updates:"+taskID.String(), map[string]interface{}{
+		"type":        "attachments_updated",
+		"task_id":     taskID,
+		"attachments": attachments,
+	})
+	return attachments
+}
+
+// setAttachmentOrder numbers the given attachments 0..n-1 in order
+func setAttachmentOrder(tx *gorm.DB, ids []uuid.UUID) error {
+	for i, id := range ids {
+		if err := tx.Model(&models.TaskAttachment{}).Where("id = ?", id).Update("order_index", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameIDs reports whether want is a permutation of have
+func sameIDs(have, want []uuid.UUID) bool {
+	if len(have) != len(want) {
+		return false
+	}
+	remaining := make(map[uuid.UUID]bool, len(have))
+	for _, id := range have {
+		remaining[id] = true
+	}
+	for _, id := range want {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
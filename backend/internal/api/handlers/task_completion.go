@@ -1,17 +1,21 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// CompleteTask marks a task as completed and generates 'invoice' data
+var errTaskNotAssigned = errors.New("task is not assigned")
+
+// CompleteTask marks a task as completed, releases its escrow and issues the invoice
 func (h *TaskHandler) CompleteTask(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	taskID, err := uuid.Parse(c.Param("id"))
@@ -20,120 +24,126 @@ func (h *TaskHandler) CompleteTask(c *gin.Context) {
 		return
 	}
 
-	var task models.Task
-	if err := h.db.First(&task, "id = ?", taskID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
-	}
-
-	// Verify requester is the assigned tasker
-	// Use explicit check on AcceptedOfferID link or if unavailable, check offers
+	var task *models.Task
 	var offer models.Offer
-	if task.AcceptedOfferID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Task is not assigned to anyone"})
-		return
-	}
-
-	if err := h.db.First(&offer, "id = ?", task.AcceptedOfferID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Assigned offer data missing"})
-		return
-	}
+	var escrow models.EscrowTransaction
+	var settlement *models.HireSettlement
+	var transition *services.TaskTransition
+	var invoice *models.Invoice
 
-	if offer.TaskerID != userID.(uuid.UUID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the assigned tasker can mark this task as complete"})
-		return
-	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = services.LockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
 
-	// Updates
-	// 1. Mark task as completed
-	task.Status = "completed"
-	// task.CompletedAt is not defined in the model, skipping.
-	// Since generated model lacks CompletedAt *Time pointer or we didn't add it, we might skip or use UpdatedAt
+		// Verify requester is the assigned tasker
+		if task.AcceptedOfferID == nil {
+			return errTaskNotAssigned
+		}
+		if err := tx.First(&offer, "id = ?", task.AcceptedOfferID).Error; err != nil {
+			return err
+		}
+		if offer.TaskerID != userID.(uuid.UUID) {
+			return services.ErrTransitionNotPermitted
+		}
 
-	h.db.Model(&task).Update("status", "completed")
+		// Checked-in equipment hires are billed from the confirmed check-out
+		if err := services.RequireCheckedOutTx(tx, taskID); err != nil {
+			return err
+		}
+		var metered models.HireSettlement
+		if err := tx.Where("task_id = ?", taskID).First(&metered).Error; err == nil {
+			settlement = &metered
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
 
-	// 2. Update Escrow Transaction to 'released' (or 'issued' for invoice logic)
-	// We find the escrow connected to this task
-	var escrow models.EscrowTransaction
-	if err := h.db.Where("task_id = ?", taskID).First(&escrow).Error; err != nil {
-		// If missing, create one now (fallback)
-		escrow = models.EscrowTransaction{
-			TaskID:   taskID,
-			OfferID:  offer.ID,
-			PosterID: task.PosterID,
-			TaskerID: offer.TaskerID,
-			Amount:   offer.Amount,
-			Status:   "released", // Equivalent to 'issued' for cash
+		// 1. Mark task as completed (must currently be in_progress)
+		actorID := userID.(uuid.UUID)
+		transition, err = h.lifecycle.TransitionTx(tx, task, models.TaskStatusCompleted, &actorID, "")
+		if err != nil {
+			return err
 		}
-		h.db.Create(&escrow)
-	} else {
-		// Update status
-		h.db.Model(&escrow).Update("status", "released")
-	}
 
-	// 3. Return Invoice Data
-	invoice := gin.H{
-		"taskId":        task.ID,
-		"taskTitle":     task.Title,
-		"amount":        offer.Amount,
-		"status":        "issued",
-		"paymentMethod": "cash", // MVP: Cash only
-		"taskerName":    "You",  // Frontend has user data
-		"posterId":      task.PosterID,
-		"completedAt":   "Just now",
-	}
+		// 2. Release the escrow, queuing the payout of money collected through a provider
+		// We find the escrow connected to this task
+		if err := tx.Where("task_id = ? AND status IN ?", taskID, []string{models.EscrowPending, models.EscrowHeld}).
+			First(&escrow).Error; err != nil {
+			// If missing, create one now (fallback); unpaid escrow is settled as cash
+			escrow = models.EscrowTransaction{
+				TaskID:   taskID,
+				OfferID:  offer.ID,
+				PosterID: task.PosterID,
+				TaskerID: offer.TaskerID,
+				Amount:   offer.Amount,
+				Currency: task.Currency,
+				Status:   models.EscrowPending,
+			}
+			if settlement != nil {
+				escrow.Amount = settlement.BilledAmount
+			}
+			fees, err := services.QuoteFees(tx, task, escrow.Amount, time.Now())
+			if err != nil {
+				return err
+			}
+			services.ApplyEscrowFees(&escrow, fees)
+			if err := tx.Create(&escrow).Error; err != nil {
+				return err
+			}
+		}
+		if err := services.SettleEscrowTx(tx, &escrow); err != nil {
+			return err
+		}
 
-	// TODO: Trigger notification to poster
-	notificationData, _ := json.Marshal(map[string]interface{}{
-		"task_id":   task.ID.String(),
-		"tasker_id": userID.(uuid.UUID).String(),
+		// 3. Issue the invoice from the released escrow
+		invoice, err = h.invoices.IssueTx(tx, task, &offer, &escrow, settlement)
+		return err
 	})
-	notification := models.Notification{
-		UserID:  task.PosterID,
-		Type:    "task_completed",
-		Title:   "Task Completed",
-		Message: "Tasker has marked '" + task.Title + "' as complete.", // You might want to truncate title if too long
-		Data:    notificationData,
+	if err != nil {
+		if errors.Is(err, errTaskNotAssigned) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Task is not assigned to anyone"})
+			return
+		}
+		if errors.Is(err, services.ErrTransitionNotPermitted) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the assigned tasker can mark this task as complete"})
+			return
+		}
+		if errors.Is(err, services.ErrHireNotCheckedOut) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "HIRE_NOT_CHECKED_OUT"})
+			return
+		}
+		if errors.Is(err, services.ErrPayoutNumberRequired) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "PAYOUT_NUMBER_REQUIRED"})
+			return
+		}
+		respondLifecycleError(c, err)
+		return
 	}
-	h.db.Create(&notification)
+
+	// Notifies the poster and broadcasts the status change
+	h.lifecycle.Publish(transition)
 
 	// 4. Update Tasker Stats (TasksCompleted)
 	// We increment the counter atomically
 	if err := h.db.Model(&models.User{}).Where("id = ?", offer.TaskerID).Update("tasks_completed", gorm.Expr("tasks_completed + ?", 1)).Error; err != nil {
 		// Log error but assume non-critical for flow
-		fmt.Printf("Failed to increment tasks_completed: %v\n", err)
+		log.Printf("[Tasks] Failed to increment tasks_completed for user %s: %v", offer.TaskerID, err)
 	}
 
 	// 5. Update Poster Stats (TasksPostedCompleted)
 	if err := h.db.Model(&models.User{}).Where("id = ?", task.PosterID).Update("tasks_posted_completed", gorm.Expr("tasks_posted_completed + ?", 1)).Error; err != nil {
-		fmt.Printf("Failed to increment tasks_posted_completed: %v\n", err)
-	}
-
-	// Broadcast via WebSocket
-	if h.fcm != nil {
-		h.fcm.BroadcastNotification(&notification)
-	}
-
-	// Send Push Notification
-	if h.fcm != nil {
-		err := h.fcm.SendNotification(
-			task.PosterID,
-			"Task Completed",
-			"Tasker has marked '"+task.Title+"' as complete.",
-			map[string]string{
-				"type":   "task_completed",
-				"taskId": task.ID.String(),
-				"url":    "/tasks/" + task.ID.String(),
-			},
-		)
-		if err != nil {
-			// Log error but don't fail the request
-			fmt.Printf("Failed to send push notification: %v\n", err)
-		}
+		log.Printf("[Tasks] Failed to increment tasks_posted_completed for user %s: %v", task.PosterID, err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Task completed successfully",
 		"invoice": invoice,
-	})
+	}
+	if settlement != nil {
+		// Metered equipment hires bill actual use; the estimate is shown alongside
+		response["settlement"] = settlement
+	}
+	c.JSON(http.StatusOK, response)
 }
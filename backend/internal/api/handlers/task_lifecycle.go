@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/api/middleware"
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TaskTransitionRequest struct {
+	Reason string `json:"reason"`
+	// Date moves a reopened task to a new date, YYYY-MM-DD
+	Date *string `json:"date"`
+}
+
+// StartTask moves an assigned task to in_progress (assigned tasker only)
+func (h *TaskHandler) StartTask(c *gin.Context) {
+	h.transitionTask(c, models.TaskStatusInProgress)
+}
+
+// CancelTask cancels a task that has not been completed (poster only)
+func (h *TaskHandler) CancelTask(c *gin.Context) {
+	h.transitionTask(c, models.TaskStatusCancelled)
+}
+
+// ReopenTask returns an assigned, cancelled or expired task to open (poster only).
+// A task whose date has passed needs a new one, or it would expire again.
+func (h *TaskHandler) ReopenTask(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req TaskTransitionRequest
+	_ = c.ShouldBindJSON(&req)
+
+	var date *time.Time
+	if req.Date != nil && *req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = &parsed
+	}
+
+	actorID := userID.(uuid.UUID)
+	task, err := h.lifecycle.Reopen(taskID, &actorID, req.Reason, date)
+	if err != nil {
+		respondLifecycleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+func (h *TaskHandler) transitionTask(c *gin.Context, to string) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// Reason is optional, so an empty body is fine
+	var req TaskTransitionRequest
+	_ = c.ShouldBindJSON(&req)
+
+	actorID := userID.(uuid.UUID)
+	task, err := h.lifecycle.Transition(taskID, to, &actorID, req.Reason)
+	if err != nil {
+		respondLifecycleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// GetStatusHistory returns the audit trail of status changes for a task to its
+// poster, its assigned tasker and admins
+func (h *TaskHandler) GetStatusHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var task models.Task
+	if err := h.db.Preload("AcceptedOffer").First(&task, "id = ?", taskID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	callerID := userID.(uuid.UUID)
+	isTasker := task.AcceptedOffer != nil && task.AcceptedOffer.TaskerID == callerID
+	if task.PosterID != callerID && !isTasker && !middleware.IsAdmin(h.db, callerID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the task's poster and assigned tasker can view its history"})
+		return
+	}
+
+	var history []models.TaskStatusHistory
+	if err := h.db.Preload("Actor").Where("task_id = ?", taskID).Order("created_at asc").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// respondLifecycleError maps task state machine errors onto HTTP responses
+func respondLifecycleError(c *gin.Context, err error) {
+	var transitionErr *services.TransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": transitionErr.Error(),
+			"code":  "INVALID_TRANSITION",
+			"from":  transitionErr.From,
+			"to":    transitionErr.To,
+		})
+	case errors.Is(err, services.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, services.ErrTransitionNotPermitted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTaskDatePassed):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "DATE_PASSED"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task status"})
+	}
+}
@@ -5,18 +5,20 @@ import (
 
 	"github.com/airmassxpress/backend/internal/config"
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type TaskerHandler struct {
-	cfg *config.Config
-	db  *gorm.DB
+	cfg    *config.Config
+	db     *gorm.DB
+	images *services.ImagePipeline
 }
 
-func NewTaskerHandler(cfg *config.Config, db *gorm.DB) *TaskerHandler {
-	return &TaskerHandler{cfg: cfg, db: db}
+func NewTaskerHandler(cfg *config.Config, db *gorm.DB, images *services.ImagePipeline) *TaskerHandler {
+	return &TaskerHandler{cfg: cfg, db: db, images: images}
 }
 
 // GetProfessions returns the list of available professions
@@ -245,6 +247,11 @@ func (h *TaskerHandler) UploadMetadata(c *gin.Context) {
 		return
 	}
 
+	// Strip EXIF (including GPS location) from KYC scans and portfolio photos
+	if req.Type != "qualification" {
+		h.images.Sanitize(req.FileURL)
+	}
+
 	// Return updated user with tasker profile to keep frontend in sync (avatar_url included)
 	var user models.User
 	if err := h.db.Preload("TaskerProfile").First(&user, "id = ?", userID).Error; err != nil {
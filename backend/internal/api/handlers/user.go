@@ -7,17 +7,21 @@ import (
 	"time"
 
 	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/airmassxpress/backend/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-	db *gorm.DB
+	db      *gorm.DB
+	storage storage.Storage
+	images  *services.ImagePipeline
 }
 
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{db: db}
+func NewUserHandler(db *gorm.DB, store storage.Storage, images *services.ImagePipeline) *UserHandler {
+	return &UserHandler{db: db, storage: store, images: images}
 }
 
 func (h *UserHandler) GetUser(c *gin.Context) {
@@ -72,6 +76,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	delete(updates, "rating")
 	delete(updates, "review_count")
 	delete(updates, "tasks_completed")
+	delete(updates, "is_admin")
 
 	var user models.User
 	if err := h.db.Model(&user).Where("id = ?", paramUserID).Updates(updates).Error; err != nil {
@@ -83,9 +88,53 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// UploadAvatar replaces the user's avatar with the multipart "file" image
 func (h *UserHandler) UploadAvatar(c *gin.Context) {
-	// TODO: Implement avatar upload
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Avatar upload not yet implemented"})
+	userID, _ := c.Get("user_id")
+	paramUserID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if userID.(uuid.UUID) != paramUserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", paramUserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	object, err := storage.UploadFile(c.Request.Context(), h.storage, storage.Avatar, "avatars/"+paramUserID.String(), file)
+	if err != nil {
+		respondStorageError(c, err)
+		return
+	}
+
+	previous := user.AvatarURL
+	if err := h.db.Model(&user).Update("avatar_url", object.URL).Error; err != nil {
+		h.storage.Delete(c.Request.Context(), object.Key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
+		return
+	}
+	h.images.Sanitize(object.URL)
+
+	// Only objects we stored can be removed; seeded avatars live under /avatars
+	if key, ok := h.storage.KeyFromURL(previous); ok {
+		if err := h.storage.Delete(c.Request.Context(), key); err != nil {
+			log.Printf("[Storage] Failed to delete previous avatar %s: %v", key, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, user)
 }
 
 type UpdateFCMTokenRequest struct {
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RequireAdmin lets through only users flagged is_admin. It must run after
+// AuthMiddleware, which sets user_id.
+func RequireAdmin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
+			c.Abort()
+			return
+		}
+
+		if !IsAdmin(db, userID.(uuid.UUID)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether userID may use the admin API
+func IsAdmin(db *gorm.DB, userID uuid.UUID) bool {
+	var user models.User
+	if err := db.Select("is_admin").First(&user, "id = ?", userID).Error; err != nil {
+		return false
+	}
+	return user.IsAdmin
+}
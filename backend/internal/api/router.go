@@ -4,13 +4,15 @@ import (
 	"github.com/airmassxpress/backend/internal/api/handlers"
 	"github.com/airmassxpress/backend/internal/api/middleware"
 	"github.com/airmassxpress/backend/internal/config"
+	"github.com/airmassxpress/backend/internal/scheduler"
 	"github.com/airmassxpress/backend/internal/services"
+	"github.com/airmassxpress/backend/internal/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub *services.Hub) *gin.Engine {
+func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub *services.Hub, taskLifecycle *services.TaskLifecycle, matcher *services.SavedSearchMatcher, jobs *scheduler.Scheduler, store storage.Storage, images *services.ImagePipeline, payments *services.PaymentService, invoices *services.InvoiceService) *gin.Engine {
 	router := gin.Default()
 
 	// CORS middleware
@@ -20,16 +22,31 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub
 	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
 	router.Use(cors.New(corsConfig))
 
+	// Initialize services
+	geoService := services.NewGeoService(db)
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(cfg, db)
-	taskHandler := handlers.NewTaskHandler(db, fcm, hub)
-	offerHandler := handlers.NewOfferHandler(cfg, db, fcm, hub)
+	taskHandler := handlers.NewTaskHandler(db, fcm, hub, taskLifecycle, geoService, matcher, store, images, invoices)
+	eligibility := services.NewEligibilityService(db)
+	offerService := services.NewOfferService(db, fcm, hub, taskLifecycle, eligibility)
+	offerHandler := handlers.NewOfferHandler(cfg, db, fcm, hub, taskLifecycle, offerService, eligibility)
+	hireHandler := handlers.NewHireHandler(services.NewHireService(db, fcm, hub))
+	paymentHandler := handlers.NewPaymentHandler(cfg, payments)
+	ledgerHandler := handlers.NewLedgerHandler(db)
+	feeHandler := handlers.NewFeeHandler(db)
+	invoiceHandler := handlers.NewInvoiceHandler(db, invoices)
+	exchangeHandler := handlers.NewExchangeHandler(db)
 	notificationHandler := handlers.NewNotificationHandler(db)
-	userHandler := handlers.NewUserHandler(db)
-	taskerHandler := handlers.NewTaskerHandler(cfg, db)
+	userHandler := handlers.NewUserHandler(db, store, images)
+	taskerHandler := handlers.NewTaskerHandler(cfg, db, images)
 	chatHandler := handlers.NewChatHandler(db, hub)
 	commentHandler := handlers.NewCommentHandler(db, hub)
 	equipmentCapacityHandler := handlers.NewEquipmentCapacityHandler(db)
+	inventoryHandler := handlers.NewInventoryHandler(cfg, db, store, geoService, images)
+	savedSearchHandler := handlers.NewSavedSearchHandler(db)
+	jobHandler := handlers.NewJobHandler(db, jobs)
+	storageHandler := handlers.NewStorageHandler(store)
 
 	// Public routes
 	api := router.Group("/api/v1")
@@ -64,19 +81,60 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub
 		api.GET("/equipment-capacities/:type", equipmentCapacityHandler.GetCapacitiesByType)
 		api.GET("/equipment-types", equipmentCapacityHandler.GetEquipmentTypes)
 
-		// Admin routes (dev only)
+		// Public equipment search
+		api.GET("/inventory/search", inventoryHandler.SearchInventory)
+		api.GET("/inventory/:id", inventoryHandler.GetCatalogueItem)
+
+		// Exchange rates, for showing amounts in another currency
+		api.GET("/exchange-rates", exchangeHandler.GetExchangeRates)
+		api.GET("/exchange-rates/convert", exchangeHandler.ConvertAmount)
+
+		// Payment provider callbacks, authenticated by their signature. Off until
+		// PAYMENTS_WEBHOOK_SECRET is set.
+		if cfg.Payments.WebhookSecret != "" {
+			api.POST("/payments/webhooks/:provider", paymentHandler.Webhook)
+		}
+
+		// Admin API, for authenticated users flagged is_admin
 		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(cfg), middleware.RequireAdmin(db))
 		{
 			admin.POST("/approve-tasker", taskerHandler.ApproveTasker)
 			admin.POST("/verify-user", userHandler.AdminVerifyUser)
 			admin.GET("/taskers/pending", taskerHandler.GetPendingTaskers)
 			admin.GET("/users", userHandler.GetAllUsers)
+			admin.GET("/jobs", jobHandler.ListJobs)
+			admin.GET("/jobs/:name/runs", jobHandler.GetJobRuns)
+			admin.POST("/jobs/:name/run", jobHandler.RunJob)
+			admin.GET("/equipment-types", equipmentCapacityHandler.AdminListEquipmentTypes)
+			admin.POST("/equipment-types", equipmentCapacityHandler.AdminCreateEquipmentType)
+			admin.PATCH("/equipment-types/:id", equipmentCapacityHandler.AdminUpdateEquipmentType)
+			admin.POST("/equipment-types/:id/retire", equipmentCapacityHandler.AdminRetireEquipmentType)
+			admin.POST("/equipment-types/:id/restore", equipmentCapacityHandler.AdminRestoreEquipmentType)
+			admin.POST("/equipment-capacities", equipmentCapacityHandler.AdminCreateCapacity)
+			admin.GET("/equipment-capacities/export", equipmentCapacityHandler.AdminExportCapacities)
+			admin.POST("/equipment-capacities/import", equipmentCapacityHandler.AdminImportCapacities)
+			admin.PATCH("/equipment-capacities/:id", equipmentCapacityHandler.AdminUpdateCapacity)
+			admin.POST("/equipment-capacities/:id/retire", equipmentCapacityHandler.AdminRetireCapacity)
+			admin.POST("/equipment-capacities/:id/restore", equipmentCapacityHandler.AdminRestoreCapacity)
+			admin.GET("/ledger/reconciliation", ledgerHandler.AdminReconciliation)
+			admin.GET("/fee-rules", feeHandler.AdminListFeeRules)
+			admin.GET("/fee-rules/preview", feeHandler.AdminPreviewFees)
+			admin.POST("/fee-rules", feeHandler.AdminCreateFeeRule)
+			admin.PATCH("/fee-rules/:id", feeHandler.AdminUpdateFeeRule)
+			admin.DELETE("/fee-rules/:id", feeHandler.AdminDeleteFeeRule)
+			admin.POST("/invoices/:id/credit-notes", invoiceHandler.AdminIssueCreditNote)
+			admin.GET("/exchange-rates", exchangeHandler.AdminListExchangeRates)
+			admin.POST("/exchange-rates", exchangeHandler.AdminCreateExchangeRate)
 		}
 
 	}
 
-	// Static files
-	router.Static("/uploads", "./uploads")
+	// Static files. Uploads are only served by the API when stored on local disk.
+	if local, ok := store.(*storage.Local); ok {
+		router.Static("/uploads", local.Dir())
+		api.PUT("/storage/local/*key", storageHandler.LocalUpload)
+	}
 	router.Static("/avatars", "./public/avatars")
 	router.Static("/public", "./public")
 	protected := api.Group("")
@@ -96,6 +154,9 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub
 		protected.POST("/tasker/profile", taskerHandler.UpdateProfile)
 		protected.POST("/tasker/upload-metadata", taskerHandler.UploadMetadata)
 
+		// Direct-to-storage uploads
+		protected.POST("/uploads/sign", storageHandler.SignUpload)
+
 		// Task management
 		protected.GET("/tasks/active", taskHandler.GetActiveTasks)
 		protected.POST("/tasks", taskHandler.CreateTask)
@@ -103,18 +164,57 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub
 		protected.DELETE("/tasks/:id", taskHandler.DeleteTask)
 		protected.POST("/tasks/:id/images", taskHandler.UploadTaskImages)
 		protected.PUT("/tasks/:id/attachments", taskHandler.AddAttachments)
+		protected.PUT("/tasks/:id/attachments/order", taskHandler.ReorderAttachments)
+		protected.POST("/tasks/:id/attachments/:attachmentId/cover", taskHandler.SetCoverAttachment)
+		protected.DELETE("/tasks/:id/attachments/:attachmentId", taskHandler.DeleteAttachment)
+		protected.POST("/tasks/:id/start", taskHandler.StartTask)
 		protected.POST("/tasks/:id/complete", taskHandler.CompleteTask)
+		protected.POST("/tasks/:id/cancel", taskHandler.CancelTask)
+		protected.POST("/tasks/:id/reopen", taskHandler.ReopenTask)
+		protected.GET("/tasks/:id/history", taskHandler.GetStatusHistory)
+		protected.GET("/tasks/:id/eligible-inventory", offerHandler.GetEligibleInventory)
+		protected.GET("/tasks/:id/hire", hireHandler.GetHire)
+		protected.POST("/tasks/:id/hire/check-in", hireHandler.CheckIn)
+		protected.POST("/tasks/:id/hire/check-out", hireHandler.CheckOut)
+		protected.POST("/tasks/:id/hire/:kind/confirm", hireHandler.ConfirmEvent)
+
+		// Payments
+		protected.GET("/tasks/:id/payments", paymentHandler.GetTaskPayments)
+		protected.POST("/tasks/:id/payments", paymentHandler.PayTask)
+		protected.POST("/payments/:id/refresh", paymentHandler.RefreshPayment)
+		protected.GET("/tasks/:id/invoices", invoiceHandler.GetTaskInvoices)
+		protected.GET("/invoices", invoiceHandler.ListInvoices)
+		protected.GET("/invoices/:id", invoiceHandler.GetInvoice)
+		protected.GET("/invoices/:id/pdf", invoiceHandler.DownloadInvoicePDF)
+
+		// Wallet
+		protected.GET("/wallet", ledgerHandler.GetWallet)
+		protected.GET("/wallet/statement", ledgerHandler.GetWalletStatement)
 		protected.GET("/reviews/pending", taskHandler.GetPendingReviews)
 
 		// Offers
 		protected.POST("/offers", offerHandler.CreateOffer)
+		protected.POST("/offers/quote-preview", offerHandler.PreviewQuote)
 		protected.GET("/offers/:id", offerHandler.GetOffer)
 		protected.PATCH("/offers/:id", offerHandler.UpdateOffer)
 		protected.DELETE("/offers/:id", offerHandler.WithdrawOffer)
 		protected.POST("/offers/:id/accept", offerHandler.AcceptOffer)
+		protected.POST("/offers/:id/viewed", offerHandler.MarkOfferViewed)
+		protected.POST("/offers/:id/counters", offerHandler.CreateCounter)
+		protected.GET("/offers/:id/counters", offerHandler.GetCounters)
+		protected.POST("/offers/:id/counters/:counterId/accept", offerHandler.AcceptCounter)
+		protected.POST("/offers/:id/counters/:counterId/decline", offerHandler.DeclineCounter)
+		protected.GET("/offers/:id/revisions", offerHandler.GetRevisions)
 		protected.POST("/offers/:id/replies", offerHandler.AddReply)
 		protected.GET("/offers/:id/replies", offerHandler.GetReplies)
 
+		// Saved searches
+		protected.GET("/saved-searches", savedSearchHandler.ListSavedSearches)
+		protected.POST("/saved-searches", savedSearchHandler.CreateSavedSearch)
+		protected.PATCH("/saved-searches/:id", savedSearchHandler.UpdateSavedSearch)
+		protected.DELETE("/saved-searches/:id", savedSearchHandler.DeleteSavedSearch)
+		protected.GET("/saved-searches/:id/matches", savedSearchHandler.GetSavedSearchMatches)
+
 		// Notifications
 		protected.GET("/notifications", notificationHandler.ListNotifications)
 		protected.PATCH("/notifications/:id/read", notificationHandler.MarkAsRead)
@@ -141,13 +241,21 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, fcm *services.FCMService, hub
 		protected.POST("/reviews/:id/reply", reviewHandler.ReplyReview)
 
 		// Inventory
-		supabaseService := services.NewSupabaseService(cfg)
-		inventoryHandler := handlers.NewInventoryHandler(cfg, db, supabaseService)
 		protected.GET("/inventory", inventoryHandler.GetMyInventory)
 		protected.POST("/inventory", inventoryHandler.CreateInventoryItem)
 		protected.POST("/inventory/upload", inventoryHandler.UploadImage)
 		protected.PUT("/inventory/:id", inventoryHandler.UpdateInventoryItem)
 		protected.DELETE("/inventory/:id", inventoryHandler.DeleteInventoryItem)
+		protected.GET("/inventory/:id/availability", inventoryHandler.GetAvailability)
+		protected.POST("/inventory/:id/blockouts", inventoryHandler.CreateBlockout)
+		protected.DELETE("/inventory/:id/blockouts/:bookingId", inventoryHandler.DeleteBlockout)
+		protected.PUT("/inventory/:id/booking-mode", inventoryHandler.SetBookingMode)
+		protected.GET("/inventory/:id/maintenance", inventoryHandler.GetMaintenance)
+		protected.POST("/inventory/:id/maintenance", inventoryHandler.AddMaintenanceRecord)
+		protected.DELETE("/inventory/:id/maintenance/:recordId", inventoryHandler.DeleteMaintenanceRecord)
+		protected.POST("/inventory/:id/certificates", inventoryHandler.AddCertificate)
+		protected.DELETE("/inventory/:id/certificates/:certificateId", inventoryHandler.DeleteCertificate)
+		protected.POST("/inventory/:id/instant-book", offerHandler.InstantBook)
 	}
 
 	return router
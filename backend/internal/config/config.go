@@ -1,21 +1,29 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	AWS      AWSConfig
-	CORS     CORSConfig
-	Supabase SupabaseConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	JWT       JWTConfig
+	AWS       AWSConfig
+	CORS      CORSConfig
+	Supabase  SupabaseConfig
+	Storage   StorageConfig
+	Scheduler SchedulerConfig
+	Payments  PaymentsConfig
+	Invoices  InvoiceConfig
 }
 
 type ServerConfig struct {
@@ -49,6 +57,65 @@ type AWSConfig struct {
 	Region          string
 	S3Bucket        string
 	UseLocalStorage bool
+	// Endpoint overrides the AWS endpoint for S3-compatible services such as MinIO or R2
+	Endpoint       string
+	ForcePathStyle bool
+	// PublicURL is the base URL objects are served from, e.g. a CDN in front of the bucket
+	PublicURL string
+}
+
+// StorageConfig selects where uploaded files are stored
+type StorageConfig struct {
+	Driver string // local, s3, supabase
+	// LocalDir and PublicBaseURL configure the local driver
+	LocalDir      string
+	PublicBaseURL string
+	// SigningSecret signs upload URLs issued by the local driver
+	SigningSecret string
+}
+
+// SchedulerConfig controls the background jobs that keep marketplace state tidy
+type SchedulerConfig struct {
+	Enabled bool
+	// ReminderLead is how long before a task's date the assignee is reminded
+	ReminderLead time.Duration
+	// AssignedStaleAfter is how long past its date (or since assignment, for
+	// undated tasks) an assigned task may sit before it is auto-cancelled
+	AssignedStaleAfter time.Duration
+	// OfferTTL is how long an offer may stay pending before it expires
+	OfferTTL time.Duration
+}
+
+// PaymentsConfig configures the payment providers. Cash is always available; the
+// simulator and EcoCash drivers are enabled by their settings.
+type PaymentsConfig struct {
+	// WebhookSecret verifies the signature on provider callbacks. Without it the
+	// webhook route is off and the simulator cannot be enabled.
+	WebhookSecret string
+	// PublicBaseURL is where providers reach this API, for callback URLs
+	PublicBaseURL string
+	// SimulatorEnabled is an explicit opt-in for local development, refused in release mode
+	SimulatorEnabled bool
+	// SimulatorDelay is how long the simulator takes to confirm a payment
+	SimulatorDelay time.Duration
+	EcoCash        EcoCashConfig
+}
+
+// EcoCashConfig points the EcoCash driver at a merchant mobile-money API
+type EcoCashConfig struct {
+	BaseURL      string
+	MerchantCode string
+	APIKey       string
+}
+
+// InvoiceConfig is printed on every invoice and credit note when it is issued.
+// Prices include tax at TaxRate percent.
+type InvoiceConfig struct {
+	IssuerName      string
+	IssuerAddress   string
+	IssuerTaxNumber string
+	TaxName         string
+	TaxRate         float64
 }
 
 type CORSConfig struct {
@@ -87,6 +154,9 @@ func Load() (*Config, error) {
 			Region:          getEnv("AWS_REGION", "us-east-1"),
 			S3Bucket:        getEnv("S3_BUCKET", "airmass-uploads"),
 			UseLocalStorage: getEnv("USE_LOCAL_STORAGE", "true") == "true",
+			Endpoint:        getEnv("S3_ENDPOINT", ""),
+			ForcePathStyle:  getEnv("S3_FORCE_PATH_STYLE", "false") == "true",
+			PublicURL:       getEnv("S3_PUBLIC_URL", ""),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: []string{"http://localhost:3000", "http://localhost:3001"},
@@ -94,16 +164,64 @@ func Load() (*Config, error) {
 		Supabase: SupabaseConfig{
 			URL: getEnv("SUPABASE_URL", ""),
 			// Default to empty; user must provide SERVICE_ROLE_KEY for backend uploads
-			Key: getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
+			Key:    getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
+			Bucket: getEnv("SUPABASE_BUCKET", "uploads"),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:            getEnv("SCHEDULER_ENABLED", "true") == "true",
+			ReminderLead:       parseDurationOr(getEnv("TASK_REMINDER_LEAD", "24h"), 24*time.Hour),
+			AssignedStaleAfter: parseDurationOr(getEnv("TASK_ASSIGNED_STALE_AFTER", "336h"), 14*24*time.Hour),
+			OfferTTL:           parseDurationOr(getEnv("OFFER_TTL", "720h"), 30*24*time.Hour),
 		},
 	}
 
+	config.Payments = PaymentsConfig{
+		WebhookSecret:    getEnv("PAYMENTS_WEBHOOK_SECRET", ""),
+		PublicBaseURL:    getEnv("PAYMENTS_PUBLIC_URL", "http://localhost:"+config.Server.Port),
+		SimulatorEnabled: getEnv("PAYMENTS_SIMULATOR", "false") == "true",
+		SimulatorDelay:   parseDurationOr(getEnv("PAYMENTS_SIMULATOR_DELAY", "5s"), 5*time.Second),
+		EcoCash: EcoCashConfig{
+			BaseURL:      getEnv("ECOCASH_API_URL", ""),
+			MerchantCode: getEnv("ECOCASH_MERCHANT_CODE", ""),
+			APIKey:       getEnv("ECOCASH_API_KEY", ""),
+		},
+	}
+
+	config.Invoices = InvoiceConfig{
+		IssuerName:      getEnv("INVOICE_ISSUER_NAME", "AirMassXpress"),
+		IssuerAddress:   getEnv("INVOICE_ISSUER_ADDRESS", ""),
+		IssuerTaxNumber: getEnv("INVOICE_ISSUER_TAX_NUMBER", ""),
+		TaxName:         getEnv("INVOICE_TAX_NAME", "VAT"),
+		TaxRate:         parseFloatOr(getEnv("INVOICE_TAX_RATE", "0"), 0),
+	}
+
+	config.Storage = StorageConfig{
+		Driver:        getEnv("STORAGE_DRIVER", defaultStorageDriver(config)),
+		LocalDir:      getEnv("LOCAL_STORAGE_DIR", "./uploads"),
+		PublicBaseURL: getEnv("LOCAL_STORAGE_URL", "/uploads"),
+		SigningSecret: getEnv("STORAGE_SIGNING_SECRET", deriveSecret(config.JWT.Secret, "storage-signing")),
+	}
+
 	return config, nil
 }
 
+// defaultStorageDriver preserves the behaviour from before STORAGE_DRIVER existed:
+// Supabase when it is configured, otherwise USE_LOCAL_STORAGE picks local or S3.
+func defaultStorageDriver(cfg *Config) string {
+	switch {
+	case cfg.Supabase.URL != "" && cfg.Supabase.Key != "":
+		return "supabase"
+	case cfg.AWS.UseLocalStorage:
+		return "local"
+	default:
+		return "s3"
+	}
+}
+
 type SupabaseConfig struct {
-	URL string
-	Key string
+	URL    string
+	Key    string
+	Bucket string
 }
 
 func (c *Config) GetDSN() string {
@@ -132,9 +250,29 @@ func getEnv(key, defaultValue string) string {
 }
 
 func parseDuration(s string) time.Duration {
+	return parseDurationOr(s, 15*time.Minute)
+}
+
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
-		return 15 * time.Minute
+		return fallback
 	}
 	return d
 }
+
+// deriveSecret derives a key for one purpose from root with a labelled HMAC, so
+// that a key leaked from one use cannot sign for another
+func deriveSecret(root, label string) string {
+	mac := hmac.New(sha256.New, []byte(root))
+	mac.Write([]byte(label))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func parseFloatOr(s string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
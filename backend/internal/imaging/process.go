@@ -0,0 +1,130 @@
+// Package imaging sanitises uploaded photos and derives display variants from
+// them using only the standard library decoders (JPEG, PNG and GIF).
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// maxPixels bounds decoded image size so a small, highly compressed upload cannot
+// exhaust memory when expanded.
+const maxPixels = 50_000_000
+
+const jpegQuality = 85
+
+var (
+	// ErrUnsupported is returned for formats that cannot be decoded here.
+	ErrUnsupported = errors.New("image format cannot be processed")
+	// ErrTooManyPixels is returned for images larger than maxPixels.
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+// Photo is a decoded upload with its metadata removed.
+type Photo struct {
+	// Data is the sanitised file to store in place of the upload
+	Data        []byte
+	ContentType string
+	// Image is the upright decoded image, or nil when only metadata could be stripped
+	Image  image.Image
+	Width  int
+	Height int
+}
+
+// Process strips EXIF and other metadata from an uploaded image. JPEGs are
+// rotated upright and re-encoded, which drops every metadata segment including
+// GPS coordinates. PNGs are re-encoded; GIFs carry no EXIF and are kept as-is so
+// animations survive. WebP metadata chunks are removed without decoding.
+func Process(data []byte, contentType string) (*Photo, error) {
+	if contentType == "image/webp" {
+		stripped, err := StripWebPMetadata(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Photo{Data: stripped, ContentType: contentType}, nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	photo := &Photo{ContentType: contentType}
+	switch format {
+	case "jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		photo.Image = Orient(img, JPEGOrientation(data))
+		if photo.Data, err = encodeJPEG(photo.Image); err != nil {
+			return nil, err
+		}
+		photo.ContentType = "image/jpeg"
+	case "png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		photo.Image = img
+		if photo.Data, err = encodePNG(img); err != nil {
+			return nil, err
+		}
+		photo.ContentType = "image/png"
+	case "gif":
+		img, err := gif.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		photo.Image = img
+		photo.Data = data
+	default:
+		return nil, ErrUnsupported
+	}
+
+	b := photo.Image.Bounds()
+	photo.Width, photo.Height = b.Dx(), b.Dy()
+	return photo, nil
+}
+
+// Encode encodes a variant as JPEG, or as PNG when it has transparency.
+// It returns the encoded bytes, content type and file extension.
+func Encode(img image.Image) ([]byte, string, string, error) {
+	if opaque(img) {
+		data, err := encodeJPEG(img)
+		return data, "image/jpeg", ".jpg", err
+	}
+	data, err := encodePNG(img)
+	return data, "image/png", ".png", err
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
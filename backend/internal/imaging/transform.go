@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// toNRGBA returns img as an *image.NRGBA with its origin at (0, 0)
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
+
+// Orient rotates and flips img so that it displays upright, given its EXIF orientation.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for sy := 0; sy < h; sy++ {
+		for sx := 0; sx < w; sx++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontal
+				dx, dy = w-1-sx, sy
+			case 3: // rotate 180
+				dx, dy = w-1-sx, h-1-sy
+			case 4: // mirror vertical
+				dx, dy = sx, h-1-sy
+			case 5: // transpose
+				dx, dy = sy, sx
+			case 6: // rotate 90 clockwise
+				dx, dy = h-1-sy, sx
+			case 7: // transverse
+				dx, dy = h-1-sy, w-1-sx
+			case 8: // rotate 90 counter-clockwise
+				dx, dy = sy, w-1-sx
+			}
+			si := sy*src.Stride + sx*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// Fit scales img down so neither side exceeds maxSize, preserving aspect ratio.
+// Images already within maxSize are returned unchanged.
+func Fit(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+
+	scale := float64(maxSize) / float64(max(w, h))
+	dw := max(1, int(math.Round(float64(w)*scale)))
+	dh := max(1, int(math.Round(float64(h)*scale)))
+	return resize(toNRGBA(img), dw, dh)
+}
+
+// resize resamples src to dw x dh with a separable triangle filter widened to the
+// scale factor, which averages every source pixel when shrinking. Filtering is
+// done on premultiplied alpha so transparent edges do not bleed dark fringes.
+func resize(src *image.NRGBA, dw, dh int) *image.NRGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+
+	// Premultiplied float copy of the source
+	in := make([]float32, sw*sh*4)
+	for y := 0; y < sh; y++ {
+		for x := 0; x < sw; x++ {
+			si := y*src.Stride + x*4
+			a := float32(src.Pix[si+3]) / 255
+			o := (y*sw + x) * 4
+			in[o] = float32(src.Pix[si]) * a
+			in[o+1] = float32(src.Pix[si+1]) * a
+			in[o+2] = float32(src.Pix[si+2]) * a
+			in[o+3] = float32(src.Pix[si+3])
+		}
+	}
+
+	// Horizontal pass: sw x sh -> dw x sh
+	xWeights := filterWeights(sw, dw)
+	mid := make([]float32, dw*sh*4)
+	for y := 0; y < sh; y++ {
+		for x, taps := range xWeights {
+			var acc [4]float32
+			for _, t := range taps {
+				o := (y*sw + t.index) * 4
+				acc[0] += in[o] * t.weight
+				acc[1] += in[o+1] * t.weight
+				acc[2] += in[o+2] * t.weight
+				acc[3] += in[o+3] * t.weight
+			}
+			copy(mid[(y*dw+x)*4:], acc[:])
+		}
+	}
+
+	// Vertical pass: dw x sh -> dw x dh
+	yWeights := filterWeights(sh, dh)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y, taps := range yWeights {
+		for x := 0; x < dw; x++ {
+			var acc [4]float32
+			for _, t := range taps {
+				o := (t.index*dw + x) * 4
+				acc[0] += mid[o] * t.weight
+				acc[1] += mid[o+1] * t.weight
+				acc[2] += mid[o+2] * t.weight
+				acc[3] += mid[o+3] * t.weight
+			}
+
+			di := y*dst.Stride + x*4
+			alpha := clamp255(acc[3])
+			dst.Pix[di+3] = alpha
+			if alpha == 0 {
+				continue
+			}
+			a := acc[3] / 255
+			dst.Pix[di] = clamp255(acc[0] / a)
+			dst.Pix[di+1] = clamp255(acc[1] / a)
+			dst.Pix[di+2] = clamp255(acc[2] / a)
+		}
+	}
+	return dst
+}
+
+type filterTap struct {
+	index  int
+	weight float32
+}
+
+// filterWeights returns, for each destination pixel, the normalised source taps
+func filterWeights(srcSize, dstSize int) [][]filterTap {
+	scale := float64(srcSize) / float64(dstSize)
+	radius := math.Max(scale, 1)
+
+	weights := make([][]filterTap, dstSize)
+	for d := 0; d < dstSize; d++ {
+		center := (float64(d)+0.5)*scale - 0.5
+		lo := max(0, int(math.Ceil(center-radius)))
+		hi := min(srcSize-1, int(math.Floor(center+radius)))
+
+		var taps []filterTap
+		var sum float64
+		for s := lo; s <= hi; s++ {
+			w := 1 - math.Abs(float64(s)-center)/radius
+			if w <= 0 {
+				continue
+			}
+			taps = append(taps, filterTap{index: s, weight: float32(w)})
+			sum += w
+		}
+		if len(taps) == 0 {
+			// Degenerate case: take the nearest source pixel
+			nearest := min(srcSize-1, max(0, int(math.Round(center))))
+			taps = []filterTap{{index: nearest, weight: 1}}
+			sum = 1
+		}
+		for i := range taps {
+			taps[i].weight /= float32(sum)
+		}
+		weights[d] = taps
+	}
+	return weights
+}
+
+func clamp255(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Booking kinds
+const (
+	BookingKindHire     = "hire"
+	BookingKindBlockout = "blockout"
+)
+
+// Booking statuses
+const (
+	BookingStatusActive   = "active"
+	BookingStatusReleased = "released"
+)
+
+// EquipmentBooking reserves an inventory item for [StartsAt, EndsAt). Hire bookings
+// are created when an equipment offer is accepted; blockouts are maintenance or
+// other windows the owner sets aside.
+type EquipmentBooking struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InventoryID uuid.UUID  `gorm:"type:uuid;not null;index:idx_equipment_bookings_window,priority:1" json:"inventory_id"`
+	Kind        string     `gorm:"type:varchar(20);not null" json:"kind"` // hire, blockout
+	Status      string     `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	StartsAt    time.Time  `gorm:"not null;index:idx_equipment_bookings_window,priority:2" json:"starts_at"`
+	EndsAt      time.Time  `gorm:"not null" json:"ends_at"`
+	TaskID      *uuid.UUID `gorm:"type:uuid;index" json:"task_id,omitempty"`
+	OfferID     *uuid.UUID `gorm:"type:uuid" json:"offer_id,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null" json:"created_by_id"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
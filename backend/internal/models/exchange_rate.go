@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate is the price of one unit of Base in Quote from EffectiveAt until
+// a later rate for the pair takes over. Rates are kept as history and used only
+// to show amounts in another currency; money always moves in the task's currency.
+type ExchangeRate struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Base        string          `gorm:"type:varchar(3);not null;index:idx_exchange_rates_pair" json:"base"`
+	Quote       string          `gorm:"type:varchar(3);not null;index:idx_exchange_rates_pair" json:"quote"`
+	Rate        decimal.Decimal `gorm:"type:decimal(18,8);not null" json:"rate"`
+	EffectiveAt time.Time       `gorm:"not null;index:idx_exchange_rates_pair" json:"effective_at"`
+	// Source notes where the rate came from, e.g. the central bank's daily fix
+	Source    string    `gorm:"type:varchar(100)" json:"source,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
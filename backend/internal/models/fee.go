@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Sides a fee rule charges
+const (
+	FeeSidePoster = "poster" // service fee added to what the poster pays
+	FeeSideTasker = "tasker" // commission deducted from what the tasker receives
+)
+
+// FeeRule prices the platform's cut on one side of a task. Each side uses the
+// single best rule matching the task: promotions beat standard rules, then a rule
+// naming both category and task type beats one naming either, which beats a
+// catch-all; ties go to the most recently updated rule.
+type FeeRule struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name string    `gorm:"type:varchar(100);not null" json:"name"`
+	Side string    `gorm:"type:varchar(10);not null;index" json:"side"` // poster, tasker
+	// Category and TaskType narrow the rule; empty matches any
+	Category string `gorm:"type:varchar(100);not null;default:''" json:"category"`
+	TaskType string `gorm:"type:varchar(20);not null;default:''" json:"task_type"`
+	// Currency limits the rule to tasks in that currency and is required for a
+	// flat fee or caps, which are amounts; empty matches any
+	Currency string `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	// The fee is Percent of the offer amount plus Flat, clamped to MinFee and MaxFee
+	Percent decimal.Decimal  `gorm:"type:decimal(5,2);not null;default:0" json:"percent"`
+	Flat    decimal.Decimal  `gorm:"type:decimal(10,2);not null;default:0" json:"flat"`
+	MinFee  *decimal.Decimal `gorm:"type:decimal(10,2)" json:"min_fee,omitempty"`
+	MaxFee  *decimal.Decimal `gorm:"type:decimal(10,2)" json:"max_fee,omitempty"`
+	// Promo rules override standard ones while their window is open
+	Promo     bool       `gorm:"default:false" json:"promo"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	Active    bool       `gorm:"default:true;index" json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// FeeLine is one fee charged on a task, as priced when quoted
+type FeeLine struct {
+	RuleID  uuid.UUID        `json:"rule_id"`
+	Name    string           `json:"name"`
+	Side    string           `json:"side"`
+	Promo   bool             `json:"promo"`
+	Percent decimal.Decimal  `json:"percent"`
+	Flat    decimal.Decimal  `json:"flat"`
+	MinFee  *decimal.Decimal `json:"min_fee,omitempty"`
+	MaxFee  *decimal.Decimal `json:"max_fee,omitempty"`
+	Amount  decimal.Decimal  `json:"amount"`
+}
+
+// FeeQuote breaks an offer amount down into what the poster pays and what the
+// tasker receives
+type FeeQuote struct {
+	Amount       decimal.Decimal `json:"amount"`
+	ServiceFee   decimal.Decimal `json:"service_fee"`
+	Commission   decimal.Decimal `json:"commission"`
+	PosterTotal  decimal.Decimal `json:"poster_total"`
+	TaskerPayout decimal.Decimal `json:"tasker_payout"`
+	Lines        []FeeLine       `json:"lines"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Hire event kinds
+const (
+	HireEventCheckIn  = "check_in"
+	HireEventCheckOut = "check_out"
+)
+
+// HireEvent records the equipment arriving on site (check-in) or leaving it
+// (check-out). One party records it and the other confirms it; confirmed events
+// are final.
+type HireEvent struct {
+	ID           uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID       uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_hire_events_task_kind" json:"task_id"`
+	Kind         string           `gorm:"type:varchar(20);not null;uniqueIndex:idx_hire_events_task_kind" json:"kind"` // check_in, check_out
+	OccurredAt   time.Time        `gorm:"not null" json:"occurred_at"`
+	Lat          *float64         `gorm:"type:decimal(10,8)" json:"lat,omitempty"`
+	Lng          *float64         `gorm:"type:decimal(11,8)" json:"lng,omitempty"`
+	EngineHours  *decimal.Decimal `gorm:"type:decimal(10,1)" json:"engine_hours,omitempty"`
+	Photos       []string         `gorm:"type:jsonb;serializer:json" json:"photos,omitempty"`
+	Note         string           `gorm:"type:text" json:"note,omitempty"`
+	RecordedByID uuid.UUID        `gorm:"type:uuid;not null" json:"recorded_by_id"`
+	// ConfirmedByID is the other party, once they agree with the record
+	ConfirmedByID *uuid.UUID `gorm:"type:uuid" json:"confirmed_by_id,omitempty"`
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// HireSettlement is the metered bill for an equipment hire, computed from the
+// confirmed check-in and check-out against the accepted offer's rate.
+type HireSettlement struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"task_id"`
+	OfferID  uuid.UUID `gorm:"type:uuid;not null" json:"offer_id"`
+	RateType string    `gorm:"type:varchar(20)" json:"rate_type"`
+	// ElapsedHours is check-out minus check-in; MachineHours is the engine-hour
+	// delta when both readings were given, otherwise the elapsed time
+	ElapsedHours    decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"elapsed_hours"`
+	MachineHours    decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"machine_hours"`
+	BilledQuantity  decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"billed_quantity"`
+	EstimatedAmount decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"estimated_amount"`
+	BilledAmount    decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"billed_amount"`
+	Difference      decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"difference"`
+	Lines           []QuoteLine     `gorm:"type:jsonb;serializer:json" json:"lines"`
+
+	// UncollectedAmount is the part of the bill above what the poster already paid
+	// into held escrow. The platform cannot collect it, so the parties settle it directly.
+	UncollectedAmount decimal.Decimal `gorm:"type:decimal(10,2);not null;default:0" json:"uncollected_amount"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
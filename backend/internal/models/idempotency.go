@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey records a client-supplied Idempotency-Key for a state-changing
+// request, so a retried request returns the original result instead of acting twice.
+// Scope names the operation and its target, e.g. "offer_accept:<offer id>".
+type IdempotencyKey struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_keys_user_key" json:"user_id"`
+	Key        string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_user_key" json:"key"`
+	Scope      string    `gorm:"size:100;not null" json:"scope"`
+	ResourceID uuid.UUID `gorm:"type:uuid;not null" json:"resource_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
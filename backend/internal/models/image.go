@@ -0,0 +1,18 @@
+package models
+
+// ImageVariants are the resized copies and placeholder generated for an uploaded
+// photo. Fields are empty until the image pipeline has processed the upload.
+type ImageVariants struct {
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	MediumURL    string `json:"medium_url,omitempty"`
+	LargeURL     string `json:"large_url,omitempty"`
+	Blurhash     string `json:"blurhash,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+// PhotoVariant records the variants of one photo in a list of photo URLs
+type PhotoVariant struct {
+	URL string `json:"url"`
+	ImageVariants
+}
@@ -7,17 +7,25 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Inventory booking modes
+const (
+	BookingModeRequest = "request" // hirers post a task and wait for an offer
+	BookingModeInstant = "instant" // hirers book directly at the published rates
+)
+
 type InventoryItem struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	Name        string    `gorm:"not null" json:"name"`
-	Category    string    `gorm:"not null;index" json:"category"`
-	Capacity    string    `json:"capacity,omitempty"`
-	Location    string    `json:"location,omitempty"`
-	Photos      []string  `gorm:"type:jsonb;serializer:json" json:"photos,omitempty"`
-	IsAvailable bool      `gorm:"default:true" json:"is_available"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name     string    `gorm:"not null" json:"name"`
+	Category string    `gorm:"not null;index" json:"category"`
+	Capacity string    `json:"capacity,omitempty"`
+	Location string    `json:"location,omitempty"`
+	Photos   []string  `gorm:"type:jsonb;serializer:json" json:"photos,omitempty"`
+	// PhotoVariants holds the generated sizes of each processed photo, keyed by URL
+	PhotoVariants []PhotoVariant `gorm:"type:jsonb;serializer:json" json:"photo_variants,omitempty"`
+	IsAvailable   bool           `gorm:"default:true" json:"is_available"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
 
 	// V2 Fields
 	CapacityID      *uuid.UUID       `gorm:"type:uuid" json:"capacity_id,omitempty"`
@@ -30,20 +38,56 @@ type InventoryItem struct {
 	DeliveryFee     *decimal.Decimal `gorm:"type:decimal(10,2)" json:"delivery_fee,omitempty"`
 	OperatorBundled bool             `gorm:"default:true" json:"operator_bundled"`
 	OperatorFee     *decimal.Decimal `gorm:"type:decimal(10,2)" json:"operator_fee,omitempty"`
+	// FuelRate is charged per machine hour when the poster wants fuel included
+	FuelRate *decimal.Decimal `gorm:"type:decimal(10,2)" json:"fuel_rate,omitempty"`
+	// Currency is what the rates and fees are in
+	Currency    string `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+	BookingMode string `gorm:"type:varchar(20);not null;default:'request'" json:"booking_mode"` // request, instant
+
+	// Maintenance and certificate summary, kept up to date by services.RefreshCompliance
+	LastInspectedAt *time.Time       `json:"last_inspected_at,omitempty"`
+	LastServicedAt  *time.Time       `json:"last_serviced_at,omitempty"`
+	LastHourMeter   *decimal.Decimal `gorm:"type:decimal(10,1)" json:"last_hour_meter,omitempty"`
+	// ComplianceStatus is empty until a certificate is uploaded, then valid, expiring or expired
+	ComplianceStatus     string     `gorm:"type:varchar(20)" json:"compliance_status,omitempty"`
+	CertificatesExpireAt *time.Time `json:"certificates_expire_at,omitempty"`
+	// ComplianceHold is set when an expired required certificate took the item off
+	// the market, so that renewing it puts the item back
+	ComplianceHold bool `gorm:"default:false" json:"compliance_hold"`
+
+	// Computed by geo queries; never stored
+	DistanceKm *float64 `gorm:"->;-:migration" json:"distance_km,omitempty"`
 
 	// Relationships
-	User             *User             `gorm:"foreignKey:UserID" json:"-"`
+	User              *User              `gorm:"foreignKey:UserID" json:"-"`
 	EquipmentCapacity *EquipmentCapacity `gorm:"foreignKey:CapacityID" json:"equipment_capacity,omitempty"`
 }
 
+// EquipmentType is an admin-managed kind of equipment, such as Excavator
+type EquipmentType struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	SortOrder   int       `gorm:"default:0" json:"sort_order"`
+	// RetiredAt hides the type and its tiers from new tasks and listings; existing
+	// references keep working
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
 // EquipmentCapacity represents a capacity tier for an equipment type
 type EquipmentCapacity struct {
 	ID            uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	EquipmentType string           `gorm:"not null;index" json:"equipment_type"`
-	CapacityCode  string           `gorm:"not null" json:"capacity_code"`
+	EquipmentType string           `gorm:"not null;index;uniqueIndex:idx_equipment_capacities_type_code" json:"equipment_type"`
+	CapacityCode  string           `gorm:"not null;uniqueIndex:idx_equipment_capacities_type_code" json:"capacity_code"`
 	DisplayName   string           `gorm:"not null" json:"display_name"`
 	MinWeightTons *decimal.Decimal `gorm:"type:decimal(10,2)" json:"min_weight_tons,omitempty"`
 	MaxWeightTons *decimal.Decimal `gorm:"type:decimal(10,2)" json:"max_weight_tons,omitempty"`
 	SortOrder     int              `gorm:"default:0" json:"sort_order"`
-	CreatedAt     time.Time        `json:"created_at"`
+	// RetiredAt stops the tier being chosen for new tasks and items; tasks and
+	// items that already reference it keep it
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
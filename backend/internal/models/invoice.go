@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrInvoiceImmutable is returned when changing or deleting an issued invoice
+var ErrInvoiceImmutable = errors.New("invoices are immutable once issued; issue a credit note instead")
+
+// Invoice kinds. A credit note corrects an invoice by crediting back part or all
+// of its total; invoices themselves never change.
+const (
+	InvoiceKindInvoice    = "invoice"
+	InvoiceKindCreditNote = "credit_note"
+)
+
+// Invoice is the tax document for a completed task, or a credit note against
+// one. Parties, issuer, lines, fees and tax are copied in when it is issued so it
+// reads the same however the task, users or fee rules change later.
+type Invoice struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Number string    `gorm:"type:varchar(30);not null;uniqueIndex" json:"number"` // INV-2026-000001, CN-2026-000001
+	Kind   string    `gorm:"type:varchar(20);not null;index" json:"kind"`         // invoice, credit_note
+	TaskID uuid.UUID `gorm:"type:uuid;not null;index" json:"task_id"`
+	// EscrowID is the escrow the invoice bills
+	EscrowID *uuid.UUID `gorm:"type:uuid;index" json:"escrow_id,omitempty"`
+	// CreditedInvoiceID is the invoice a credit note corrects
+	CreditedInvoiceID     *uuid.UUID `gorm:"type:uuid;index" json:"credited_invoice_id,omitempty"`
+	CreditedInvoiceNumber string     `gorm:"type:varchar(30)" json:"credited_invoice_number,omitempty"`
+	PosterID              uuid.UUID  `gorm:"type:uuid;not null;index" json:"poster_id"`
+	TaskerID              uuid.UUID  `gorm:"type:uuid;not null;index" json:"tasker_id"`
+
+	IssuerName      string `gorm:"type:varchar(200);not null" json:"issuer_name"`
+	IssuerAddress   string `gorm:"type:text" json:"issuer_address,omitempty"`
+	IssuerTaxNumber string `gorm:"type:varchar(50)" json:"issuer_tax_number,omitempty"`
+	PosterName      string `gorm:"type:varchar(200)" json:"poster_name"`
+	TaskerName      string `gorm:"type:varchar(200)" json:"tasker_name"`
+	TaskTitle       string `gorm:"type:varchar(255)" json:"task_title"`
+
+	Currency string      `gorm:"type:varchar(3);not null" json:"currency"`
+	Lines    []QuoteLine `gorm:"type:jsonb;serializer:json" json:"lines"`
+	// Subtotal is the tasker's price; the poster paid Total, Subtotal plus the
+	// service fee, and the tasker is paid Subtotal less commission
+	Subtotal     decimal.Decimal `gorm:"type:decimal(12,2);not null" json:"subtotal"`
+	ServiceFee   decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0" json:"service_fee"`
+	Commission   decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0" json:"commission"`
+	Fees         []FeeLine       `gorm:"type:jsonb;serializer:json" json:"fees,omitempty"`
+	Total        decimal.Decimal `gorm:"type:decimal(12,2);not null" json:"total"`
+	TaskerPayout decimal.Decimal `gorm:"type:decimal(12,2);not null" json:"tasker_payout"`
+	// Prices include tax; Tax is the part of Total that is TaxName at TaxRate percent
+	TaxName string          `gorm:"type:varchar(20)" json:"tax_name,omitempty"`
+	TaxRate decimal.Decimal `gorm:"type:decimal(5,2);not null;default:0" json:"tax_rate"`
+	Tax     decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0" json:"tax"`
+
+	PaymentMethod string `gorm:"type:varchar(20)" json:"payment_method,omitempty"`
+	// Reason explains a credit note
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	IssuedAt  time.Time `gorm:"not null;index" json:"issued_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (i *Invoice) BeforeUpdate(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+func (i *Invoice) BeforeDelete(tx *gorm.DB) error {
+	return ErrInvoiceImmutable
+}
+
+// InvoiceSequence hands out gap-free document numbers per series and year
+type InvoiceSequence struct {
+	Series     string `gorm:"type:varchar(10);primaryKey" json:"series"`
+	Year       int    `gorm:"primaryKey;autoIncrement:false" json:"year"`
+	LastNumber int    `gorm:"not null;default:0" json:"last_number"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job run outcomes
+const (
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// JobRun records one execution of a scheduled background job. Runs skipped
+// because another instance held the job's lock are not recorded.
+type JobRun struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	JobName    string    `gorm:"size:100;not null;index:idx_job_runs_job_started,priority:1" json:"job_name"`
+	Instance   string    `gorm:"size:255" json:"instance"`
+	Status     string    `gorm:"size:20;not null" json:"status"` // succeeded, failed
+	Processed  int       `gorm:"default:0" json:"processed"`     // rows acted on, e.g. tasks expired
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time `gorm:"not null;index:idx_job_runs_job_started,priority:2,sort:desc" json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
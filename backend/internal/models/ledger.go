@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrLedgerImmutable is returned when changing or deleting a posted journal entry
+var ErrLedgerImmutable = errors.New("journal entries are immutable; post a reversing entry instead")
+
+// Ledger account kinds. Wallets, escrow, fees and payout clearing are
+// credit-normal: a positive balance is money the platform holds for someone or
+// has earned. Provider funds are debit-normal: money sitting at a provider.
+const (
+	LedgerWallet         = "wallet"          // what the platform owes a user; negative when the user owes
+	LedgerEscrow         = "escrow"          // task money committed by posters until release or refund
+	LedgerFees           = "fees"            // platform revenue
+	LedgerPayoutClearing = "payout_clearing" // payouts and refunds sent to a provider, awaiting confirmation
+	LedgerProviderFunds  = "provider_funds"  // money held at a payment provider
+)
+
+// Journal entry kinds
+const (
+	JournalEscrowOpened     = "escrow_opened"
+	JournalEscrowAdjusted   = "escrow_adjusted"
+	JournalEscrowReleased   = "escrow_released"
+	JournalEscrowRefunded   = "escrow_refunded"
+	JournalEscrowCancelled  = "escrow_cancelled"
+	JournalCashSettled      = "cash_settled"
+	JournalPaymentCollected = "payment_collected"
+	JournalPayoutQueued     = "payout_queued"
+	JournalPayoutSent       = "payout_sent"
+	JournalPayoutFailed     = "payout_failed"
+	JournalRefundQueued     = "refund_queued"
+	JournalRefundSent       = "refund_sent"
+	JournalRefundFailed     = "refund_failed"
+)
+
+// LedgerAccount is one account in the double-entry ledger, in one currency.
+// Code identifies it, e.g. wallet:<user id>:USD or escrow:USD.
+type LedgerAccount struct {
+	ID       uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code     string     `gorm:"type:varchar(100);not null;uniqueIndex" json:"code"`
+	Kind     string     `gorm:"type:varchar(20);not null;index" json:"kind"`
+	UserID   *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Provider string     `gorm:"type:varchar(20)" json:"provider,omitempty"`
+	Currency string     `gorm:"type:varchar(3);not null" json:"currency"`
+	// CreatedAt only; accounts never change
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JournalEntry is a balanced set of postings recording one money event. Entries
+// are never changed or deleted; mistakes are corrected by a reversing entry.
+// Reference is unique per event, so posting the same event twice is a no-op.
+type JournalEntry struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Reference string          `gorm:"type:varchar(100);not null;uniqueIndex" json:"reference"`
+	Kind      string          `gorm:"type:varchar(30);not null;index" json:"kind"`
+	TaskID    *uuid.UUID      `gorm:"type:uuid;index" json:"task_id,omitempty"`
+	EscrowID  *uuid.UUID      `gorm:"type:uuid;index" json:"escrow_id,omitempty"`
+	PaymentID *uuid.UUID      `gorm:"type:uuid;index" json:"payment_id,omitempty"`
+	Postings  []LedgerPosting `gorm:"foreignKey:EntryID" json:"postings,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// LedgerPosting moves Amount into or out of an account: positive amounts are
+// debits, negative amounts credits. An entry's postings sum to zero.
+type LedgerPosting struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EntryID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"entry_id"`
+	AccountID uuid.UUID       `gorm:"type:uuid;not null;index" json:"account_id"`
+	Amount    decimal.Decimal `gorm:"type:decimal(12,2);not null" json:"amount"`
+	CreatedAt time.Time       `gorm:"index" json:"created_at"`
+}
+
+func (e *JournalEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (e *JournalEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (p *LedgerPosting) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (p *LedgerPosting) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Maintenance record kinds
+const (
+	MaintenanceService    = "service"
+	MaintenanceRepair     = "repair"
+	MaintenanceInspection = "inspection"
+)
+
+// Certificate kinds. Inspection and insurance certificates are required to hire
+// an item out once uploaded; other documents only show a warning when they lapse.
+const (
+	CertificateInspection = "inspection"
+	CertificateInsurance  = "insurance"
+	CertificateOther      = "other"
+)
+
+// Compliance statuses summarising an item's certificates
+const (
+	ComplianceValid    = "valid"
+	ComplianceExpiring = "expiring"
+	ComplianceExpired  = "expired"
+)
+
+// MaintenanceRecord is an entry in an item's maintenance log
+type MaintenanceRecord struct {
+	ID          uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InventoryID uuid.UUID         `gorm:"type:uuid;not null;index" json:"inventory_id"`
+	Kind        string            `gorm:"type:varchar(20);not null" json:"kind"` // service, repair, inspection
+	PerformedAt time.Time         `gorm:"not null" json:"performed_at"`
+	HourMeter   *decimal.Decimal  `gorm:"type:decimal(10,1)" json:"hour_meter,omitempty"`
+	Description string            `gorm:"type:text;not null" json:"description"`
+	Parts       []MaintenancePart `gorm:"type:jsonb;serializer:json" json:"parts,omitempty"`
+	Cost        *decimal.Decimal  `gorm:"type:decimal(10,2)" json:"cost,omitempty"`
+	// PerformedBy is the mechanic or workshop, free text
+	PerformedBy string    `json:"performed_by,omitempty"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// MaintenancePart is a part fitted during maintenance
+type MaintenancePart struct {
+	Name       string `json:"name"`
+	PartNumber string `json:"part_number,omitempty"`
+	Quantity   int    `json:"quantity"`
+}
+
+// EquipmentCertificate is an uploaded inspection, insurance or other certificate.
+// A renewal is uploaded as a new certificate; the latest expiry of each kind counts.
+type EquipmentCertificate struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InventoryID uuid.UUID  `gorm:"type:uuid;not null;index" json:"inventory_id"`
+	Kind        string     `gorm:"type:varchar(20);not null" json:"kind"` // inspection, insurance, other
+	Issuer      string     `json:"issuer,omitempty"`
+	Number      string     `json:"number,omitempty"`
+	DocumentURL string     `gorm:"not null" json:"document_url"`
+	IssuedAt    *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/datatypes"
 )
 
@@ -19,13 +20,21 @@ type Notification struct {
 }
 
 type EscrowTransaction struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	TaskID    uuid.UUID `gorm:"type:uuid" json:"task_id"`
-	OfferID   uuid.UUID `gorm:"type:uuid" json:"offer_id"`
-	PosterID  uuid.UUID `gorm:"type:uuid;not null" json:"poster_id"`
-	TaskerID  uuid.UUID `gorm:"type:uuid;not null" json:"tasker_id"`
-	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
-	Status    string    `gorm:"default:'held'" json:"status"` // held, released, refunded
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID   uuid.UUID       `gorm:"type:uuid" json:"task_id"`
+	OfferID  uuid.UUID       `gorm:"type:uuid" json:"offer_id"`
+	PosterID uuid.UUID       `gorm:"type:uuid;not null" json:"poster_id"`
+	TaskerID uuid.UUID       `gorm:"type:uuid;not null" json:"tasker_id"`
+	Amount   decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency string          `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+	Status   string          `gorm:"default:'held'" json:"status"` // pending, held, released, refunded, cancelled
+	// ServiceFee is charged to the poster on top of Amount; Commission is kept
+	// from the tasker's payout. Fees snapshots the rules that priced them.
+	ServiceFee decimal.Decimal `gorm:"type:decimal(10,2);not null;default:0" json:"service_fee"`
+	Commission decimal.Decimal `gorm:"type:decimal(10,2);not null;default:0" json:"commission"`
+	Fees       []FeeLine       `gorm:"type:jsonb;serializer:json" json:"fees,omitempty"`
+	// PaymentMethod is how the poster paid, once they have
+	PaymentMethod string    `gorm:"type:varchar(20)" json:"payment_method,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
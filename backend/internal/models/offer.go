@@ -3,31 +3,56 @@ package models
 import (
 	"time"
 
+	"github.com/airmassxpress/backend/internal/money"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
+// Offer statuses
+const (
+	OfferStatusPending   = "pending"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusRejected  = "rejected"
+	OfferStatusWithdrawn = "withdrawn"
+	OfferStatusExpired   = "expired"
+)
+
 type Offer struct {
-	ID                uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	TaskID            uuid.UUID      `gorm:"type:uuid;not null;index" json:"task_id"`
-	TaskerID          uuid.UUID      `gorm:"type:uuid;not null;index" json:"tasker_id"`
-	Amount            float64        `gorm:"type:decimal(10,2);not null" json:"amount"`
+	ID       uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"task_id"`
+	TaskerID uuid.UUID       `gorm:"type:uuid;not null;index" json:"tasker_id"`
+	Amount   decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	// Currency is always the task's
+	Currency          string         `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
 	Description       string         `gorm:"type:text;not null" json:"description"`
 	EstimatedDuration string         `json:"estimated_duration,omitempty"`
 	Availability      string         `json:"availability,omitempty"`
-	Status            string         `gorm:"default:'pending'" json:"status"` // pending, accepted, rejected, withdrawn
+	Status            string         `gorm:"default:'pending'" json:"status"` // pending, accepted, rejected, withdrawn, expired
+	Version           int            `gorm:"not null;default:1" json:"version"`
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// ViewedByPosterAt is set the first time the poster opens the offer; after that
+	// the amount is locked and other edits are flagged via EditedAt.
+	ViewedByPosterAt *time.Time `json:"viewed_by_poster_at,omitempty"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	// PreviousAmount is the amount before the most recent edit that changed it
+	PreviousAmount *decimal.Decimal `gorm:"type:decimal(10,2)" json:"previous_amount,omitempty"`
+
 	// V2 Equipment Quote Fields
-	QuoteType        string     `gorm:"type:varchar(20)" json:"quote_type,omitempty"` // structured, flexible
-	RateType         string     `gorm:"type:varchar(20)" json:"rate_type,omitempty"`  // hourly, daily, weekly
-	BaseRate         *float64   `gorm:"type:decimal(10,2)" json:"base_rate,omitempty"`
-	DeliveryFee      *float64   `gorm:"type:decimal(10,2)" json:"delivery_fee,omitempty"`
-	OperatorFee      *float64   `gorm:"type:decimal(10,2)" json:"operator_fee,omitempty"`
-	IncludesOperator bool       `gorm:"default:false" json:"includes_operator"`
-	InventoryID      *uuid.UUID `gorm:"type:uuid" json:"inventory_id,omitempty"`
+	QuoteType        string           `gorm:"type:varchar(20)" json:"quote_type,omitempty"` // structured, flexible
+	RateType         string           `gorm:"type:varchar(20)" json:"rate_type,omitempty"`  // hourly, daily, weekly
+	BaseRate         *decimal.Decimal `gorm:"type:decimal(10,2)" json:"base_rate,omitempty"`
+	DeliveryFee      *decimal.Decimal `gorm:"type:decimal(10,2)" json:"delivery_fee,omitempty"`
+	OperatorFee      *decimal.Decimal `gorm:"type:decimal(10,2)" json:"operator_fee,omitempty"`
+	IncludesOperator bool             `gorm:"default:false" json:"includes_operator"`
+	InventoryID      *uuid.UUID       `gorm:"type:uuid" json:"inventory_id,omitempty"`
+	// QuoteLines is the server-computed breakdown of a structured quote
+	QuoteLines []QuoteLine `gorm:"type:jsonb;serializer:json" json:"quote_lines,omitempty"`
+	// Fees prices the platform's service fee and commission on Amount; not stored
+	Fees *FeeQuote `gorm:"-" json:"fees,omitempty"`
 
 	// Relationships
 	Task      *Task          `gorm:"foreignKey:TaskID" json:"task,omitempty"`
@@ -36,6 +61,79 @@ type Offer struct {
 	Inventory *InventoryItem `gorm:"foreignKey:InventoryID" json:"inventory,omitempty"`
 }
 
+// AmountMoney is the offer amount in its currency
+func (o *Offer) AmountMoney() money.Money {
+	return money.New(o.Amount, o.Currency)
+}
+
+// Quote line kinds
+const (
+	QuoteLineBase     = "base"
+	QuoteLineDelivery = "delivery"
+	QuoteLineOperator = "operator"
+	QuoteLineFuel     = "fuel"
+	// QuoteLineAdjustment carries a negotiated difference from the computed quote
+	QuoteLineAdjustment = "adjustment"
+)
+
+// QuoteLine is one priced component of an equipment quote
+type QuoteLine struct {
+	Kind        string          `json:"kind"`
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// Counter-offer statuses
+const (
+	OfferCounterPending    = "pending"
+	OfferCounterAccepted   = "accepted"
+	OfferCounterDeclined   = "declined"
+	OfferCounterSuperseded = "superseded"
+)
+
+// OfferCounter is a proposed change to an offer's terms by the poster or the tasker.
+// Unset fields keep the offer's current value. Only the other party may accept or
+// decline it, and a new counter supersedes any pending one.
+type OfferCounter struct {
+	ID                uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OfferID           uuid.UUID        `gorm:"type:uuid;not null;index" json:"offer_id"`
+	ProposedByID      uuid.UUID        `gorm:"type:uuid;not null" json:"proposed_by_id"`
+	Amount            *decimal.Decimal `gorm:"type:decimal(10,2)" json:"amount,omitempty"`
+	EstimatedDuration *string          `json:"estimated_duration,omitempty"`
+	Availability      *string          `json:"availability,omitempty"`
+	Message           string           `gorm:"type:text" json:"message,omitempty"`
+	Status            string           `gorm:"size:20;not null;default:'pending'" json:"status"`
+	RespondedAt       *time.Time       `json:"responded_at,omitempty"`
+	CreatedAt         time.Time        `json:"created_at"`
+
+	// Relationships
+	ProposedBy *User `gorm:"foreignKey:ProposedByID" json:"proposed_by,omitempty"`
+}
+
+// Offer revision sources
+const (
+	OfferRevisionCreated = "created"
+	OfferRevisionCounter = "counter"
+	OfferRevisionEdit    = "edit"
+)
+
+// OfferRevision is a snapshot of an offer's terms at one version. A revision is
+// written whenever the terms change, so the rows form the full negotiation history.
+type OfferRevision struct {
+	ID                uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OfferID           uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_offer_revisions_offer_version" json:"offer_id"`
+	Version           int             `gorm:"not null;uniqueIndex:idx_offer_revisions_offer_version" json:"version"`
+	Amount            decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	EstimatedDuration string          `json:"estimated_duration,omitempty"`
+	Availability      string          `json:"availability,omitempty"`
+	Source            string          `gorm:"size:20;not null" json:"source"` // created, counter, edit
+	CounterID         *uuid.UUID      `gorm:"type:uuid" json:"counter_id,omitempty"`
+	ChangedByID       *uuid.UUID      `gorm:"type:uuid" json:"changed_by_id,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
 type OfferReply struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	OfferID   uuid.UUID `gorm:"type:uuid;not null" json:"offer_id"`
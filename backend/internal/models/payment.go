@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Escrow statuses. Escrow is pending from acceptance until the poster pays, held
+// while the platform has the money, then released to the tasker on completion or
+// refunded to the poster. Unpaid escrow on a reopened or cancelled task is cancelled.
+const (
+	EscrowPending   = "pending"
+	EscrowHeld      = "held"
+	EscrowReleased  = "released"
+	EscrowRefunded  = "refunded"
+	EscrowCancelled = "cancelled"
+)
+
+// Payment transaction kinds
+const (
+	PaymentCollection = "collection"
+	PaymentPayout     = "payout"
+	PaymentRefund     = "refund"
+)
+
+// Payment transaction statuses. Queued payouts and refunds are sent to the
+// provider by the process_payments job; pending ones await its confirmation.
+const (
+	PaymentQueued    = "queued"
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// PaymentTransaction is one movement of money through a payment provider:
+// collecting an escrow from the poster, paying it out to the tasker, or
+// refunding it to the poster.
+type PaymentTransaction struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EscrowID uuid.UUID `gorm:"type:uuid;not null;index" json:"escrow_id"`
+	TaskID   uuid.UUID `gorm:"type:uuid;not null;index" json:"task_id"`
+	// UserID pays a collection and receives a payout or refund
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind     string    `gorm:"type:varchar(20);not null" json:"kind"`     // collection, payout, refund
+	Provider string    `gorm:"type:varchar(20);not null" json:"provider"` // cash, ecocash, simulator
+	// Reference is ours and sent to the provider; ProviderRef is theirs
+	Reference     string          `gorm:"type:varchar(40);not null;uniqueIndex" json:"reference"`
+	ProviderRef   string          `gorm:"type:varchar(100);index" json:"provider_ref,omitempty"`
+	Amount        decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency      string          `gorm:"type:varchar(3);not null" json:"currency"`
+	Phone         string          `gorm:"type:varchar(20)" json:"phone,omitempty"`
+	Status        string          `gorm:"type:varchar(20);not null;index" json:"status"` // queued, pending, succeeded, failed
+	FailureReason string          `gorm:"type:text" json:"failure_reason,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
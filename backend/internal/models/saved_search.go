@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Saved search alert frequencies
+const (
+	SavedSearchInstant = "instant"
+	SavedSearchDaily   = "daily"
+)
+
+// SavedSearch is a tasker's stored browse filter. Newly created tasks that match
+// it trigger an alert, either immediately or in a daily digest.
+type SavedSearch struct {
+	ID        uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string           `gorm:"size:100" json:"name"`
+	Category  string           `gorm:"size:100" json:"category,omitempty"`
+	TaskType  string           `gorm:"size:20" json:"task_type,omitempty"`
+	Keywords  string           `gorm:"size:255" json:"keywords,omitempty"`
+	MinBudget *decimal.Decimal `gorm:"type:decimal(10,2)" json:"min_budget,omitempty"`
+	MaxBudget *decimal.Decimal `gorm:"type:decimal(10,2)" json:"max_budget,omitempty"`
+	// Currency is what the budget bounds are in; tasks in other currencies fall
+	// outside them
+	Currency string `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+
+	// Radius around a point; all three are set together or not at all
+	Lat      *float64 `gorm:"type:decimal(10,8)" json:"lat,omitempty"`
+	Lng      *float64 `gorm:"type:decimal(11,8)" json:"lng,omitempty"`
+	RadiusKm *float64 `gorm:"type:decimal(6,2)" json:"radius_km,omitempty"`
+
+	Frequency    string     `gorm:"size:20;not null;default:'instant'" json:"frequency"` // instant, daily
+	Active       bool       `gorm:"default:true" json:"active"`
+	LastDigestAt *time.Time `json:"last_digest_at,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SavedSearchMatch records that a task matched a saved search. The unique index
+// guarantees each task is alerted at most once per search.
+type SavedSearchMatch struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SavedSearchID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_saved_search_match" json:"saved_search_id"`
+	TaskID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_saved_search_match" json:"task_id"`
+	NotifiedAt    *time.Time `gorm:"index" json:"notified_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	Task *Task `gorm:"foreignKey:TaskID" json:"task,omitempty"`
+}
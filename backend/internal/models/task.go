@@ -3,25 +3,41 @@ package models
 import (
 	"time"
 
+	"github.com/airmassxpress/backend/internal/money"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
+// Task lifecycle statuses. Transitions between them are enforced by
+// services.TaskLifecycle; handlers must never write Task.Status directly.
+const (
+	TaskStatusOpen       = "open"
+	TaskStatusAssigned   = "assigned"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusCompleted  = "completed"
+	TaskStatusCancelled  = "cancelled"
+	TaskStatusExpired    = "expired"
+)
+
 type Task struct {
-	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	PosterID        uuid.UUID      `gorm:"type:uuid;not null" json:"poster_id"`
-	Title           string         `gorm:"not null" json:"title"`
-	Description     string         `gorm:"type:text;not null" json:"description"`
-	Category        string         `gorm:"not null;index" json:"category"`
-	TaskType        string         `gorm:"default:'service';index" json:"task_type"` // service, equipment
-	Budget          float64        `gorm:"type:decimal(10,2);not null" json:"budget"`
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PosterID    uuid.UUID       `gorm:"type:uuid;not null" json:"poster_id"`
+	Title       string          `gorm:"not null" json:"title"`
+	Description string          `gorm:"type:text;not null" json:"description"`
+	Category    string          `gorm:"not null;index" json:"category"`
+	TaskType    string          `gorm:"default:'service';index" json:"task_type"` // service, equipment
+	Budget      decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"budget"`
+	// Currency is what the task is priced in; offers must use it too
+	Currency        string         `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
 	Location        string         `gorm:"not null" json:"location"`
 	Lat             *float64       `gorm:"type:decimal(10,8)" json:"lat,omitempty"`
 	Lng             *float64       `gorm:"type:decimal(11,8)" json:"lng,omitempty"`
 	DateType        string         `gorm:"type:varchar(20)" json:"date_type,omitempty"` // on_date, before_date, flexible
 	Date            *time.Time     `json:"date,omitempty"`
 	TimeOfDay       string         `json:"time_of_day,omitempty"`
-	Status          string         `gorm:"default:'open';index" json:"status"` // open, assigned, in_progress, completed, cancelled
+	ReminderSentAt  *time.Time     `json:"reminder_sent_at,omitempty"`         // set once the assignee has been reminded of Date
+	Status          string         `gorm:"default:'open';index" json:"status"` // open, assigned, in_progress, completed, cancelled, expired
 	AcceptedOfferID *uuid.UUID     `gorm:"type:uuid" json:"accepted_offer_id,omitempty"`
 	ConversationID  *uuid.UUID     `gorm:"type:uuid" json:"conversation_id,omitempty"`
 	OfferCount      int            `gorm:"default:0" json:"offer_count"`
@@ -43,6 +59,18 @@ type Task struct {
 	AddressDetails     string `gorm:"type:text" json:"address_details,omitempty"`
 	LocationConfSource string `gorm:"type:varchar(50);default:'user_confirmed_pin'" json:"location_conf_source,omitempty"`
 
+	// Computed by geo queries; never stored
+	DistanceKm *float64 `gorm:"->;-:migration" json:"distance_km,omitempty"`
+
+	// Computed by keyword search; never stored
+	SearchRank     *float64 `gorm:"->;-:migration" json:"search_rank,omitempty"`
+	Snippet        *string  `gorm:"->;-:migration" json:"snippet,omitempty"`
+	TitleHighlight *string  `gorm:"->;-:migration" json:"title_highlight,omitempty"`
+
+	// DisplayBudget is Budget converted to a currency the client asked for, at the
+	// latest exchange rate; never stored
+	DisplayBudget *money.Money `gorm:"-" json:"display_budget,omitempty"`
+
 	// Relationships
 	Poster           *User              `gorm:"foreignKey:PosterID" json:"poster,omitempty"`
 	Attachments      []TaskAttachment   `gorm:"foreignKey:TaskID" json:"attachments,omitempty"`
@@ -59,11 +87,36 @@ type TaskAttachment struct {
 	Name       string    `json:"name"`
 	OrderIndex int       `gorm:"default:0" json:"order_index"`
 	CreatedAt  time.Time `json:"created_at"`
+
+	ImageVariants `gorm:"embedded"`
+}
+
+// TaskStatusHistory is the append-only audit trail of task status transitions.
+// ActorID is nil when the transition was performed by the system.
+type TaskStatusHistory struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"task_id"`
+	FromStatus string     `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus   string     `gorm:"type:varchar(20);not null" json:"to_status"`
+	ActorID    *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Reason     string     `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Relationships
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
 }
 
 func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == uuid.Nil {
 		t.ID = uuid.New()
 	}
+	if t.Currency == "" {
+		t.Currency = money.Default
+	}
 	return nil
 }
+
+// BudgetMoney is the budget in the task's currency
+func (t *Task) BudgetMoney() money.Money {
+	return money.New(t.Budget, t.Currency)
+}
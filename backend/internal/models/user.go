@@ -17,6 +17,7 @@ type User struct {
 	Bio                  string    `json:"bio,omitempty"`
 	Location             string    `json:"location,omitempty"`
 	IsVerified           bool      `gorm:"default:false" json:"is_verified"`
+	IsAdmin              bool      `gorm:"default:false" json:"is_admin"` // grants the admin API; set only in the database
 	Rating               float64   `gorm:"type:decimal(3,2);default:0" json:"rating"`
 	ReviewCount          int       `gorm:"default:0" json:"review_count"`
 	TasksCompleted       int       `gorm:"default:0" json:"tasks_completed"`
@@ -0,0 +1,88 @@
+// Package money pairs decimal amounts with the ISO 4217 currency they are in,
+// so that amounts in different currencies are never added or compared by
+// accident. The marketplace trades in US dollars and Zimbabwe Gold (ZiG, ISO
+// code ZWG).
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Supported currencies
+const (
+	USD = "USD"
+	ZWG = "ZWG" // Zimbabwe Gold, ZiG
+)
+
+// Default is the currency of tasks, offers and listings that do not name one
+const Default = USD
+
+// Currencies lists the supported currencies
+var Currencies = []string{USD, ZWG}
+
+var (
+	// ErrUnsupportedCurrency is returned for a currency the marketplace does not trade in.
+	ErrUnsupportedCurrency = errors.New("currency must be USD or ZWG")
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+)
+
+// Money is an amount in a currency, held to the cent
+type Money struct {
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// New returns amount in currency, rounded to the cent
+func New(amount decimal.Decimal, currency string) Money {
+	return Money{Amount: amount.Round(2), Currency: currency}
+}
+
+// ParseCurrency normalises a currency code, accepting ZiG for ZWG; empty means
+// Default
+func ParseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	switch code {
+	case "":
+		return Default, nil
+	case "ZIG":
+		return ZWG, nil
+	case USD, ZWG:
+		return code, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+}
+
+// Add returns m plus other, which must be in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return New(m.Amount.Add(other.Amount), m.Currency), nil
+}
+
+// Sub returns m less other, which must be in the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return New(m.Amount.Sub(other.Amount), m.Currency), nil
+}
+
+// Convert returns m in currency to at rate, the price of one unit of m's
+// currency in to
+func (m Money) Convert(rate decimal.Decimal, to string) Money {
+	if to == m.Currency {
+		return m
+	}
+	return New(m.Amount.Mul(rate), to)
+}
+
+// String formats m as e.g. "USD 12.50"
+func (m Money) String() string {
+	return m.Currency + " " + m.Amount.StringFixed(2)
+}
@@ -0,0 +1,74 @@
+package money
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr error
+	}{
+		{"", Default, nil},
+		{"USD", USD, nil},
+		{" usd ", USD, nil},
+		{"ZWG", ZWG, nil},
+		{"zwg", ZWG, nil},
+		{"ZiG", ZWG, nil},
+		{"ZWL", "", ErrUnsupportedCurrency},
+		{"EUR", "", ErrUnsupportedCurrency},
+		{"US", "", ErrUnsupportedCurrency},
+	}
+	for _, tt := range tests {
+		got, err := ParseCurrency(tt.code)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseCurrency(%q) = %q, %v; want %q, %v", tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dec := decimal.RequireFromString
+	tests := []struct {
+		name   string
+		from   Money
+		rate   string
+		to     string
+		want   string
+		wantTo string
+	}{
+		{"USD to ZWG", New(dec("10"), USD), "26.75", ZWG, "ZWG 267.50", ZWG},
+		{"ZWG to USD rounds to the cent", New(dec("100"), ZWG), "0.0373832", USD, "USD 3.74", USD},
+		{"half cent rounds away from zero", New(dec("1"), USD), "0.125", ZWG, "ZWG 0.13", ZWG},
+		{"same currency ignores rate", New(dec("12.5"), USD), "26.75", USD, "USD 12.50", USD},
+		{"zero", New(decimal.Zero, USD), "26.75", ZWG, "ZWG 0.00", ZWG},
+	}
+	for _, tt := range tests {
+		got := tt.from.Convert(dec(tt.rate), tt.to)
+		if got.String() != tt.want || got.Currency != tt.wantTo {
+			t.Errorf("%s: Convert = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	dec := decimal.RequireFromString
+	usd := New(dec("10.25"), USD)
+
+	if got, err := usd.Add(New(dec("2.50"), USD)); err != nil || got.String() != "USD 12.75" {
+		t.Errorf("Add = %s, %v; want USD 12.75", got, err)
+	}
+	if got, err := usd.Sub(New(dec("12.50"), USD)); err != nil || got.String() != "USD -2.25" {
+		t.Errorf("Sub = %s, %v; want USD -2.25", got, err)
+	}
+	if _, err := usd.Add(New(dec("1"), ZWG)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := usd.Sub(New(dec("1"), ZWG)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub across currencies: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
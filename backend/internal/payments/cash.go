@@ -0,0 +1,38 @@
+package payments
+
+import (
+	"context"
+	"errors"
+)
+
+// Cash settles in person between the poster and tasker, so every request
+// succeeds at once and no money moves through the platform.
+type Cash struct{}
+
+func NewCash() *Cash {
+	return &Cash{}
+}
+
+func (c *Cash) Method() string {
+	return MethodCash
+}
+
+func (c *Cash) Collect(ctx context.Context, req Request) (*Result, error) {
+	return &Result{ProviderRef: req.Reference, Status: StatusSucceeded, Message: "To be paid in cash"}, nil
+}
+
+func (c *Cash) Payout(ctx context.Context, req Request) (*Result, error) {
+	return &Result{ProviderRef: req.Reference, Status: StatusSucceeded, Message: "Paid in cash"}, nil
+}
+
+func (c *Cash) Refund(ctx context.Context, req Request) (*Result, error) {
+	return &Result{ProviderRef: req.Reference, Status: StatusSucceeded, Message: "Nothing was collected"}, nil
+}
+
+func (c *Cash) Status(ctx context.Context, providerRef string) (*Result, error) {
+	return &Result{ProviderRef: providerRef, Status: StatusSucceeded}, nil
+}
+
+func (c *Cash) ParseWebhook(body []byte) (*Event, error) {
+	return nil, errors.New("cash payments have no webhook")
+}
@@ -0,0 +1,212 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+)
+
+// ecocashMSISDN matches an Econet number in international form
+var ecocashMSISDN = regexp.MustCompile(`^2637[0-9]{8}$`)
+
+// EcoCash charges and pays Econet mobile-money wallets through a merchant API.
+// Collections prompt the subscriber for their PIN on the handset, so they stay
+// pending until polled. EcoCash callbacks cannot carry our webhook signature, so
+// no notify URL is given and outcomes are only learned by polling Status.
+type EcoCash struct {
+	baseURL      string
+	merchantCode string
+	apiKey       string
+	client       *http.Client
+}
+
+func NewEcoCash(cfg config.EcoCashConfig) (*EcoCash, error) {
+	if cfg.APIKey == "" || cfg.MerchantCode == "" {
+		return nil, errors.New("ecocash payments require ECOCASH_MERCHANT_CODE and ECOCASH_API_KEY")
+	}
+	return &EcoCash{
+		baseURL:      strings.TrimSuffix(cfg.BaseURL, "/"),
+		merchantCode: cfg.MerchantCode,
+		apiKey:       cfg.APIKey,
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// ecocashTransaction is the transaction resource sent to and returned by the API
+type ecocashTransaction struct {
+	ClientCorrelator  string         `json:"clientCorrelator"`
+	ReferenceCode     string         `json:"referenceCode,omitempty"`
+	TranType          string         `json:"tranType,omitempty"`
+	EndUserID         string         `json:"endUserId,omitempty"`
+	MerchantCode      string         `json:"merchantCode,omitempty"`
+	OriginalReference string         `json:"originalEcocashReference,omitempty"`
+	PaymentAmount     *ecocashAmount `json:"paymentAmount,omitempty"`
+	EcocashReference  string         `json:"ecocashReference,omitempty"`
+	Status            string         `json:"transactionOperationStatus,omitempty"`
+	Message           string         `json:"serverReferenceCode,omitempty"`
+}
+
+type ecocashAmount struct {
+	ChargingInformation ecocashCharge `json:"charginginformation"`
+}
+
+type ecocashCharge struct {
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+}
+
+func (e *EcoCash) Method() string {
+	return MethodEcoCash
+}
+
+func (e *EcoCash) Collect(ctx context.Context, req Request) (*Result, error) {
+	return e.submit(ctx, "/transactions/amount", "MER", req)
+}
+
+func (e *EcoCash) Payout(ctx context.Context, req Request) (*Result, error) {
+	return e.submit(ctx, "/transactions/payout", "MTS", req)
+}
+
+func (e *EcoCash) Refund(ctx context.Context, req Request) (*Result, error) {
+	if req.ProviderRef == "" {
+		return nil, errors.New("ecocash refund requires the original ecocash reference")
+	}
+	return e.submit(ctx, "/transactions/refund", "REF", req)
+}
+
+func (e *EcoCash) Status(ctx context.Context, providerRef string) (*Result, error) {
+	httpReq, err := e.newRequest(ctx, http.MethodGet, "/transactions/reference/"+url.PathEscape(providerRef), nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := e.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return e.result(body)
+}
+
+func (e *EcoCash) ParseWebhook(body []byte) (*Event, error) {
+	return nil, errors.New("ecocash payments are polled and have no webhook")
+}
+
+// submit posts a transaction; our reference is the client correlator, which the
+// API uses to reject duplicates
+func (e *EcoCash) submit(ctx context.Context, path, tranType string, req Request) (*Result, error) {
+	msisdn, err := NormalizeMSISDN(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := ecocashTransaction{
+		ClientCorrelator:  req.Reference,
+		ReferenceCode:     req.Reference,
+		TranType:          tranType,
+		EndUserID:         msisdn,
+		MerchantCode:      e.merchantCode,
+		OriginalReference: req.ProviderRef,
+		PaymentAmount: &ecocashAmount{ChargingInformation: ecocashCharge{
+			Amount:      req.Amount.StringFixed(2),
+			Currency:    req.Currency,
+			Description: req.Description,
+		}},
+	}
+
+	payload, err := json.Marshal(txn)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := e.newRequest(ctx, http.MethodPost, path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	body, err := e.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return e.result(body)
+}
+
+func (e *EcoCash) result(body []byte) (*Result, error) {
+	var txn ecocashTransaction
+	if err := json.Unmarshal(body, &txn); err != nil {
+		return nil, fmt.Errorf("unexpected ecocash response: %s", string(body))
+	}
+	return &Result{ProviderRef: txn.EcocashReference, Status: ecocashStatus(txn.Status), Message: txn.Status}, nil
+}
+
+func (e *EcoCash) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+func (e *EcoCash) do(req *http.Request) ([]byte, error) {
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("ecocash api error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// ecocashStatus maps an EcoCash transaction status onto ours
+func ecocashStatus(status string) string {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "COMPLETED", "CHARGED", "SUCCESS", "SUCCESSFUL", "REFUNDED":
+		return StatusSucceeded
+	case "FAILED", "REJECTED", "CANCELLED", "EXPIRED", "DECLINED":
+		return StatusFailed
+	default:
+		// PENDING SUBSCRIBER VALIDATION and the like
+		return StatusPending
+	}
+}
+
+// NormalizeMSISDN turns a local (0771234567) or international (+263 77 123 4567)
+// Econet number into the 263771234567 form EcoCash expects
+func NormalizeMSISDN(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if r == ' ' || r == '-' || r == '+' || r == '(' || r == ')' {
+			return -1
+		}
+		return 'x'
+	}, phone)
+	switch {
+	case strings.HasPrefix(digits, "00263"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "07"):
+		digits = "263" + digits[1:]
+	case strings.HasPrefix(digits, "7") && len(digits) == 9:
+		digits = "263" + digits
+	}
+	if !ecocashMSISDN.MatchString(digits) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPhone, phone)
+	}
+	return digits, nil
+}
@@ -0,0 +1,178 @@
+// Package payments moves money through pluggable providers: EcoCash mobile
+// money, cash settled in person, and a simulator for local development.
+// Providers collect from payers, pay out to payees and refund collections;
+// asynchronous outcomes arrive on a signed webhook or by polling Status.
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+// Payment methods, one per provider
+const (
+	MethodCash      = "cash"
+	MethodEcoCash   = "ecocash"
+	MethodSimulator = "simulator"
+)
+
+// Outcomes reported by providers
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Webhook signature headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	SignatureHeader = "X-Payment-Signature"
+	TimestampHeader = "X-Payment-Timestamp"
+)
+
+// SignatureTolerance is how old a signed webhook may be
+const SignatureTolerance = 5 * time.Minute
+
+var (
+	// ErrUnknownMethod is returned for a payment method with no enabled provider.
+	ErrUnknownMethod = errors.New("payment method is not available")
+	// ErrInvalidPhone is returned for a phone number the provider cannot charge or pay.
+	ErrInvalidPhone = errors.New("invalid mobile money number")
+	// ErrInvalidSignature is returned for a webhook with a missing, forged or stale signature.
+	ErrInvalidSignature = errors.New("invalid or expired webhook signature")
+)
+
+// Request asks a provider to move Amount. Reference is ours and unique per
+// transaction, so providers can deduplicate retries.
+type Request struct {
+	Reference   string
+	Amount      decimal.Decimal
+	Currency    string
+	Phone       string
+	Description string
+	// ProviderRef is the collection being refunded, for refunds
+	ProviderRef string
+}
+
+// Result is a provider's answer to a request or status check
+type Result struct {
+	ProviderRef string
+	Status      string // pending, succeeded, failed
+	Message     string
+}
+
+// Event is an outcome reported on the webhook
+type Event struct {
+	Reference   string
+	ProviderRef string
+	Status      string
+	Message     string
+}
+
+// Provider is implemented by each payment driver.
+type Provider interface {
+	// Method is the payment method the provider handles.
+	Method() string
+	// Collect asks the payer to pay, usually by a prompt on their phone.
+	Collect(ctx context.Context, req Request) (*Result, error)
+	// Payout sends money to the payee.
+	Payout(ctx context.Context, req Request) (*Result, error)
+	// Refund returns a collection, in full or in part, to the payer.
+	Refund(ctx context.Context, req Request) (*Result, error)
+	// Status polls the outcome of an earlier request.
+	Status(ctx context.Context, providerRef string) (*Result, error)
+	// ParseWebhook decodes a verified callback body.
+	ParseWebhook(body []byte) (*Event, error)
+}
+
+// Registry holds the enabled providers by method
+type Registry struct {
+	providers map[string]Provider
+}
+
+// New enables cash, the simulator when cfg.Payments.SimulatorEnabled, and EcoCash
+// when its API is configured. The simulator reports outcomes on the signed webhook,
+// so it needs PAYMENTS_WEBHOOK_SECRET, and it is refused in release mode.
+func New(cfg *config.Config) (*Registry, error) {
+	r := &Registry{providers: map[string]Provider{}}
+	r.add(NewCash())
+	if cfg.Payments.SimulatorEnabled {
+		if cfg.Server.GinMode == "release" {
+			return nil, errors.New("PAYMENTS_SIMULATOR cannot be enabled with GIN_MODE=release")
+		}
+		if cfg.Payments.WebhookSecret == "" {
+			return nil, errors.New("PAYMENTS_SIMULATOR requires PAYMENTS_WEBHOOK_SECRET")
+		}
+		r.add(NewSimulator(cfg.Payments.SimulatorDelay, WebhookURL(cfg, MethodSimulator), cfg.Payments.WebhookSecret))
+	}
+	if cfg.Payments.EcoCash.BaseURL != "" {
+		ecocash, err := NewEcoCash(cfg.Payments.EcoCash)
+		if err != nil {
+			return nil, err
+		}
+		r.add(ecocash)
+	}
+	return r, nil
+}
+
+// WebhookURL is where the provider for method reports outcomes
+func WebhookURL(cfg *config.Config, method string) string {
+	return strings.TrimSuffix(cfg.Payments.PublicBaseURL, "/") + "/api/v1/payments/webhooks/" + method
+}
+
+func (r *Registry) add(p Provider) {
+	r.providers[p.Method()] = p
+}
+
+// Get returns the provider for method
+func (r *Registry) Get(method string) (Provider, error) {
+	if p, ok := r.providers[method]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+}
+
+// Methods lists the enabled payment methods
+func (r *Registry) Methods() []string {
+	methods := make([]string, 0, len(r.providers))
+	for method := range r.providers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Sign returns the webhook signature of body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a webhook's signature and that it was sent within
+// SignatureTolerance of now
+func VerifySignature(secret, signature, timestamp string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" || secret == "" {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package payments
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"reference":"esc_1","status":"succeeded"}`)
+	at := func(d time.Duration) int64 { return now.Add(d).Unix() }
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		wantErr   error
+	}{
+		{"valid", secret, Sign(secret, at(0), body), strconv.FormatInt(at(0), 10), body, nil},
+		{"within tolerance", secret, Sign(secret, at(-4*time.Minute), body), strconv.FormatInt(at(-4*time.Minute), 10), body, nil},
+		{"slightly ahead", secret, Sign(secret, at(time.Minute), body), strconv.FormatInt(at(time.Minute), 10), body, nil},
+		{"stale", secret, Sign(secret, at(-6*time.Minute), body), strconv.FormatInt(at(-6*time.Minute), 10), body, ErrInvalidSignature},
+		{"far ahead", secret, Sign(secret, at(6*time.Minute), body), strconv.FormatInt(at(6*time.Minute), 10), body, ErrInvalidSignature},
+		{"tampered body", secret, Sign(secret, at(0), body), strconv.FormatInt(at(0), 10), []byte(`{"reference":"esc_2","status":"succeeded"}`), ErrInvalidSignature},
+		{"other secret", secret, Sign("other", at(0), body), strconv.FormatInt(at(0), 10), body, ErrInvalidSignature},
+		{"replayed with new timestamp", secret, Sign(secret, at(-time.Hour), body), strconv.FormatInt(at(0), 10), body, ErrInvalidSignature},
+		{"no secret configured", "", Sign("", at(0), body), strconv.FormatInt(at(0), 10), body, ErrInvalidSignature},
+		{"no signature", secret, "", strconv.FormatInt(at(0), 10), body, ErrInvalidSignature},
+		{"bad timestamp", secret, Sign(secret, at(0), body), "yesterday", body, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		if err := VerifySignature(tt.secret, tt.signature, tt.timestamp, tt.body, now); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeMSISDN(t *testing.T) {
+	tests := []struct {
+		phone   string
+		want    string
+		wantErr error
+	}{
+		{"0771234567", "263771234567", nil},
+		{"077 123 4567", "263771234567", nil},
+		{"+263 77-123-4567", "263771234567", nil},
+		{"00263771234567", "263771234567", nil},
+		{"771234567", "263771234567", nil},
+		{"263781234567", "263781234567", nil},
+		{"0241234567", "", ErrInvalidPhone},
+		{"077123456", "", ErrInvalidPhone},
+		{"077123456a", "", ErrInvalidPhone},
+		{"", "", ErrInvalidPhone},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeMSISDN(tt.phone)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("NormalizeMSISDN(%q) = %q, %v; want %q, %v", tt.phone, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
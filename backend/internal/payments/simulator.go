@@ -0,0 +1,140 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// simulatorFailSuffix makes the simulator decline numbers ending in it, so the
+// failure path can be exercised locally
+const simulatorFailSuffix = "0000"
+
+// Simulator stands in for a mobile-money provider during development. Every
+// request is pending at first; after delay it settles and the outcome is posted
+// to the webhook, signed like a real provider's callback. Phone numbers ending in
+// 0000 are declined.
+type Simulator struct {
+	delay      time.Duration
+	webhookURL string
+	secret     string
+	client     *http.Client
+
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+func NewSimulator(delay time.Duration, webhookURL, secret string) *Simulator {
+	return &Simulator{
+		delay:      delay,
+		webhookURL: webhookURL,
+		secret:     secret,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		results:    make(map[string]*Result),
+	}
+}
+
+// simulatorEvent is the webhook body posted by the simulator
+type simulatorEvent struct {
+	Reference   string `json:"reference"`
+	ProviderRef string `json:"provider_ref"`
+	Status      string `json:"status"`
+	Message     string `json:"message,omitempty"`
+}
+
+func (s *Simulator) Method() string {
+	return MethodSimulator
+}
+
+func (s *Simulator) Collect(ctx context.Context, req Request) (*Result, error) {
+	return s.start(req, "Payment approved")
+}
+
+func (s *Simulator) Payout(ctx context.Context, req Request) (*Result, error) {
+	return s.start(req, "Payout sent")
+}
+
+func (s *Simulator) Refund(ctx context.Context, req Request) (*Result, error) {
+	return s.start(req, "Refund sent")
+}
+
+func (s *Simulator) Status(ctx context.Context, providerRef string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if result, ok := s.results[providerRef]; ok {
+		copied := *result
+		return &copied, nil
+	}
+	// Forgotten across a restart; a real provider would still know
+	return &Result{ProviderRef: providerRef, Status: StatusFailed, Message: "Unknown simulator transaction"}, nil
+}
+
+func (s *Simulator) ParseWebhook(body []byte) (*Event, error) {
+	var event simulatorEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return &Event{Reference: event.Reference, ProviderRef: event.ProviderRef, Status: event.Status, Message: event.Message}, nil
+}
+
+// start records a pending request and settles it after the delay
+func (s *Simulator) start(req Request, message string) (*Result, error) {
+	phone := strings.TrimSpace(req.Phone)
+	if phone == "" {
+		return nil, ErrInvalidPhone
+	}
+
+	providerRef := "SIM-" + strings.ToUpper(uuid.NewString()[:8])
+	final := &Result{ProviderRef: providerRef, Status: StatusSucceeded, Message: message}
+	if strings.HasSuffix(phone, simulatorFailSuffix) {
+		final.Status, final.Message = StatusFailed, "Insufficient funds"
+	}
+
+	s.mu.Lock()
+	s.results[providerRef] = &Result{ProviderRef: providerRef, Status: StatusPending}
+	s.mu.Unlock()
+
+	time.AfterFunc(s.delay, func() {
+		s.mu.Lock()
+		s.results[providerRef] = final
+		s.mu.Unlock()
+		s.notify(simulatorEvent{Reference: req.Reference, ProviderRef: providerRef, Status: final.Status, Message: final.Message})
+	})
+
+	return &Result{ProviderRef: providerRef, Status: StatusPending, Message: "Awaiting confirmation"}, nil
+}
+
+// notify posts a signed callback to the webhook
+func (s *Simulator) notify(event simulatorEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("[Payments] Simulator failed to build webhook for %s: %v", event.Reference, err)
+		return
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(s.secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("[Payments] Simulator webhook for %s failed: %v", event.Reference, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("[Payments] Simulator webhook for %s returned status %d", event.Reference, resp.StatusCode)
+	}
+}
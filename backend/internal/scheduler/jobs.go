@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/services"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// jobBatchSize bounds how many tasks one run acts on; the rest wait for the next run.
+const jobBatchSize = 500
+
+// marketplaceJobs holds the dependencies of the task and offer housekeeping jobs.
+type marketplaceJobs struct {
+	db        *gorm.DB
+	fcm       *services.FCMService
+	lifecycle *services.TaskLifecycle
+	matcher   *services.SavedSearchMatcher
+	cfg       config.SchedulerConfig
+}
+
+// RegisterMarketplaceJobs registers the jobs that expire, remind and clean up
+// tasks and offers, that check equipment certificates, and that send saved-search
+// digests.
+func RegisterMarketplaceJobs(s *Scheduler, db *gorm.DB, fcm *services.FCMService, lifecycle *services.TaskLifecycle, matcher *services.SavedSearchMatcher, cfg config.SchedulerConfig) {
+	j := &marketplaceJobs{db: db, fcm: fcm, lifecycle: lifecycle, matcher: matcher, cfg: cfg}
+
+	s.Register(Job{Name: "expire_tasks", Schedule: Every(15 * time.Minute), Run: j.expireTasks})
+	s.Register(Job{Name: "expire_offers", Schedule: Every(time.Hour), Run: j.expireOffers})
+	s.Register(Job{Name: "remind_assignees", Schedule: Every(15 * time.Minute), Run: j.remindAssignees})
+	s.Register(Job{Name: "close_stale_assigned", Schedule: Every(time.Hour), Run: j.closeStaleAssigned})
+	s.Register(Job{Name: "equipment_compliance", Schedule: Every(time.Hour), Run: j.checkEquipmentCompliance})
+	s.Register(Job{Name: "saved_search_digests", Schedule: Every(time.Hour), Run: func(ctx context.Context, now time.Time) (int, error) {
+		return matcher.SendDigests(now)
+	}})
+}
+
+// expireTasks moves open tasks whose date is before today to expired.
+func (j *marketplaceJobs) expireTasks(ctx context.Context, now time.Time) (int, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	var taskIDs []uuid.UUID
+	if err := j.db.WithContext(ctx).Model(&models.Task{}).
+		Where("status = ? AND date IS NOT NULL AND date < ?", models.TaskStatusOpen, today).
+		Order("date asc").Limit(jobBatchSize).
+		Pluck("id", &taskIDs).Error; err != nil {
+		return 0, err
+	}
+
+	return j.transitionAll(ctx, taskIDs, models.TaskStatusExpired, "Task date has passed")
+}
+
+// closeStaleAssigned cancels tasks left in assigned well past their date, or long
+// after assignment when undated, refunding any held escrow.
+func (j *marketplaceJobs) closeStaleAssigned(ctx context.Context, now time.Time) (int, error) {
+	cutoff := now.Add(-j.cfg.AssignedStaleAfter)
+
+	var taskIDs []uuid.UUID
+	if err := j.db.WithContext(ctx).Model(&models.Task{}).
+		Where("status = ?", models.TaskStatusAssigned).
+		Where(`COALESCE(tasks.date, (
+			SELECT MAX(h.created_at) FROM task_status_histories h
+			WHERE h.task_id = tasks.id AND h.to_status = ?
+		), tasks.updated_at) < ?`, models.TaskStatusAssigned, cutoff).
+		Limit(jobBatchSize).
+		Pluck("id", &taskIDs).Error; err != nil {
+		return 0, err
+	}
+
+	return j.transitionAll(ctx, taskIDs, models.TaskStatusCancelled, "Automatically cancelled: the task was never started")
+}
+
+// transitionAll applies a system transition to each task, skipping tasks whose
+// status changed since they were selected.
+func (j *marketplaceJobs) transitionAll(ctx context.Context, taskIDs []uuid.UUID, to, reason string) (int, error) {
+	done := 0
+	for _, taskID := range taskIDs {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+
+		if _, err := j.lifecycle.Transition(taskID, to, nil, reason); err != nil {
+			var transitionErr *services.TransitionError
+			if errors.As(err, &transitionErr) || errors.Is(err, services.ErrTaskNotFound) {
+				continue
+			}
+			return done, fmt.Errorf("task %s: %w", taskID, err)
+		}
+		done++
+	}
+	return done, nil
+}
+
+// expireOffers lapses pending offers older than the offer TTL, and those on tasks
+// that can no longer accept them.
+func (j *marketplaceJobs) expireOffers(ctx context.Context, now time.Time) (int, error) {
+	result := j.db.WithContext(ctx).Model(&models.Offer{}).
+		Where("status = ?", models.OfferStatusPending).
+		Where(`created_at < ? OR NOT EXISTS (
+			SELECT 1 FROM tasks WHERE tasks.id = offers.task_id AND tasks.deleted_at IS NULL AND tasks.status NOT IN ?
+		)`, now.Add(-j.cfg.OfferTTL), []string{models.TaskStatusCompleted, models.TaskStatusExpired}).
+		Update("status", models.OfferStatusExpired)
+	return int(result.RowsAffected), result.Error
+}
+
+// remindAssignees notifies the assigned tasker once when a task's date is near.
+func (j *marketplaceJobs) remindAssignees(ctx context.Context, now time.Time) (int, error) {
+	type dueTask struct {
+		ID       uuid.UUID
+		Title    string
+		Date     time.Time
+		TaskerID uuid.UUID
+	}
+
+	var due []dueTask
+	if err := j.db.WithContext(ctx).Model(&models.Task{}).
+		Select("tasks.id, tasks.title, tasks.date, offers.tasker_id").
+		Joins("JOIN offers ON offers.id = tasks.accepted_offer_id").
+		Where("tasks.status = ? AND tasks.reminder_sent_at IS NULL", models.TaskStatusAssigned).
+		Where("tasks.date IS NOT NULL AND tasks.date BETWEEN ? AND ?", now.UTC().Truncate(24*time.Hour), now.Add(j.cfg.ReminderLead)).
+		Limit(jobBatchSize).
+		Scan(&due).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, task := range due {
+		// Claim the reminder first so a retry never sends it twice
+		result := j.db.WithContext(ctx).Model(&models.Task{}).
+			Where("id = ? AND reminder_sent_at IS NULL", task.ID).
+			Update("reminder_sent_at", now)
+		if result.Error != nil {
+			return sent, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		data := map[string]interface{}{
+			"task_id": task.ID.String(),
+			"url":     "/tasks/" + task.ID.String(),
+		}
+		message := fmt.Sprintf("'%s' is scheduled for %s.", task.Title, task.Date.Format("Mon 2 Jan"))
+		if _, err := services.Notify(j.db, j.fcm, task.TaskerID, "task_reminder", "Upcoming Task", message, data); err != nil {
+			log.Printf("[Scheduler] Failed to remind tasker %s about task %s: %v", task.TaskerID, task.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// checkEquipmentCompliance refreshes items whose current certificates have started
+// to expire or have lapsed, taking items with a lapsed inspection or insurance
+// certificate off the market and notifying their owners.
+func (j *marketplaceJobs) checkEquipmentCompliance(ctx context.Context, now time.Time) (int, error) {
+	var itemIDs []uuid.UUID
+	if err := j.db.WithContext(ctx).Model(&models.InventoryItem{}).
+		Where(`EXISTS (
+			SELECT 1 FROM equipment_certificates c
+			WHERE c.inventory_id = inventory_items.id AND c.expires_at < ?
+			AND NOT EXISTS (
+				SELECT 1 FROM equipment_certificates r
+				WHERE r.inventory_id = c.inventory_id AND r.kind = c.kind AND r.expires_at > c.expires_at
+			)
+			AND (
+				(c.expires_at > ? AND inventory_items.compliance_status = ?)
+				OR (c.expires_at <= ? AND (inventory_items.compliance_status <> ?
+					OR (c.kind IN ? AND inventory_items.is_available)))
+			)
+		)`, now.Add(services.CertificateExpiryWarning),
+			now, models.ComplianceValid,
+			now, models.ComplianceExpired, []string{models.CertificateInspection, models.CertificateInsurance}).
+		Limit(jobBatchSize).
+		Pluck("id", &itemIDs).Error; err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, itemID := range itemIDs {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+
+		var change *services.ComplianceChange
+		err := j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			change, err = services.RefreshCompliance(tx, itemID, now)
+			return err
+		})
+		if errors.Is(err, services.ErrInventoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return done, fmt.Errorf("inventory item %s: %w", itemID, err)
+		}
+		services.NotifyComplianceChange(j.db, j.fcm, change)
+		done++
+	}
+	return done, nil
+}
+
+// RegisterPaymentJobs registers the job that sends queued payouts and refunds to
+// the payment providers and polls payments whose webhook has not arrived.
+func RegisterPaymentJobs(s *Scheduler, payments *services.PaymentService) {
+	s.Register(Job{Name: "process_payments", Schedule: Every(time.Minute), Run: func(ctx context.Context, now time.Time) (int, error) {
+		return payments.ProcessQueued(ctx, now, jobBatchSize)
+	}})
+}
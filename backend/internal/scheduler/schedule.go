@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule decides when a job runs next.
+type Schedule interface {
+	// Next returns the first run time strictly after t.
+	Next(t time.Time) time.Time
+	String() string
+}
+
+type every struct {
+	interval time.Duration
+}
+
+// Every runs a job at a fixed interval, aligned to multiples of the interval so
+// that all instances agree on run times.
+func Every(interval time.Duration) Schedule {
+	return every{interval: interval}
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Truncate(e.interval).Add(e.interval)
+}
+
+func (e every) String() string {
+	return "every " + e.interval.String()
+}
+
+type dailyAt struct {
+	hour, minute int
+}
+
+// DailyAt runs a job once a day at the given UTC time.
+func DailyAt(hour, minute int) Schedule {
+	return dailyAt{hour: hour, minute: minute}
+}
+
+func (d dailyAt) Next(t time.Time) time.Time {
+	t = t.UTC()
+	next := time.Date(t.Year(), t.Month(), t.Day(), d.hour, d.minute, 0, 0, time.UTC)
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (d dailyAt) String() string {
+	return fmt.Sprintf("daily at %02d:%02d UTC", d.hour, d.minute)
+}
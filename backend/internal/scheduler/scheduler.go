@@ -0,0 +1,231 @@
+// Package scheduler runs periodic background jobs. Every instance of the server
+// runs the scheduler, and a Postgres advisory lock per job elects which instance
+// executes each run, so jobs are safe to run with multiple replicas.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Job is a unit of periodic work. Run returns how many rows it acted on.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Timeout  time.Duration
+	Run      func(ctx context.Context, now time.Time) (int, error)
+}
+
+// JobStats are in-memory metrics for a job on this instance.
+type JobStats struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Running      bool       `json:"running"`
+	Runs         int64      `json:"runs"`
+	Failures     int64      `json:"failures"`
+	Skipped      int64      `json:"skipped"` // another instance held the lock
+	Processed    int64      `json:"processed"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	NextRunAt    time.Time  `json:"next_run_at"`
+}
+
+const defaultJobTimeout = 5 * time.Minute
+
+var errLockHeld = errors.New("job lock held by another instance")
+
+type Scheduler struct {
+	db       *gorm.DB
+	instance string
+
+	mu    sync.Mutex
+	jobs  []Job
+	stats map[string]*JobStats
+}
+
+func New(db *gorm.DB) *Scheduler {
+	instance, _ := os.Hostname()
+	return &Scheduler{
+		db:       db,
+		instance: instance,
+		stats:    map[string]*JobStats{},
+	}
+}
+
+// Register adds a job. It must be called before Start.
+func (s *Scheduler) Register(job Job) {
+	if job.Timeout == 0 {
+		job.Timeout = defaultJobTimeout
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	s.stats[job.Name] = &JobStats{Name: job.Name, Schedule: job.Schedule.String()}
+}
+
+// Start runs every registered job on its schedule until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		go s.loop(ctx, job)
+	}
+	log.Printf("[Scheduler] Started %d jobs on %s", len(jobs), s.instance)
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	for {
+		next := job.Schedule.Next(time.Now())
+		s.update(job.Name, func(st *JobStats) { st.NextRunAt = next })
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.RunNow(ctx, job.Name)
+	}
+}
+
+// RunNow executes a job immediately if no other instance is running it.
+// It reports false if the job is unknown or the lock was not acquired.
+func (s *Scheduler) RunNow(ctx context.Context, name string) bool {
+	job, ok := s.job(name)
+	if !ok {
+		return false
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	var processed int
+	err := s.withLock(runCtx, job.Name, func() error {
+		s.update(job.Name, func(st *JobStats) { st.Running = true })
+		defer s.update(job.Name, func(st *JobStats) { st.Running = false })
+
+		var runErr error
+		processed, runErr = job.Run(runCtx, started)
+		return runErr
+	})
+	if errors.Is(err, errLockHeld) {
+		s.update(job.Name, func(st *JobStats) { st.Skipped++ })
+		return false
+	}
+
+	s.record(job.Name, started, processed, err)
+	return true
+}
+
+// withLock runs fn while holding a session-level advisory lock for the job on a
+// dedicated connection. It returns errLockHeld if another session holds it.
+func (s *Scheduler) withLock(ctx context.Context, name string, fn func() error) error {
+	key := lockKey(name)
+	return s.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return errLockHeld
+		}
+		defer func() {
+			// Use a fresh context so the lock is released even after a timeout
+			if err := conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", key).Error; err != nil {
+				log.Printf("[Scheduler] Failed to release lock for %s: %v", name, err)
+			}
+		}()
+		return fn()
+	})
+}
+
+func (s *Scheduler) record(name string, started time.Time, processed int, err error) {
+	finished := time.Now()
+	duration := finished.Sub(started)
+
+	run := models.JobRun{
+		JobName:    name,
+		Instance:   s.instance,
+		Status:     models.JobRunSucceeded,
+		Processed:  processed,
+		StartedAt:  started,
+		FinishedAt: finished,
+		DurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Printf("[Scheduler] Job %s failed after %s: %v", name, duration, err)
+	} else if processed > 0 {
+		log.Printf("[Scheduler] Job %s processed %d in %s", name, processed, duration)
+	}
+	if dbErr := s.db.Create(&run).Error; dbErr != nil {
+		log.Printf("[Scheduler] Failed to record run of %s: %v", name, dbErr)
+	}
+
+	s.update(name, func(st *JobStats) {
+		st.Runs++
+		st.Processed += int64(processed)
+		st.LastRunAt = &started
+		st.LastDuration = duration.String()
+		st.LastError = ""
+		if err != nil {
+			st.Failures++
+			st.LastError = err.Error()
+		}
+	})
+}
+
+// Stats returns a snapshot of per-job metrics, sorted by name.
+func (s *Scheduler) Stats() []JobStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]JobStats, 0, len(s.stats))
+	for _, st := range s.stats {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+func (s *Scheduler) job(name string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+func (s *Scheduler) update(name string, fn func(*JobStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.stats[name]; ok {
+		fn(st)
+	}
+}
+
+// lockKey maps a job name onto the bigint key space of Postgres advisory locks
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
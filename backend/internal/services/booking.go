@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrEquipmentBooked is returned when accepting an offer whose item is booked for part of the hire.
+	ErrEquipmentBooked = errors.New("equipment is already booked for part of this hire period")
+	// ErrBlockoutOverlapsHire is returned when a blockout would cover an existing hire booking.
+	ErrBlockoutOverlapsHire = errors.New("blockout overlaps an existing hire booking")
+	// ErrInvalidBookingWindow is returned for a window that does not end after it starts.
+	ErrInvalidBookingWindow = errors.New("booking must end after it starts")
+	// ErrBookingNotFound is returned when the blockout does not exist on the item.
+	ErrBookingNotFound = errors.New("booking not found")
+)
+
+// TaskHireWindow returns the calendar period a hire occupies: from the task's date
+// (or now when unset) for its hire duration. Unlike quotes, which bill working
+// hours, a booked day, week or month keeps the machine for the whole period.
+func TaskHireWindow(task *models.Task, now time.Time) (time.Time, time.Time) {
+	start := now
+	if task.Date != nil {
+		start = *task.Date
+	}
+
+	unit, duration := hireDuration(task)
+	count := int(duration.IntPart())
+	switch unit {
+	case "hourly":
+		return start, start.Add(time.Duration(count) * time.Hour)
+	case "weekly":
+		return start, start.AddDate(0, 0, 7*count)
+	case "monthly":
+		return start, start.AddDate(0, count, 0)
+	default:
+		return start, start.AddDate(0, 0, count)
+	}
+}
+
+// OverlappingBookings returns the item's active bookings that overlap [start, end),
+// ignoring the hire booking of excludeTaskID when set
+func OverlappingBookings(db *gorm.DB, inventoryID uuid.UUID, start, end time.Time, excludeTaskID *uuid.UUID) ([]models.EquipmentBooking, error) {
+	query := db.Where("inventory_id = ? AND status = ? AND starts_at < ? AND ends_at > ?",
+		inventoryID, models.BookingStatusActive, end, start)
+	if excludeTaskID != nil {
+		query = query.Where("task_id IS NULL OR task_id <> ?", *excludeTaskID)
+	}
+
+	var bookings []models.EquipmentBooking
+	if err := query.Order("starts_at asc").Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// BookEquipmentTx books the accepted offer's inventory item for the task's hire
+// window, failing with ErrEquipmentBooked if any active booking overlaps it. Offers
+// without an item are ignored and return a nil booking.
+func BookEquipmentTx(tx *gorm.DB, task *models.Task, offer *models.Offer) (*models.EquipmentBooking, error) {
+	if offer.InventoryID == nil {
+		return nil, nil
+	}
+	// The item lock serialises bookings so two hires cannot both pass the overlap check
+	if _, err := lockInventoryItem(tx, *offer.InventoryID); err != nil {
+		return nil, err
+	}
+
+	start, end := TaskHireWindow(task, time.Now())
+	overlapping, err := OverlappingBookings(tx, *offer.InventoryID, start, end, &task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlapping) > 0 {
+		return nil, ErrEquipmentBooked
+	}
+
+	booking := models.EquipmentBooking{
+		InventoryID: *offer.InventoryID,
+		Kind:        models.BookingKindHire,
+		Status:      models.BookingStatusActive,
+		StartsAt:    start,
+		EndsAt:      end,
+		TaskID:      &task.ID,
+		OfferID:     &offer.ID,
+		CreatedByID: task.PosterID,
+	}
+	if err := tx.Create(&booking).Error; err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// ReleaseTaskBookingsTx frees every active hire booking held by the task
+func ReleaseTaskBookingsTx(tx *gorm.DB, taskID uuid.UUID) error {
+	return tx.Model(&models.EquipmentBooking{}).
+		Where("task_id = ? AND status = ?", taskID, models.BookingStatusActive).
+		Updates(map[string]interface{}{
+			"status":      models.BookingStatusReleased,
+			"released_at": time.Now(),
+		}).Error
+}
+
+// CreateBlockout sets aside [start, end) on the owner's item. It may overlap other
+// blockouts but not an active hire booking.
+func CreateBlockout(db *gorm.DB, inventoryID, ownerID uuid.UUID, start, end time.Time, reason string) (*models.EquipmentBooking, error) {
+	if !end.After(start) {
+		return nil, ErrInvalidBookingWindow
+	}
+
+	var blockout models.EquipmentBooking
+	err := db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockInventoryItem(tx, inventoryID)
+		if err != nil {
+			return err
+		}
+		if item.UserID != ownerID {
+			return ErrInventoryNotOwned
+		}
+
+		overlapping, err := OverlappingBookings(tx, inventoryID, start, end, nil)
+		if err != nil {
+			return err
+		}
+		for _, booking := range overlapping {
+			if booking.Kind == models.BookingKindHire {
+				return ErrBlockoutOverlapsHire
+			}
+		}
+
+		blockout = models.EquipmentBooking{
+			InventoryID: inventoryID,
+			Kind:        models.BookingKindBlockout,
+			Status:      models.BookingStatusActive,
+			StartsAt:    start,
+			EndsAt:      end,
+			Reason:      reason,
+			CreatedByID: ownerID,
+		}
+		return tx.Create(&blockout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &blockout, nil
+}
+
+// ReleaseBlockout lifts one of the owner's blockouts
+func ReleaseBlockout(db *gorm.DB, inventoryID, bookingID, ownerID uuid.UUID) error {
+	var item models.InventoryItem
+	if err := db.Select("id", "user_id").First(&item, "id = ?", inventoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInventoryNotFound
+		}
+		return err
+	}
+	if item.UserID != ownerID {
+		return ErrInventoryNotOwned
+	}
+
+	result := db.Model(&models.EquipmentBooking{}).
+		Where("id = ? AND inventory_id = ? AND kind = ? AND status = ?",
+			bookingID, inventoryID, models.BookingKindBlockout, models.BookingStatusActive).
+		Updates(map[string]interface{}{
+			"status":      models.BookingStatusReleased,
+			"released_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookingNotFound
+	}
+	return nil
+}
+
+func lockInventoryItem(tx *gorm.DB, inventoryID uuid.UUID) (*models.InventoryItem, error) {
+	var item models.InventoryItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "user_id").
+		First(&item, "id = ?", inventoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventoryNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
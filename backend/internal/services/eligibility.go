@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reasons an inventory item cannot be offered on an equipment task
+const (
+	IneligibleCategory        = "category_mismatch"
+	IneligibleUnavailable     = "unavailable"
+	IneligibleCapacityMissing = "capacity_missing"
+	IneligibleCapacity        = "capacity_mismatch"
+	IneligibleCommitted       = "committed"
+	IneligibleBlockedOut      = "blocked_out"
+)
+
+// IneligibleReason explains why an item does not qualify for a task
+type IneligibleReason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// InventoryEligibility is one of a tasker's items checked against a task
+type InventoryEligibility struct {
+	Item     models.InventoryItem `json:"item"`
+	Eligible bool                 `json:"eligible"`
+	Reasons  []IneligibleReason   `json:"reasons,omitempty"`
+}
+
+// IneligibleError is returned when an offer names an item that does not qualify for the task
+type IneligibleError struct {
+	Reasons []IneligibleReason
+}
+
+func (e *IneligibleError) Error() string {
+	messages := make([]string, len(e.Reasons))
+	for i, reason := range e.Reasons {
+		messages[i] = reason.Message
+	}
+	return "inventory item is not eligible for this task: " + strings.Join(messages, "; ")
+}
+
+// EligibilityService decides which inventory items a tasker can offer on an equipment task
+type EligibilityService struct {
+	db *gorm.DB
+}
+
+func NewEligibilityService(db *gorm.DB) *EligibilityService {
+	return &EligibilityService{db: db}
+}
+
+// ForTask checks every item the tasker owns against the task, eligible items first
+func (s *EligibilityService) ForTask(task *models.Task, taskerID uuid.UUID) ([]InventoryEligibility, error) {
+	var items []models.InventoryItem
+	if err := s.db.Preload("EquipmentCapacity").Where("user_id = ?", taskerID).
+		Order("created_at desc").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	required, bookings, err := s.taskContext(task, taskerID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]InventoryEligibility, 0, len(items))
+	var ineligible []InventoryEligibility
+	for _, item := range items {
+		result := evaluateItem(task, required, bookings[item.ID], item)
+		if result.Eligible {
+			results = append(results, result)
+		} else {
+			ineligible = append(ineligible, result)
+		}
+	}
+	return append(results, ineligible...), nil
+}
+
+// Check returns an *IneligibleError when item does not qualify for the task
+func (s *EligibilityService) Check(task *models.Task, item *models.InventoryItem) error {
+	if item.CapacityID != nil && item.EquipmentCapacity == nil {
+		var capacity models.EquipmentCapacity
+		if err := s.db.First(&capacity, "id = ?", *item.CapacityID).Error; err == nil {
+			item.EquipmentCapacity = &capacity
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	required, bookings, err := s.taskContext(task, item.UserID)
+	if err != nil {
+		return err
+	}
+
+	if result := evaluateItem(task, required, bookings[item.ID], *item); !result.Eligible {
+		return &IneligibleError{Reasons: result.Reasons}
+	}
+	return nil
+}
+
+// taskContext loads the task's required capacity tier and, per item, the tasker's
+// active bookings that overlap the task's hire window
+func (s *EligibilityService) taskContext(task *models.Task, taskerID uuid.UUID) (*models.EquipmentCapacity, map[uuid.UUID][]models.EquipmentBooking, error) {
+	var required *models.EquipmentCapacity
+	if task.RequiredCapacityID != nil {
+		var capacity models.EquipmentCapacity
+		if err := s.db.First(&capacity, "id = ?", *task.RequiredCapacityID).Error; err == nil {
+			required = &capacity
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+
+	start, end := TaskHireWindow(task, time.Now())
+	var overlapping []models.EquipmentBooking
+	if err := s.db.
+		Where("inventory_id IN (?)", s.db.Model(&models.InventoryItem{}).Select("id").Where("user_id = ?", taskerID)).
+		Where("status = ? AND starts_at < ? AND ends_at > ?", models.BookingStatusActive, end, start).
+		Where("task_id IS NULL OR task_id <> ?", task.ID).
+		Order("starts_at asc").
+		Find(&overlapping).Error; err != nil {
+		return nil, nil, err
+	}
+	bookings := make(map[uuid.UUID][]models.EquipmentBooking)
+	for _, booking := range overlapping {
+		bookings[booking.InventoryID] = append(bookings[booking.InventoryID], booking)
+	}
+	return required, bookings, nil
+}
+
+func evaluateItem(task *models.Task, required *models.EquipmentCapacity, bookings []models.EquipmentBooking, item models.InventoryItem) InventoryEligibility {
+	result := InventoryEligibility{Item: item}
+	reject := func(code, message string) {
+		result.Reasons = append(result.Reasons, IneligibleReason{Code: code, Message: message})
+	}
+
+	if !strings.EqualFold(item.Category, task.Category) {
+		reject(IneligibleCategory, fmt.Sprintf("Item is listed as %s but the task needs %s", item.Category, task.Category))
+	}
+	if !item.IsAvailable {
+		reject(IneligibleUnavailable, "Item is marked as unavailable")
+	}
+	if task.RequiredCapacityID != nil {
+		switch {
+		case item.CapacityID == nil:
+			reject(IneligibleCapacityMissing, "Item has no capacity set; the task requires a specific capacity")
+		case !capacityFits(task.RequiredCapacityID, required, item.CapacityID, item.EquipmentCapacity):
+			reject(IneligibleCapacity, capacityMismatchMessage(required, item.EquipmentCapacity))
+		}
+	}
+	for _, booking := range bookings {
+		window := fmt.Sprintf("%s to %s", booking.StartsAt.Format(time.RFC3339), booking.EndsAt.Format(time.RFC3339))
+		if booking.Kind == models.BookingKindBlockout {
+			reject(IneligibleBlockedOut, "Item is blocked out from "+window)
+		} else {
+			reject(IneligibleCommitted, "Item is booked for another hire from "+window)
+		}
+	}
+
+	result.Eligible = len(result.Reasons) == 0
+	return result
+}
+
+// capacityFits reports whether an item's capacity tier satisfies the task's. The
+// same tier always fits; another tier of the same equipment type fits when its
+// weight range lies within the required one.
+func capacityFits(requiredID *uuid.UUID, required *models.EquipmentCapacity, itemCapacityID *uuid.UUID, itemCapacity *models.EquipmentCapacity) bool {
+	if *itemCapacityID == *requiredID {
+		return true
+	}
+	if required == nil || itemCapacity == nil || itemCapacity.EquipmentType != required.EquipmentType {
+		return false
+	}
+	if required.MinWeightTons == nil && required.MaxWeightTons == nil {
+		return false
+	}
+	if required.MinWeightTons != nil && (itemCapacity.MinWeightTons == nil || itemCapacity.MinWeightTons.LessThan(*required.MinWeightTons)) {
+		return false
+	}
+	if required.MaxWeightTons != nil && (itemCapacity.MaxWeightTons == nil || itemCapacity.MaxWeightTons.GreaterThan(*required.MaxWeightTons)) {
+		return false
+	}
+	return true
+}
+
+func capacityMismatchMessage(required, itemCapacity *models.EquipmentCapacity) string {
+	if required == nil || itemCapacity == nil {
+		return "Item capacity does not match the task's required capacity"
+	}
+	return fmt.Sprintf("Item capacity %s does not match the required %s", itemCapacity.DisplayName, required.DisplayName)
+}
@@ -0,0 +1,671 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultEquipmentCapacities seeds an empty catalogue, in the bulk import format
+//
+//go:embed seed/equipment_capacities.csv
+var defaultEquipmentCapacities []byte
+
+var (
+	// ErrEquipmentTypeNotFound is returned for an unknown equipment type.
+	ErrEquipmentTypeNotFound = errors.New("equipment type not found")
+	// ErrEquipmentTypeExists is returned when a type name is already taken.
+	ErrEquipmentTypeExists = errors.New("an equipment type with this name already exists")
+	// ErrEquipmentTypeRetired is returned when adding or restoring tiers of a retired type.
+	ErrEquipmentTypeRetired = errors.New("this equipment type has been retired")
+	// ErrCapacityNotFound is returned for an unknown capacity tier.
+	ErrCapacityNotFound = errors.New("capacity tier not found")
+	// ErrCapacityExists is returned when the type already has a tier with the code.
+	ErrCapacityExists = errors.New("this equipment type already has a tier with this code")
+	// ErrCapacityRetired is returned when a new task or item picks a retired tier.
+	ErrCapacityRetired = errors.New("this capacity tier has been retired")
+	// ErrInvalidCapacityCSV is returned for an import that is not readable CSV.
+	ErrInvalidCapacityCSV = errors.New("invalid capacity CSV")
+)
+
+// capacityColumns are the columns of the CSV import and export, in export order
+var capacityColumns = []string{"equipment_type", "capacity_code", "display_name", "min_weight_tons", "max_weight_tons", "sort_order", "retired"}
+
+// CapacityRow is one capacity tier in a bulk import or export
+type CapacityRow struct {
+	EquipmentType string           `json:"equipment_type"`
+	CapacityCode  string           `json:"capacity_code"`
+	DisplayName   string           `json:"display_name"`
+	MinWeightTons *decimal.Decimal `json:"min_weight_tons,omitempty"`
+	MaxWeightTons *decimal.Decimal `json:"max_weight_tons,omitempty"`
+	SortOrder     int              `json:"sort_order"`
+	Retired       bool             `json:"retired"`
+}
+
+// CatalogueEntryError is returned for an equipment type or tier that fails validation
+type CatalogueEntryError struct {
+	Reason string
+}
+
+func (e *CatalogueEntryError) Error() string {
+	return e.Reason
+}
+
+// RowError is a problem with one row of a bulk import. Rows are numbered from 1,
+// not counting the CSV header.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportError lists every invalid row of a rejected import
+type ImportError struct {
+	Rows []RowError
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%d invalid rows", len(e.Rows))
+}
+
+// ImportResult counts what a bulk import changed
+type ImportResult struct {
+	TypesCreated int `json:"types_created"`
+	Created      int `json:"created"`
+	Updated      int `json:"updated"`
+	Unchanged    int `json:"unchanged"`
+}
+
+// CapacityPatch holds the tier fields an admin update changes; nil fields are kept
+type CapacityPatch struct {
+	CapacityCode  *string
+	DisplayName   *string
+	MinWeightTons *decimal.Decimal
+	MaxWeightTons *decimal.Decimal
+	SortOrder     *int
+}
+
+// ParseCapacityCSV reads capacity tiers in the export format. The header names
+// the columns, in any order; equipment_type, capacity_code and display_name are
+// required. Every invalid row is reported in an *ImportError.
+func ParseCapacityCSV(r io.Reader) ([]CapacityRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCapacityCSV, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range capacityColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: header is missing %s", ErrInvalidCapacityCSV, name)
+		}
+	}
+
+	var rows []CapacityRow
+	var invalid []RowError
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidCapacityCSV, n, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := CapacityRow{
+			EquipmentType: field("equipment_type"),
+			CapacityCode:  field("capacity_code"),
+			DisplayName:   field("display_name"),
+		}
+		var problems []string
+		if row.MinWeightTons, err = parseOptionalDecimal(field("min_weight_tons")); err != nil {
+			problems = append(problems, "invalid min_weight_tons")
+		}
+		if row.MaxWeightTons, err = parseOptionalDecimal(field("max_weight_tons")); err != nil {
+			problems = append(problems, "invalid max_weight_tons")
+		}
+		if raw := field("sort_order"); raw != "" {
+			if row.SortOrder, err = strconv.Atoi(raw); err != nil {
+				problems = append(problems, "invalid sort_order")
+			}
+		}
+		if raw := field("retired"); raw != "" {
+			if row.Retired, err = strconv.ParseBool(raw); err != nil {
+				problems = append(problems, "retired must be true or false")
+			}
+		}
+		if len(problems) > 0 {
+			invalid = append(invalid, RowError{Row: n, Error: strings.Join(problems, "; ")})
+		}
+		rows = append(rows, row)
+	}
+	if len(invalid) > 0 {
+		return nil, &ImportError{Rows: invalid}
+	}
+	return rows, nil
+}
+
+// WriteCapacityCSV writes tiers in the format ParseCapacityCSV reads
+func WriteCapacityCSV(w io.Writer, rows []CapacityRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(capacityColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write([]string{
+			row.EquipmentType,
+			row.CapacityCode,
+			row.DisplayName,
+			formatOptionalDecimal(row.MinWeightTons),
+			formatOptionalDecimal(row.MaxWeightTons),
+			strconv.Itoa(row.SortOrder),
+			strconv.FormatBool(row.Retired),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportCapacities upserts tiers by equipment type and capacity code in one
+// transaction, creating missing types after the existing ones. A row's retired
+// flag retires or restores its tier. Nothing is written if any row is invalid.
+func ImportCapacities(db *gorm.DB, rows []CapacityRow) (*ImportResult, error) {
+	var invalid []RowError
+	seen := make(map[string]int, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		if err := normalizeCapacityRow(row); err != nil {
+			invalid = append(invalid, RowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		key := row.EquipmentType + "\x00" + row.CapacityCode
+		if first, ok := seen[key]; ok {
+			invalid = append(invalid, RowError{Row: i + 1, Error: fmt.Sprintf("duplicates row %d", first)})
+			continue
+		}
+		seen[key] = i + 1
+	}
+	if len(invalid) > 0 {
+		return nil, &ImportError{Rows: invalid}
+	}
+
+	result := &ImportResult{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Serialise imports against each other and against admin edits
+		var types []models.EquipmentType
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("sort_order").Find(&types).Error; err != nil {
+			return err
+		}
+		typesByName := make(map[string]*models.EquipmentType, len(types))
+		nextSortOrder := 1
+		for i := range types {
+			typesByName[types[i].Name] = &types[i]
+			if types[i].SortOrder >= nextSortOrder {
+				nextSortOrder = types[i].SortOrder + 1
+			}
+		}
+
+		now := time.Now()
+		for _, row := range rows {
+			if _, ok := typesByName[row.EquipmentType]; !ok {
+				equipmentType := models.EquipmentType{Name: row.EquipmentType, SortOrder: nextSortOrder}
+				if err := tx.Create(&equipmentType).Error; err != nil {
+					return err
+				}
+				typesByName[row.EquipmentType] = &equipmentType
+				nextSortOrder++
+				result.TypesCreated++
+			}
+
+			var retiredAt *time.Time
+			if row.Retired {
+				retiredAt = &now
+			}
+
+			var capacity models.EquipmentCapacity
+			err := tx.Where("equipment_type = ? AND capacity_code = ?", row.EquipmentType, row.CapacityCode).First(&capacity).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				capacity = models.EquipmentCapacity{
+					EquipmentType: row.EquipmentType,
+					CapacityCode:  row.CapacityCode,
+					DisplayName:   row.DisplayName,
+					MinWeightTons: row.MinWeightTons,
+					MaxWeightTons: row.MaxWeightTons,
+					SortOrder:     row.SortOrder,
+					RetiredAt:     retiredAt,
+				}
+				if err := tx.Create(&capacity).Error; err != nil {
+					return err
+				}
+				result.Created++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if row.Retired == (capacity.RetiredAt != nil) {
+				retiredAt = capacity.RetiredAt
+			}
+			if capacity.DisplayName == row.DisplayName && capacity.SortOrder == row.SortOrder &&
+				equalOptionalDecimal(capacity.MinWeightTons, row.MinWeightTons) &&
+				equalOptionalDecimal(capacity.MaxWeightTons, row.MaxWeightTons) &&
+				retiredAt == capacity.RetiredAt {
+				result.Unchanged++
+				continue
+			}
+			if err := tx.Model(&capacity).Updates(map[string]interface{}{
+				"display_name":    row.DisplayName,
+				"min_weight_tons": row.MinWeightTons,
+				"max_weight_tons": row.MaxWeightTons,
+				"sort_order":      row.SortOrder,
+				"retired_at":      retiredAt,
+			}).Error; err != nil {
+				return err
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExportCapacities returns every tier, retired ones included, ordered by type and tier
+func ExportCapacities(db *gorm.DB) ([]CapacityRow, error) {
+	var capacities []models.EquipmentCapacity
+	if err := db.Model(&models.EquipmentCapacity{}).Select("equipment_capacities.*").
+		Joins("LEFT JOIN equipment_types ON equipment_types.name = equipment_capacities.equipment_type").
+		Order("equipment_types.sort_order, equipment_capacities.equipment_type, equipment_capacities.sort_order").
+		Find(&capacities).Error; err != nil {
+		return nil, err
+	}
+
+	rows := make([]CapacityRow, len(capacities))
+	for i, capacity := range capacities {
+		rows[i] = CapacityRow{
+			EquipmentType: capacity.EquipmentType,
+			CapacityCode:  capacity.CapacityCode,
+			DisplayName:   capacity.DisplayName,
+			MinWeightTons: capacity.MinWeightTons,
+			MaxWeightTons: capacity.MaxWeightTons,
+			SortOrder:     capacity.SortOrder,
+			Retired:       capacity.RetiredAt != nil,
+		}
+	}
+	return rows, nil
+}
+
+// SeedEquipmentCatalogue creates an equipment type for each type that only exists
+// on capacity rows, and loads the default tiers when there are none at all
+func SeedEquipmentCatalogue(db *gorm.DB) (*ImportResult, error) {
+	var count int64
+	if err := db.Model(&models.EquipmentCapacity{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		rows, err := ParseCapacityCSV(bytes.NewReader(defaultEquipmentCapacities))
+		if err != nil {
+			return nil, fmt.Errorf("invalid default equipment capacities: %w", err)
+		}
+		return ImportCapacities(db, rows)
+	}
+
+	var missing []string
+	if err := db.Model(&models.EquipmentCapacity{}).
+		Where("equipment_type NOT IN (?)", db.Model(&models.EquipmentType{}).Select("name")).
+		Distinct().Order("equipment_type").Pluck("equipment_type", &missing).Error; err != nil {
+		return nil, err
+	}
+	result := &ImportResult{}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	var maxSortOrder int
+	if err := db.Model(&models.EquipmentType{}).Select("COALESCE(MAX(sort_order), 0)").Scan(&maxSortOrder).Error; err != nil {
+		return nil, err
+	}
+	for i, name := range missing {
+		equipmentType := models.EquipmentType{Name: name, SortOrder: maxSortOrder + i + 1}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&equipmentType).Error; err != nil {
+			return nil, err
+		}
+		result.TypesCreated++
+	}
+	return result, nil
+}
+
+// CreateEquipmentType adds an equipment type
+func CreateEquipmentType(db *gorm.DB, equipmentType *models.EquipmentType) error {
+	equipmentType.Name = strings.TrimSpace(equipmentType.Name)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := requireUniqueTypeName(tx, equipmentType.Name, nil); err != nil {
+			return err
+		}
+		return tx.Create(equipmentType).Error
+	})
+}
+
+// UpdateEquipmentType changes a type's name, description or sort order. Renaming
+// carries its tiers over to the new name.
+func UpdateEquipmentType(db *gorm.DB, id uuid.UUID, name, description *string, sortOrder *int) (*models.EquipmentType, error) {
+	var equipmentType models.EquipmentType
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockEquipmentType(tx, id, &equipmentType); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if name != nil && strings.TrimSpace(*name) != equipmentType.Name {
+			newName := strings.TrimSpace(*name)
+			if err := requireUniqueTypeName(tx, newName, &id); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.EquipmentCapacity{}).Where("equipment_type = ?", equipmentType.Name).
+				Update("equipment_type", newName).Error; err != nil {
+				return err
+			}
+			updates["name"] = newName
+		}
+		if description != nil {
+			updates["description"] = *description
+		}
+		if sortOrder != nil {
+			updates["sort_order"] = *sortOrder
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&equipmentType).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &equipmentType, nil
+}
+
+// SetEquipmentTypeRetired retires a type along with its active tiers, or restores
+// it along with the tiers retired with it
+func SetEquipmentTypeRetired(db *gorm.DB, id uuid.UUID, retired bool) (*models.EquipmentType, error) {
+	var equipmentType models.EquipmentType
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockEquipmentType(tx, id, &equipmentType); err != nil {
+			return err
+		}
+		if retired == (equipmentType.RetiredAt != nil) {
+			return nil
+		}
+
+		tiers := tx.Model(&models.EquipmentCapacity{}).Where("equipment_type = ?", equipmentType.Name)
+		if retired {
+			now := time.Now()
+			if err := tiers.Where("retired_at IS NULL").Update("retired_at", now).Error; err != nil {
+				return err
+			}
+			return tx.Model(&equipmentType).Update("retired_at", now).Error
+		}
+		if err := tiers.Where("retired_at = ?", *equipmentType.RetiredAt).Update("retired_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Model(&equipmentType).Update("retired_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &equipmentType, nil
+}
+
+// CreateCapacity adds a tier to an active equipment type
+func CreateCapacity(db *gorm.DB, capacity *models.EquipmentCapacity) error {
+	row := CapacityRow{
+		EquipmentType: capacity.EquipmentType,
+		CapacityCode:  capacity.CapacityCode,
+		DisplayName:   capacity.DisplayName,
+		MinWeightTons: capacity.MinWeightTons,
+		MaxWeightTons: capacity.MaxWeightTons,
+	}
+	if err := normalizeCapacityRow(&row); err != nil {
+		return err
+	}
+	capacity.EquipmentType, capacity.CapacityCode, capacity.DisplayName = row.EquipmentType, row.CapacityCode, row.DisplayName
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var equipmentType models.EquipmentType
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", capacity.EquipmentType).First(&equipmentType).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEquipmentTypeNotFound
+			}
+			return err
+		}
+		if equipmentType.RetiredAt != nil {
+			return ErrEquipmentTypeRetired
+		}
+		if err := requireUniqueCapacityCode(tx, capacity.EquipmentType, capacity.CapacityCode, nil); err != nil {
+			return err
+		}
+		return tx.Create(capacity).Error
+	})
+}
+
+// UpdateCapacity edits a tier. Its equipment type cannot change, as tasks and items
+// referencing the tier rely on it.
+func UpdateCapacity(db *gorm.DB, id uuid.UUID, patch CapacityPatch) (*models.EquipmentCapacity, error) {
+	var capacity models.EquipmentCapacity
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCapacity(tx, id, &capacity); err != nil {
+			return err
+		}
+
+		row := CapacityRow{
+			EquipmentType: capacity.EquipmentType,
+			CapacityCode:  capacity.CapacityCode,
+			DisplayName:   capacity.DisplayName,
+			MinWeightTons: capacity.MinWeightTons,
+			MaxWeightTons: capacity.MaxWeightTons,
+			SortOrder:     capacity.SortOrder,
+		}
+		if patch.CapacityCode != nil {
+			row.CapacityCode = *patch.CapacityCode
+		}
+		if patch.DisplayName != nil {
+			row.DisplayName = *patch.DisplayName
+		}
+		if patch.MinWeightTons != nil {
+			row.MinWeightTons = patch.MinWeightTons
+		}
+		if patch.MaxWeightTons != nil {
+			row.MaxWeightTons = patch.MaxWeightTons
+		}
+		if patch.SortOrder != nil {
+			row.SortOrder = *patch.SortOrder
+		}
+		if err := normalizeCapacityRow(&row); err != nil {
+			return err
+		}
+		if row.CapacityCode != capacity.CapacityCode {
+			if err := requireUniqueCapacityCode(tx, capacity.EquipmentType, row.CapacityCode, &id); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&capacity).Updates(map[string]interface{}{
+			"capacity_code":   row.CapacityCode,
+			"display_name":    row.DisplayName,
+			"min_weight_tons": row.MinWeightTons,
+			"max_weight_tons": row.MaxWeightTons,
+			"sort_order":      row.SortOrder,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &capacity, nil
+}
+
+// SetCapacityRetired retires or restores a tier. Retired tiers stay in the table,
+// so tasks and items that reference them are unaffected.
+func SetCapacityRetired(db *gorm.DB, id uuid.UUID, retired bool) (*models.EquipmentCapacity, error) {
+	var capacity models.EquipmentCapacity
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCapacity(tx, id, &capacity); err != nil {
+			return err
+		}
+		if retired == (capacity.RetiredAt != nil) {
+			return nil
+		}
+		if retired {
+			return tx.Model(&capacity).Update("retired_at", time.Now()).Error
+		}
+
+		var equipmentType models.EquipmentType
+		if err := tx.Where("name = ?", capacity.EquipmentType).First(&equipmentType).Error; err == nil && equipmentType.RetiredAt != nil {
+			return ErrEquipmentTypeRetired
+		}
+		return tx.Model(&capacity).Update("retired_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &capacity, nil
+}
+
+// RequireActiveCapacity checks that a new task or item may use the tier
+func RequireActiveCapacity(db *gorm.DB, id uuid.UUID) error {
+	var capacity models.EquipmentCapacity
+	if err := db.Select("id", "retired_at").First(&capacity, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCapacityNotFound
+		}
+		return err
+	}
+	if capacity.RetiredAt != nil {
+		return ErrCapacityRetired
+	}
+	return nil
+}
+
+func normalizeCapacityRow(row *CapacityRow) error {
+	row.EquipmentType = strings.TrimSpace(row.EquipmentType)
+	row.CapacityCode = strings.ToUpper(strings.TrimSpace(row.CapacityCode))
+	row.DisplayName = strings.TrimSpace(row.DisplayName)
+
+	switch {
+	case row.EquipmentType == "" || len(row.EquipmentType) > 50:
+		return &CatalogueEntryError{Reason: "equipment_type is required and at most 50 characters"}
+	case row.CapacityCode == "" || len(row.CapacityCode) > 30:
+		return &CatalogueEntryError{Reason: "capacity_code is required and at most 30 characters"}
+	case row.DisplayName == "" || len(row.DisplayName) > 150:
+		return &CatalogueEntryError{Reason: "display_name is required and at most 150 characters"}
+	case row.MinWeightTons != nil && row.MinWeightTons.IsNegative(),
+		row.MaxWeightTons != nil && row.MaxWeightTons.IsNegative():
+		return &CatalogueEntryError{Reason: "weights must not be negative"}
+	case row.MinWeightTons != nil && row.MaxWeightTons != nil && row.MinWeightTons.GreaterThan(*row.MaxWeightTons):
+		return &CatalogueEntryError{Reason: "min_weight_tons must not exceed max_weight_tons"}
+	}
+	return nil
+}
+
+func requireUniqueTypeName(tx *gorm.DB, name string, exceptID *uuid.UUID) error {
+	if name == "" || len(name) > 50 {
+		return &CatalogueEntryError{Reason: "name is required and at most 50 characters"}
+	}
+	query := tx.Model(&models.EquipmentType{}).Where("LOWER(name) = LOWER(?)", name)
+	if exceptID != nil {
+		query = query.Where("id <> ?", *exceptID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEquipmentTypeExists
+	}
+	return nil
+}
+
+func requireUniqueCapacityCode(tx *gorm.DB, equipmentType, code string, exceptID *uuid.UUID) error {
+	query := tx.Model(&models.EquipmentCapacity{}).Where("equipment_type = ? AND capacity_code = ?", equipmentType, code)
+	if exceptID != nil {
+		query = query.Where("id <> ?", *exceptID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCapacityExists
+	}
+	return nil
+}
+
+func lockEquipmentType(tx *gorm.DB, id uuid.UUID, equipmentType *models.EquipmentType) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(equipmentType, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEquipmentTypeNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func lockCapacity(tx *gorm.DB, id uuid.UUID, capacity *models.EquipmentCapacity) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(capacity, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCapacityNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func parseOptionalDecimal(raw string) (*decimal.Decimal, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func formatOptionalDecimal(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func equalOptionalDecimal(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrExchangeRateNotFound is returned when no rate is known for a currency pair.
+	ErrExchangeRateNotFound = errors.New("no exchange rate for that currency pair")
+	// ErrInvalidExchangeRate is returned for a rate that cannot convert amounts.
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+)
+
+// rateScale is the number of decimal places a derived inverse rate keeps
+const rateScale = 8
+
+// RecordExchangeRate stores a rate for a pair. Earlier rates are kept as history;
+// the newest one in effect is used from EffectiveAt on.
+func RecordExchangeRate(db *gorm.DB, rate *models.ExchangeRate) error {
+	invalid := func(reason string) error { return fmt.Errorf("%w: %s", ErrInvalidExchangeRate, reason) }
+	base, err := money.ParseCurrency(rate.Base)
+	if err != nil {
+		return invalid(err.Error())
+	}
+	quote, err := money.ParseCurrency(rate.Quote)
+	if err != nil {
+		return invalid(err.Error())
+	}
+	switch {
+	case base == quote:
+		return invalid("base and quote must differ")
+	case !rate.Rate.IsPositive():
+		return invalid("rate must be greater than 0")
+	}
+	rate.Base, rate.Quote = base, quote
+	if rate.EffectiveAt.IsZero() {
+		rate.EffectiveAt = time.Now()
+	}
+	return db.Create(rate).Error
+}
+
+// ExchangeRateAt returns the rate in effect at at for one unit of base in quote.
+// A pair can be recorded either way round; whichever direction was set most
+// recently wins, inverted when needed.
+func ExchangeRateAt(db *gorm.DB, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	if base == quote {
+		return &models.ExchangeRate{Base: base, Quote: quote, Rate: decimal.NewFromInt(1), EffectiveAt: at}, nil
+	}
+
+	var rate models.ExchangeRate
+	err := db.Where("((base = ? AND quote = ?) OR (base = ? AND quote = ?)) AND effective_at <= ?", base, quote, quote, base, at).
+		Order("effective_at DESC, created_at DESC").
+		First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExchangeRateNotFound
+		}
+		return nil, err
+	}
+	if rate.Base != base {
+		rate.Base, rate.Quote = base, quote
+		rate.Rate = decimal.NewFromInt(1).DivRound(rate.Rate, rateScale)
+	}
+	return &rate, nil
+}
+
+// ConvertMoney converts m into currency to at the rate in effect at at. It is
+// for showing amounts only; money always moves in the task's currency.
+func ConvertMoney(db *gorm.DB, m money.Money, to string, at time.Time) (money.Money, error) {
+	rate, err := ExchangeRateAt(db, m.Currency, to, at)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return m.Convert(rate.Rate, to), nil
+}
+
+// LatestExchangeRates returns the rate in effect now from base to each other
+// supported currency that has one
+func LatestExchangeRates(db *gorm.DB, base string) ([]models.ExchangeRate, error) {
+	now := time.Now()
+	rates := []models.ExchangeRate{}
+	for _, quote := range money.Currencies {
+		if quote == base {
+			continue
+		}
+		rate, err := ExchangeRateAt(db, base, quote, now)
+		if errors.Is(err, ErrExchangeRateNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+	return rates, nil
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFeeRuleNotFound is returned when the fee rule does not exist.
+	ErrFeeRuleNotFound = errors.New("fee rule not found")
+	// ErrInvalidFeeRule is returned for a fee rule that cannot price a fee.
+	ErrInvalidFeeRule = errors.New("invalid fee rule")
+)
+
+// FeeRulePatch holds the fee rule fields to change; nil fields are kept. The
+// Clear flags remove an optional cap or window bound.
+type FeeRulePatch struct {
+	Name          *string
+	Category      *string
+	TaskType      *string
+	Currency      *string
+	Percent       *decimal.Decimal
+	Flat          *decimal.Decimal
+	MinFee        *decimal.Decimal
+	MaxFee        *decimal.Decimal
+	Promo         *bool
+	StartsAt      *time.Time
+	EndsAt        *time.Time
+	Active        *bool
+	ClearMinFee   bool
+	ClearMaxFee   bool
+	ClearStartsAt bool
+	ClearEndsAt   bool
+}
+
+// validateFeeRule normalises a rule and checks it prices a sensible fee
+func validateFeeRule(rule *models.FeeRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Category = strings.TrimSpace(rule.Category)
+	rule.TaskType = strings.TrimSpace(rule.TaskType)
+
+	invalid := func(reason string) error { return fmt.Errorf("%w: %s", ErrInvalidFeeRule, reason) }
+	if rule.Currency = strings.TrimSpace(rule.Currency); rule.Currency != "" {
+		currency, err := money.ParseCurrency(rule.Currency)
+		if err != nil {
+			return invalid(err.Error())
+		}
+		rule.Currency = currency
+	}
+	switch {
+	case rule.Name == "":
+		return invalid("name is required")
+	case rule.Side != models.FeeSidePoster && rule.Side != models.FeeSideTasker:
+		return invalid("side must be poster or tasker")
+	case rule.TaskType != "" && rule.TaskType != "service" && rule.TaskType != "equipment":
+		return invalid("task_type must be service, equipment or empty")
+	case rule.Percent.IsNegative() || rule.Percent.GreaterThan(decimal.NewFromInt(100)):
+		return invalid("percent must be between 0 and 100")
+	case rule.Flat.IsNegative():
+		return invalid("flat must not be negative")
+	case rule.MinFee != nil && rule.MinFee.IsNegative(), rule.MaxFee != nil && rule.MaxFee.IsNegative():
+		return invalid("min_fee and max_fee must not be negative")
+	case rule.MinFee != nil && rule.MaxFee != nil && rule.MinFee.GreaterThan(*rule.MaxFee):
+		return invalid("min_fee must not exceed max_fee")
+	case rule.Currency == "" && (!rule.Flat.IsZero() || rule.MinFee != nil || rule.MaxFee != nil):
+		return invalid("currency is required for a flat fee or caps")
+	case rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt):
+		return invalid("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// CreateFeeRule adds a fee rule
+func CreateFeeRule(db *gorm.DB, rule *models.FeeRule) error {
+	if err := validateFeeRule(rule); err != nil {
+		return err
+	}
+	return db.Create(rule).Error
+}
+
+// UpdateFeeRule applies patch to a fee rule. Escrow already priced keeps the fees
+// it was opened with.
+func UpdateFeeRule(db *gorm.DB, id uuid.UUID, patch FeeRulePatch) (*models.FeeRule, error) {
+	var rule models.FeeRule
+	if err := db.First(&rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFeeRuleNotFound
+		}
+		return nil, err
+	}
+
+	if patch.Name != nil {
+		rule.Name = *patch.Name
+	}
+	if patch.Category != nil {
+		rule.Category = *patch.Category
+	}
+	if patch.TaskType != nil {
+		rule.TaskType = *patch.TaskType
+	}
+	if patch.Currency != nil {
+		rule.Currency = *patch.Currency
+	}
+	if patch.Percent != nil {
+		rule.Percent = *patch.Percent
+	}
+	if patch.Flat != nil {
+		rule.Flat = *patch.Flat
+	}
+	if patch.MinFee != nil || patch.ClearMinFee {
+		rule.MinFee = patch.MinFee
+	}
+	if patch.MaxFee != nil || patch.ClearMaxFee {
+		rule.MaxFee = patch.MaxFee
+	}
+	if patch.Promo != nil {
+		rule.Promo = *patch.Promo
+	}
+	if patch.StartsAt != nil || patch.ClearStartsAt {
+		rule.StartsAt = patch.StartsAt
+	}
+	if patch.EndsAt != nil || patch.ClearEndsAt {
+		rule.EndsAt = patch.EndsAt
+	}
+	if patch.Active != nil {
+		rule.Active = *patch.Active
+	}
+	if err := validateFeeRule(&rule); err != nil {
+		return nil, err
+	}
+
+	// Select every column so that cleared caps and false flags are written
+	if err := db.Model(&rule).Select("*").Omit("id", "side", "created_at").Updates(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteFeeRule removes a fee rule
+func DeleteFeeRule(db *gorm.DB, id uuid.UUID) error {
+	result := db.Delete(&models.FeeRule{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrFeeRuleNotFound
+	}
+	return nil
+}
+
+// activeFeeRules returns the rules in force at at
+func activeFeeRules(db *gorm.DB, at time.Time) ([]models.FeeRule, error) {
+	var rules []models.FeeRule
+	err := db.Where("active AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", at, at).
+		Find(&rules).Error
+	return rules, err
+}
+
+// feeRuleSpecificity ranks a rule naming both category and task type above one
+// naming either, and either above a catch-all; category counts for more
+func feeRuleSpecificity(rule *models.FeeRule) int {
+	specificity := 0
+	if rule.Category != "" {
+		specificity += 2
+	}
+	if rule.TaskType != "" {
+		specificity++
+	}
+	return specificity
+}
+
+// pickFeeRule returns the best rule for side on task, or nil when none matches
+func pickFeeRule(rules []models.FeeRule, side string, task *models.Task) *models.FeeRule {
+	var matching []*models.FeeRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Side != side ||
+			(rule.Category != "" && !strings.EqualFold(rule.Category, task.Category)) ||
+			(rule.TaskType != "" && rule.TaskType != task.TaskType) ||
+			(rule.Currency != "" && rule.Currency != task.Currency) {
+			continue
+		}
+		matching = append(matching, rule)
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		a, b := matching[i], matching[j]
+		if a.Promo != b.Promo {
+			return a.Promo
+		}
+		if sa, sb := feeRuleSpecificity(a), feeRuleSpecificity(b); sa != sb {
+			return sa > sb
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	return matching[0]
+}
+
+// priceFeeLine works out line's fee on amount: the percentage plus the flat fee,
+// clamped to its caps and never more than amount itself
+func priceFeeLine(line *models.FeeLine, amount decimal.Decimal) {
+	fee := amount.Mul(line.Percent).Div(decimal.NewFromInt(100)).Add(line.Flat)
+	if line.MinFee != nil && fee.LessThan(*line.MinFee) {
+		fee = *line.MinFee
+	}
+	if line.MaxFee != nil && fee.GreaterThan(*line.MaxFee) {
+		fee = *line.MaxFee
+	}
+	line.Amount = decimal.Min(fee, amount).Round(2)
+}
+
+// newFeeQuote totals lines priced on amount
+func newFeeQuote(amount decimal.Decimal, lines []models.FeeLine) *models.FeeQuote {
+	quote := &models.FeeQuote{Amount: amount, Lines: lines}
+	for i := range quote.Lines {
+		line := &quote.Lines[i]
+		priceFeeLine(line, amount)
+		if line.Side == models.FeeSidePoster {
+			quote.ServiceFee = quote.ServiceFee.Add(line.Amount)
+		} else {
+			quote.Commission = quote.Commission.Add(line.Amount)
+		}
+	}
+	quote.PosterTotal = amount.Add(quote.ServiceFee)
+	quote.TaskerPayout = amount.Sub(quote.Commission)
+	return quote
+}
+
+// quoteFeesWith prices the fees on amount for task using rules
+func quoteFeesWith(rules []models.FeeRule, task *models.Task, amount decimal.Decimal) *models.FeeQuote {
+	lines := []models.FeeLine{}
+	for _, side := range []string{models.FeeSidePoster, models.FeeSideTasker} {
+		if rule := pickFeeRule(rules, side, task); rule != nil {
+			lines = append(lines, models.FeeLine{
+				RuleID:  rule.ID,
+				Name:    rule.Name,
+				Side:    rule.Side,
+				Promo:   rule.Promo,
+				Percent: rule.Percent,
+				Flat:    rule.Flat,
+				MinFee:  rule.MinFee,
+				MaxFee:  rule.MaxFee,
+			})
+		}
+	}
+	return newFeeQuote(amount.Round(2), lines)
+}
+
+// QuoteFees prices the poster's service fee and the tasker's commission on an
+// offer of amount for task, using the rules in force at at
+func QuoteFees(db *gorm.DB, task *models.Task, amount decimal.Decimal, at time.Time) (*models.FeeQuote, error) {
+	rules, err := activeFeeRules(db, at)
+	if err != nil {
+		return nil, err
+	}
+	return quoteFeesWith(rules, task, amount), nil
+}
+
+// AttachOfferFees sets Fees on each of task's offers at current pricing
+func AttachOfferFees(db *gorm.DB, task *models.Task, offers ...*models.Offer) error {
+	if len(offers) == 0 {
+		return nil
+	}
+	rules, err := activeFeeRules(db, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, offer := range offers {
+		offer.Fees = quoteFeesWith(rules, task, offer.Amount)
+	}
+	return nil
+}
+
+// ApplyEscrowFees records quote on escrow, before it is created
+func ApplyEscrowFees(escrow *models.EscrowTransaction, quote *models.FeeQuote) {
+	escrow.ServiceFee = quote.ServiceFee
+	escrow.Commission = quote.Commission
+	escrow.Fees = quote.Lines
+}
+
+// repriceEscrowFees reprices the fees escrow was opened with on a new amount, as
+// when a metered hire is billed above or below its estimate
+func repriceEscrowFees(escrow *models.EscrowTransaction, amount decimal.Decimal) {
+	lines := make([]models.FeeLine, len(escrow.Fees))
+	copy(lines, escrow.Fees)
+	ApplyEscrowFees(escrow, newFeeQuote(amount, lines))
+}
+
+// EscrowFeeQuote is the fee breakdown recorded on escrow
+func EscrowFeeQuote(escrow *models.EscrowTransaction) *models.FeeQuote {
+	amount := escrowAmount(escrow)
+	lines := escrow.Fees
+	if lines == nil {
+		lines = []models.FeeLine{}
+	}
+	return &models.FeeQuote{
+		Amount:       amount,
+		ServiceFee:   escrow.ServiceFee,
+		Commission:   escrow.Commission,
+		PosterTotal:  amount.Add(escrow.ServiceFee),
+		TaskerPayout: amount.Sub(escrow.Commission),
+		Lines:        lines,
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func decPtr(s string) *decimal.Decimal {
+	d := dec(s)
+	return &d
+}
+
+func TestPickFeeRule(t *testing.T) {
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.AddDate(0, 1, 0)
+	rules := []models.FeeRule{
+		{Name: "poster default", Side: models.FeeSidePoster, UpdatedAt: older},
+		{Name: "poster default, newer", Side: models.FeeSidePoster, UpdatedAt: newer},
+		{Name: "poster cleaning", Side: models.FeeSidePoster, Category: "Cleaning", UpdatedAt: older},
+		{Name: "poster remote", Side: models.FeeSidePoster, TaskType: "remote", UpdatedAt: newer},
+		{Name: "poster cleaning remote", Side: models.FeeSidePoster, Category: "Cleaning", TaskType: "remote", UpdatedAt: older},
+		{Name: "poster ZWG promo", Side: models.FeeSidePoster, Currency: "ZWG", Promo: true, UpdatedAt: older},
+		{Name: "tasker default", Side: models.FeeSideTasker, UpdatedAt: older},
+		{Name: "tasker ZWG", Side: models.FeeSideTasker, Currency: "ZWG", UpdatedAt: newer},
+	}
+
+	tests := []struct {
+		name string
+		side string
+		task models.Task
+		want string
+	}{
+		{"newest catch-all", models.FeeSidePoster, models.Task{Category: "Gardening", Currency: "USD"}, "poster default, newer"},
+		{"category beats newer task type", models.FeeSidePoster, models.Task{Category: "Cleaning", TaskType: "in_person", Currency: "USD"}, "poster cleaning"},
+		{"category case-insensitive", models.FeeSidePoster, models.Task{Category: "cleaning", Currency: "USD"}, "poster cleaning"},
+		{"task type beats catch-all", models.FeeSidePoster, models.Task{Category: "Gardening", TaskType: "remote", Currency: "USD"}, "poster remote"},
+		{"both beat either", models.FeeSidePoster, models.Task{Category: "Cleaning", TaskType: "remote", Currency: "USD"}, "poster cleaning remote"},
+		{"promo beats specificity", models.FeeSidePoster, models.Task{Category: "Cleaning", TaskType: "remote", Currency: "ZWG"}, "poster ZWG promo"},
+		{"currency narrows", models.FeeSideTasker, models.Task{Currency: "ZWG"}, "tasker ZWG"},
+		{"other currency", models.FeeSideTasker, models.Task{Currency: "USD"}, "tasker default"},
+	}
+	for _, tt := range tests {
+		rule := pickFeeRule(rules, tt.side, &tt.task)
+		if rule == nil || rule.Name != tt.want {
+			t.Errorf("%s: picked %v, want %q", tt.name, rule, tt.want)
+		}
+	}
+
+	if rule := pickFeeRule(rules[:1], models.FeeSideTasker, &models.Task{}); rule != nil {
+		t.Errorf("no tasker rule: picked %q", rule.Name)
+	}
+}
+
+func TestPriceFeeLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   models.FeeLine
+		amount string
+		want   string
+	}{
+		{"percent", models.FeeLine{Percent: dec("10")}, "100", "10"},
+		{"percent plus flat", models.FeeLine{Percent: dec("5"), Flat: dec("1.50")}, "40", "3.5"},
+		{"rounded to the cent", models.FeeLine{Percent: dec("7.5")}, "33.33", "2.5"},
+		{"raised to min", models.FeeLine{Percent: dec("5"), MinFee: decPtr("2")}, "10", "2"},
+		{"lowered to max", models.FeeLine{Percent: dec("10"), MaxFee: decPtr("25")}, "1000", "25"},
+		{"never above amount", models.FeeLine{Flat: dec("5")}, "3", "3"},
+		{"min never above amount", models.FeeLine{MinFee: decPtr("5")}, "3", "3"},
+		{"zero amount", models.FeeLine{Percent: dec("10"), Flat: dec("1")}, "0", "0"},
+	}
+	for _, tt := range tests {
+		line := tt.line
+		priceFeeLine(&line, dec(tt.amount))
+		if !line.Amount.Equal(dec(tt.want)) {
+			t.Errorf("%s: fee = %s, want %s", tt.name, line.Amount, tt.want)
+		}
+	}
+}
+
+func TestQuoteFeesWith(t *testing.T) {
+	rules := []models.FeeRule{
+		{Name: "service fee", Side: models.FeeSidePoster, Percent: dec("10")},
+		{Name: "commission", Side: models.FeeSideTasker, Percent: dec("15"), MinFee: decPtr("5"), Currency: "USD"},
+	}
+
+	tests := []struct {
+		name                string
+		currency, amount    string
+		serviceFee, commiss string
+		posterTotal, payout string
+	}{
+		{"both sides", "USD", "100", "10", "15", "110", "85"},
+		{"commission min", "USD", "20", "2", "5", "22", "15"},
+		{"no commission rule", "ZWG", "100", "10", "0", "110", "100"},
+	}
+	for _, tt := range tests {
+		quote := quoteFeesWith(rules, &models.Task{Currency: tt.currency}, dec(tt.amount))
+		if !quote.ServiceFee.Equal(dec(tt.serviceFee)) || !quote.Commission.Equal(dec(tt.commiss)) ||
+			!quote.PosterTotal.Equal(dec(tt.posterTotal)) || !quote.TaskerPayout.Equal(dec(tt.payout)) {
+			t.Errorf("%s: quote = fee %s commission %s total %s payout %s", tt.name,
+				quote.ServiceFee, quote.Commission, quote.PosterTotal, quote.TaskerPayout)
+		}
+	}
+}
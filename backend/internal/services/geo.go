@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+const earthRadiusKm = 6371.0
+
+// Geo query backends, in order of preference.
+const (
+	GeoBackendPostGIS       = "postgis"
+	GeoBackendEarthDistance = "earthdistance"
+	GeoBackendHaversine     = "haversine"
+)
+
+// BoundingBox is a lat/lng rectangle. Boxes crossing the antimeridian are not supported.
+type BoundingBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// GeoFilter restricts a query to rows near a point and/or inside a bounding box.
+type GeoFilter struct {
+	Lat      *float64
+	Lng      *float64
+	RadiusKm float64
+	BBox     *BoundingBox
+}
+
+// HasPoint reports whether the filter is centred on a point, which enables distance ordering.
+func (f GeoFilter) HasPoint() bool {
+	return f.Lat != nil && f.Lng != nil
+}
+
+// IsZero reports whether the filter applies no geographic restriction.
+func (f GeoFilter) IsZero() bool {
+	return !f.HasPoint() && f.BBox == nil
+}
+
+// GeoService builds distance and radius SQL for tables with lat/lng columns. It uses
+// PostGIS when installed, the earthdistance extension when available, and plain
+// haversine SQL otherwise.
+type GeoService struct {
+	db      *gorm.DB
+	backend string
+}
+
+// NewGeoService detects which geo extensions the connected database provides.
+func NewGeoService(db *gorm.DB) *GeoService {
+	var extensions []string
+	if err := db.Raw("SELECT extname FROM pg_extension WHERE extname IN ('postgis', 'earthdistance')").Scan(&extensions).Error; err != nil {
+		log.Printf("[GeoService] Failed to detect extensions, falling back to haversine: %v", err)
+	}
+
+	backend := GeoBackendHaversine
+	for _, ext := range extensions {
+		if ext == "postgis" {
+			backend = GeoBackendPostGIS
+			break
+		}
+		if ext == "earthdistance" {
+			backend = GeoBackendEarthDistance
+		}
+	}
+	log.Printf("[GeoService] Using %s backend for geo queries", backend)
+
+	return &GeoService{db: db, backend: backend}
+}
+
+// Backend returns the detected geo backend name.
+func (g *GeoService) Backend() string {
+	return g.backend
+}
+
+// DistanceExpr returns an SQL expression for the distance in kilometres between the
+// row's lat/lng columns and the given point, along with its bind arguments.
+func (g *GeoService) DistanceExpr(table string, lat, lng float64) (string, []interface{}) {
+	rowLat := fmt.Sprintf("%s.lat::float8", table)
+	rowLng := fmt.Sprintf("%s.lng::float8", table)
+
+	switch g.backend {
+	case GeoBackendPostGIS:
+		return fmt.Sprintf("(ST_Distance(geography(ST_SetSRID(ST_MakePoint(%s, %s), 4326)), geography(ST_SetSRID(ST_MakePoint(?, ?), 4326))) / 1000)", rowLng, rowLat),
+			[]interface{}{lng, lat}
+	case GeoBackendEarthDistance:
+		return fmt.Sprintf("(earth_distance(ll_to_earth(%s, %s), ll_to_earth(?, ?)) / 1000)", rowLat, rowLng),
+			[]interface{}{lat, lng}
+	default:
+		return fmt.Sprintf("(%f * 2 * asin(sqrt(power(sin(radians(%s - ?) / 2), 2) + cos(radians(?)) * cos(radians(%s)) * power(sin(radians(%s - ?) / 2), 2))))",
+				earthRadiusKm, rowLat, rowLat, rowLng),
+			[]interface{}{lat, lat, lng}
+	}
+}
+
+// Apply adds the filter's radius and bounding-box conditions to query. Callers that want
+// distance_km in results select DistanceExpr themselves.
+func (g *GeoService) Apply(query *gorm.DB, table string, f GeoFilter) *gorm.DB {
+	if f.IsZero() {
+		return query
+	}
+
+	query = query.Where(fmt.Sprintf("%s.lat IS NOT NULL AND %s.lng IS NOT NULL", table, table))
+
+	if f.BBox != nil {
+		query = query.Where(fmt.Sprintf("%s.lat BETWEEN ? AND ? AND %s.lng BETWEEN ? AND ?", table, table),
+			f.BBox.MinLat, f.BBox.MaxLat, f.BBox.MinLng, f.BBox.MaxLng)
+	}
+
+	if !f.HasPoint() {
+		return query
+	}
+
+	if f.RadiusKm > 0 {
+		query = g.applyRadius(query, table, *f.Lat, *f.Lng, f.RadiusKm)
+	}
+
+	return query
+}
+
+// applyRadius restricts rows to within radiusKm of the point using an index-friendly
+// predicate for the active backend.
+func (g *GeoService) applyRadius(query *gorm.DB, table string, lat, lng, radiusKm float64) *gorm.DB {
+	rowLat := fmt.Sprintf("%s.lat::float8", table)
+	rowLng := fmt.Sprintf("%s.lng::float8", table)
+	meters := radiusKm * 1000
+
+	switch g.backend {
+	case GeoBackendPostGIS:
+		return query.Where(fmt.Sprintf("ST_DWithin(geography(ST_SetSRID(ST_MakePoint(%s, %s), 4326)), geography(ST_SetSRID(ST_MakePoint(?, ?), 4326)), ?)", rowLng, rowLat),
+			lng, lat, meters)
+	case GeoBackendEarthDistance:
+		return query.Where(fmt.Sprintf("earth_box(ll_to_earth(?, ?), ?) @> ll_to_earth(%s, %s) AND earth_distance(ll_to_earth(?, ?), ll_to_earth(%s, %s)) <= ?", rowLat, rowLng, rowLat, rowLng),
+			lat, lng, meters, lat, lng, meters)
+	default:
+		// Cheap bounding-box prefilter on the lat/lng index before the exact distance check
+		box := BoundingBoxAround(lat, lng, radiusKm)
+		distanceExpr, distanceArgs := g.DistanceExpr(table, lat, lng)
+		return query.
+			Where(fmt.Sprintf("%s.lat BETWEEN ? AND ? AND %s.lng BETWEEN ? AND ?", table, table), box.MinLat, box.MaxLat, box.MinLng, box.MaxLng).
+			Where(distanceExpr+" <= ?", append(distanceArgs, radiusKm)...)
+	}
+}
+
+// BoundingBoxAround returns the smallest lat/lng box containing a circle of radiusKm.
+func BoundingBoxAround(lat, lng, radiusKm float64) BoundingBox {
+	dLat := radiusKm / 111.045
+	dLng := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.000001 {
+		dLng = math.Min(radiusKm/(111.045*cos), 180)
+	}
+	return BoundingBox{
+		MinLat: math.Max(lat-dLat, -90),
+		MinLng: math.Max(lng-dLng, -180),
+		MaxLat: math.Min(lat+dLat, 90),
+		MaxLng: math.Min(lng+dLng, 180),
+	}
+}
+
+// HaversineKm returns the great-circle distance between two points in kilometres.
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadiusKm * 2 * math.Asin(math.Sqrt(a))
+}
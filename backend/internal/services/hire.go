@@ -0,0 +1,432 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotEquipmentHire is returned for hire events on a task that is not an assigned equipment hire.
+	ErrNotEquipmentHire = errors.New("task is not an assigned equipment hire")
+	// ErrNotHireParty is returned when someone other than the hirer or the equipment owner acts on a hire.
+	ErrNotHireParty = errors.New("only the hirer and the equipment owner can record hire events")
+	// ErrHireEventNotFound is returned when confirming an event that has not been recorded.
+	ErrHireEventNotFound = errors.New("hire event has not been recorded")
+	// ErrHireEventConfirmed is returned when changing or re-confirming a confirmed event.
+	ErrHireEventConfirmed = errors.New("hire event has already been confirmed")
+	// ErrConfirmOwnHireEvent is returned when the party who recorded an event tries to confirm it.
+	ErrConfirmOwnHireEvent = errors.New("the other party must confirm this hire event")
+	// ErrCheckInRequired is returned when checking out before the check-in is confirmed.
+	ErrCheckInRequired = errors.New("check-in must be recorded and confirmed before check-out")
+	// ErrInvalidHireReading is returned when a check-out precedes the check-in or its engine hours are lower.
+	ErrInvalidHireReading = errors.New("check-out must be after check-in, with engine hours no lower than at check-in")
+	// ErrHireNotCheckedOut is returned when completing a checked-in hire before its check-out is confirmed.
+	ErrHireNotCheckedOut = errors.New("confirm the equipment check-out before completing the hire")
+)
+
+// Hourly hires are billed in half hours, rounded up
+var hourlyBillingStep = decimal.NewFromFloat(0.5)
+
+// HireReading is what a party records at check-in or check-out
+type HireReading struct {
+	OccurredAt  *time.Time
+	Lat         *float64
+	Lng         *float64
+	EngineHours *decimal.Decimal
+	Photos      []string
+	Note        string
+}
+
+// HireService tracks when hired equipment arrives and leaves and meters the bill
+type HireService struct {
+	db  *gorm.DB
+	fcm *FCMService
+	hub *Hub
+}
+
+func NewHireService(db *gorm.DB, fcm *FCMService, hub *Hub) *HireService {
+	return &HireService{db: db, fcm: fcm, hub: hub}
+}
+
+// Record stores a check-in or check-out by either party. An unconfirmed event can be
+// recorded again, replacing the earlier reading; the other party then confirms it.
+func (s *HireService) Record(taskID, userID uuid.UUID, kind string, reading HireReading) (*models.HireEvent, error) {
+	var (
+		task  *models.Task
+		offer *models.Offer
+		event models.HireEvent
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, offer, err = lockHire(tx, taskID, userID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("task_id = ? AND kind = ?", taskID, kind).First(&event).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if event.ConfirmedAt != nil {
+			return ErrHireEventConfirmed
+		}
+
+		event.TaskID = taskID
+		event.Kind = kind
+		event.OccurredAt = time.Now()
+		if reading.OccurredAt != nil {
+			event.OccurredAt = *reading.OccurredAt
+		}
+		event.Lat = reading.Lat
+		event.Lng = reading.Lng
+		event.EngineHours = reading.EngineHours
+		event.Photos = reading.Photos
+		event.Note = reading.Note
+		event.RecordedByID = userID
+
+		if kind == models.HireEventCheckOut {
+			var checkIn models.HireEvent
+			if err := tx.Where("task_id = ? AND kind = ?", taskID, models.HireEventCheckIn).First(&checkIn).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrCheckInRequired
+				}
+				return err
+			}
+			if checkIn.ConfirmedAt == nil {
+				return ErrCheckInRequired
+			}
+			if !event.OccurredAt.After(checkIn.OccurredAt) ||
+				(event.EngineHours != nil && checkIn.EngineHours != nil && event.EngineHours.LessThan(*checkIn.EngineHours)) {
+				return ErrInvalidHireReading
+			}
+		}
+
+		return tx.Save(&event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recipient := otherHireParty(task, offer, userID)
+	s.publish(task, &event, nil, recipient, "hire_event_recorded", "Confirm "+hireEventTitle(kind),
+		fmt.Sprintf("The %s for %s was recorded. Please confirm it.", hireEventLabel(kind), task.Title))
+	return &event, nil
+}
+
+// Confirm accepts the other party's check-in or check-out. Confirming the check-out
+// meters the hire and adjusts the escrow to the billed amount.
+func (s *HireService) Confirm(taskID, userID uuid.UUID, kind string) (*models.HireEvent, *models.HireSettlement, error) {
+	var (
+		task       *models.Task
+		offer      *models.Offer
+		event      models.HireEvent
+		settlement *models.HireSettlement
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, offer, err = lockHire(tx, taskID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id = ? AND kind = ?", taskID, kind).First(&event).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrHireEventNotFound
+			}
+			return err
+		}
+		if event.ConfirmedAt != nil {
+			return ErrHireEventConfirmed
+		}
+		if event.RecordedByID == userID {
+			return ErrConfirmOwnHireEvent
+		}
+
+		now := time.Now()
+		event.ConfirmedByID = &userID
+		event.ConfirmedAt = &now
+		if err := tx.Model(&event).Updates(map[string]interface{}{
+			"confirmed_by_id": userID,
+			"confirmed_at":    now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if kind != models.HireEventCheckOut {
+			return nil
+		}
+		var checkIn models.HireEvent
+		if err := tx.Where("task_id = ? AND kind = ?", taskID, models.HireEventCheckIn).First(&checkIn).Error; err != nil {
+			return err
+		}
+		metered := MeterHire(offer, &checkIn, &event)
+		settlement = &metered
+
+		var open []models.EscrowTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("task_id = ? AND status IN ?", taskID, []string{models.EscrowPending, models.EscrowHeld}).
+			Find(&open).Error; err != nil {
+			return err
+		}
+		// Held escrow was collected at the estimate and nothing collects more, so
+		// the escrow is only repriced up to it; the payout can never exceed the
+		// collection
+		for i := range open {
+			if held := escrowAmount(&open[i]); open[i].Status == models.EscrowHeld && settlement.BilledAmount.GreaterThan(held) {
+				settlement.UncollectedAmount = settlement.BilledAmount.Sub(held)
+			}
+		}
+		if err := tx.Create(settlement).Error; err != nil {
+			return err
+		}
+
+		for i := range open {
+			escrow := &open[i]
+			estimated := escrowTotal(escrow)
+			billed := settlement.BilledAmount
+			if escrow.Status == models.EscrowHeld {
+				billed = decimal.Min(billed, escrowAmount(escrow))
+			}
+			escrow.Amount = billed
+			repriceEscrowFees(escrow, billed)
+			if err := tx.Model(escrow).Select("amount", "service_fee", "commission", "fees").Updates(escrow).Error; err != nil {
+				return err
+			}
+			if err := postEscrowAdjustedTx(tx, escrow, settlement.ID.String(), estimated, escrowTotal(escrow)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.publish(task, &event, settlement, event.RecordedByID, "hire_event_confirmed", hireEventTitle(kind)+" Confirmed",
+		fmt.Sprintf("The %s for %s was confirmed.", hireEventLabel(kind), task.Title))
+	if settlement != nil {
+		message := fmt.Sprintf("The hire for %s was metered at %s (estimate %s).",
+			task.Title, money.New(settlement.BilledAmount, task.Currency), money.New(settlement.EstimatedAmount, task.Currency))
+		if settlement.UncollectedAmount.IsPositive() {
+			message += fmt.Sprintf(" The escrow covers the estimate; the remaining %s is settled between you directly.",
+				money.New(settlement.UncollectedAmount, task.Currency))
+		}
+		for _, recipient := range []uuid.UUID{task.PosterID, offer.TaskerID} {
+			if _, err := Notify(s.db, s.fcm, recipient, "hire_metered", "Hire Metered", message,
+				map[string]interface{}{"task_id": task.ID.String()}); err != nil {
+				log.Printf("[Hire] Failed to notify user %s of metered hire %s: %v", recipient, task.ID, err)
+			}
+		}
+	}
+	return &event, settlement, nil
+}
+
+// Events returns the task's hire events and settlement for either party
+func (s *HireService) Events(taskID, userID uuid.UUID) ([]models.HireEvent, *models.HireSettlement, error) {
+	var task models.Task
+	if err := s.db.First(&task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTaskNotFound
+		}
+		return nil, nil, err
+	}
+	offer, err := hireOffer(s.db, &task)
+	if err != nil {
+		return nil, nil, err
+	}
+	if userID != task.PosterID && userID != offer.TaskerID {
+		return nil, nil, ErrNotHireParty
+	}
+
+	var events []models.HireEvent
+	if err := s.db.Where("task_id = ?", taskID).Order("occurred_at asc").Find(&events).Error; err != nil {
+		return nil, nil, err
+	}
+	var settlement models.HireSettlement
+	if err := s.db.Where("task_id = ?", taskID).First(&settlement).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return events, nil, nil
+		}
+		return nil, nil, err
+	}
+	return events, &settlement, nil
+}
+
+// RequireCheckedOutTx fails with ErrHireNotCheckedOut when the task's equipment was
+// checked in but the check-out is not yet confirmed
+func RequireCheckedOutTx(tx *gorm.DB, taskID uuid.UUID) error {
+	var events []models.HireEvent
+	if err := tx.Where("task_id = ?", taskID).Find(&events).Error; err != nil {
+		return err
+	}
+	checkedIn, checkedOut := false, false
+	for _, event := range events {
+		switch event.Kind {
+		case models.HireEventCheckIn:
+			checkedIn = true
+		case models.HireEventCheckOut:
+			checkedOut = event.ConfirmedAt != nil
+		}
+	}
+	if checkedIn && !checkedOut {
+		return ErrHireNotCheckedOut
+	}
+	return nil
+}
+
+// MeterHire bills a hire from its confirmed check-in and check-out. Hourly rates
+// are charged per half hour of machine time (engine hours when both readings were
+// given), daily and weekly rates per started day or week on site. The offer's quote
+// lines are repriced with those quantities; delivery stays fixed, and any
+// difference negotiated between the quote and the offer amount is kept as an
+// adjustment line. Offers without a rate are billed at their agreed amount.
+func MeterHire(offer *models.Offer, checkIn, checkOut *models.HireEvent) models.HireSettlement {
+	elapsed := decimal.NewFromFloat(checkOut.OccurredAt.Sub(checkIn.OccurredAt).Hours()).Round(2)
+	machine := elapsed
+	if checkIn.EngineHours != nil && checkOut.EngineHours != nil {
+		machine = checkOut.EngineHours.Sub(*checkIn.EngineHours)
+	}
+
+	estimate := offer.Amount.Round(2)
+	settlement := models.HireSettlement{
+		TaskID:          offer.TaskID,
+		OfferID:         offer.ID,
+		RateType:        offer.RateType,
+		ElapsedHours:    elapsed,
+		MachineHours:    machine,
+		EstimatedAmount: estimate,
+		BilledAmount:    estimate,
+	}
+
+	switch offer.RateType {
+	case "hourly":
+		settlement.BilledQuantity = machine.Div(hourlyBillingStep).Ceil().Mul(hourlyBillingStep)
+	case "daily":
+		settlement.BilledQuantity = decimal.Max(decimal.NewFromInt(1), elapsed.Div(decimal.NewFromInt(24)).Ceil())
+	case "weekly":
+		settlement.BilledQuantity = decimal.Max(decimal.NewFromInt(1), elapsed.Div(decimal.NewFromInt(24*7)).Ceil())
+	default:
+		return settlement
+	}
+
+	lines := offer.QuoteLines
+	if len(lines) == 0 {
+		if offer.BaseRate == nil {
+			return settlement
+		}
+		lines = []models.QuoteLine{{Kind: models.QuoteLineBase, Description: "Hire", UnitPrice: *offer.BaseRate}}
+		if offer.DeliveryFee != nil && offer.DeliveryFee.IsPositive() {
+			fee := offer.DeliveryFee.Round(2)
+			lines = append(lines, models.QuoteLine{Kind: models.QuoteLineDelivery, Description: "Delivery and collection",
+				Quantity: decimal.NewFromInt(1), UnitPrice: fee, Amount: fee})
+		}
+	}
+
+	quoted := decimal.Zero
+	billed := decimal.Zero
+	for _, line := range lines {
+		quoted = quoted.Add(line.Amount)
+		switch line.Kind {
+		case models.QuoteLineBase, models.QuoteLineOperator:
+			line.Quantity = settlement.BilledQuantity
+		case models.QuoteLineFuel:
+			line.Quantity = machine
+		}
+		line.Amount = line.Quantity.Mul(line.UnitPrice).Round(2)
+		settlement.Lines = append(settlement.Lines, line)
+		billed = billed.Add(line.Amount)
+	}
+	if len(offer.QuoteLines) > 0 {
+		if adjustment := estimate.Sub(quoted); !adjustment.IsZero() {
+			settlement.Lines = append(settlement.Lines, models.QuoteLine{Kind: models.QuoteLineAdjustment,
+				Description: "Negotiated adjustment", Quantity: decimal.NewFromInt(1), UnitPrice: adjustment, Amount: adjustment})
+			billed = billed.Add(adjustment)
+		}
+	}
+
+	settlement.BilledAmount = decimal.Max(decimal.Zero, billed)
+	settlement.Difference = settlement.BilledAmount.Sub(estimate)
+	return settlement
+}
+
+// lockHire locks the task and checks it is an assigned equipment hire userID is party to
+func lockHire(tx *gorm.DB, taskID, userID uuid.UUID) (*models.Task, *models.Offer, error) {
+	task, err := LockTask(tx, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if task.Status != models.TaskStatusAssigned && task.Status != models.TaskStatusInProgress {
+		return nil, nil, ErrNotEquipmentHire
+	}
+	offer, err := hireOffer(tx.Clauses(clause.Locking{Strength: "UPDATE"}), task)
+	if err != nil {
+		return nil, nil, err
+	}
+	if userID != task.PosterID && userID != offer.TaskerID {
+		return nil, nil, ErrNotHireParty
+	}
+	return task, offer, nil
+}
+
+// hireOffer loads the accepted offer of an equipment task
+func hireOffer(db *gorm.DB, task *models.Task) (*models.Offer, error) {
+	if task.TaskType != "equipment" || task.AcceptedOfferID == nil {
+		return nil, ErrNotEquipmentHire
+	}
+	var offer models.Offer
+	if err := db.First(&offer, "id = ?", *task.AcceptedOfferID).Error; err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func otherHireParty(task *models.Task, offer *models.Offer, userID uuid.UUID) uuid.UUID {
+	if userID == task.PosterID {
+		return offer.TaskerID
+	}
+	return task.PosterID
+}
+
+func hireEventLabel(kind string) string {
+	if kind == models.HireEventCheckOut {
+		return "check-out"
+	}
+	return "check-in"
+}
+
+func hireEventTitle(kind string) string {
+	if kind == models.HireEventCheckOut {
+		return "Check-out"
+	}
+	return "Check-in"
+}
+
+// publish notifies recipient of a hire event and broadcasts it on the task's room
+func (s *HireService) publish(task *models.Task, event *models.HireEvent, settlement *models.HireSettlement, recipient uuid.UUID, notificationType, title, message string) {
+	data := map[string]interface{}{
+		"task_id":       task.ID.String(),
+		"hire_event_id": event.ID.String(),
+		"kind":          event.Kind,
+	}
+	if _, err := Notify(s.db, s.fcm, recipient, notificationType, title, message, data); err != nil {
+		log.Printf("[Hire] Failed to notify user %s of hire event %s: %v", recipient, event.ID, err)
+	}
+
+	if s.hub != nil {
+		s.hub.BroadcastToRoom("task_updates:"+task.ID.String(), map[string]interface{}{
+			"type":       "hire_updated",
+			"task_id":    task.ID,
+			"event":      event,
+			"settlement": settlement,
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+)
+
+func TestMeterHire(t *testing.T) {
+	start := time.Date(2026, 10, 5, 8, 0, 0, 0, time.UTC)
+	structured := []models.QuoteLine{
+		{Kind: models.QuoteLineBase, Quantity: dec("1"), UnitPrice: dec("100"), Amount: dec("100")},
+		{Kind: models.QuoteLineFuel, Quantity: dec("8"), UnitPrice: dec("2"), Amount: dec("16")},
+		{Kind: models.QuoteLineDelivery, Quantity: dec("1"), UnitPrice: dec("30"), Amount: dec("30")},
+	}
+
+	tests := []struct {
+		name         string
+		offer        models.Offer
+		elapsed      time.Duration
+		engineHours  [2]string
+		wantQuantity string
+		wantBilled   string
+		wantDiff     string
+	}{
+		{"fixed price", models.Offer{Amount: dec("150")}, 30 * time.Hour, [2]string{}, "0", "150", "0"},
+		{"hourly without rate", models.Offer{Amount: dec("60"), RateType: "hourly"}, 3 * time.Hour, [2]string{}, "3", "60", "0"},
+		{"hourly rounds up to the half hour", models.Offer{Amount: dec("60"), RateType: "hourly", BaseRate: decPtr("20")},
+			3*time.Hour + 10*time.Minute, [2]string{}, "3.5", "70", "10"},
+		{"hourly on engine hours", models.Offer{Amount: dec("60"), RateType: "hourly", BaseRate: decPtr("20")},
+			5 * time.Hour, [2]string{"100.0", "102.0"}, "2", "40", "-20"},
+		{"daily bills each started day", models.Offer{Amount: dec("130"), RateType: "daily", BaseRate: decPtr("100"), DeliveryFee: decPtr("30")},
+			25 * time.Hour, [2]string{}, "2", "230", "100"},
+		{"daily bills at least a day", models.Offer{Amount: dec("100"), RateType: "daily", BaseRate: decPtr("100")},
+			2 * time.Hour, [2]string{}, "1", "100", "0"},
+		{"weekly bills each started week", models.Offer{Amount: dec("500"), RateType: "weekly", BaseRate: decPtr("500")},
+			8 * 24 * time.Hour, [2]string{}, "2", "1000", "500"},
+		{"structured quote keeps negotiated adjustment", models.Offer{Amount: dec("140"), RateType: "daily", QuoteLines: structured},
+			30 * time.Hour, [2]string{"10.0", "22.0"}, "2", "248", "108"},
+	}
+	for _, tt := range tests {
+		checkIn := models.HireEvent{OccurredAt: start}
+		checkOut := models.HireEvent{OccurredAt: start.Add(tt.elapsed)}
+		if tt.engineHours[0] != "" {
+			checkIn.EngineHours = decPtr(tt.engineHours[0])
+			checkOut.EngineHours = decPtr(tt.engineHours[1])
+		}
+
+		settlement := MeterHire(&tt.offer, &checkIn, &checkOut)
+		if !settlement.BilledQuantity.Equal(dec(tt.wantQuantity)) {
+			t.Errorf("%s: quantity = %s, want %s", tt.name, settlement.BilledQuantity, tt.wantQuantity)
+		}
+		if !settlement.BilledAmount.Equal(dec(tt.wantBilled)) {
+			t.Errorf("%s: billed = %s, want %s", tt.name, settlement.BilledAmount, tt.wantBilled)
+		}
+		if !settlement.Difference.Equal(dec(tt.wantDiff)) {
+			t.Errorf("%s: difference = %s, want %s", tt.name, settlement.Difference, tt.wantDiff)
+		}
+		if !settlement.EstimatedAmount.Equal(tt.offer.Amount) {
+			t.Errorf("%s: estimate = %s, want %s", tt.name, settlement.EstimatedAmount, tt.offer.Amount)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/imaging"
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// imageVariantSizes are the longest-side pixel sizes of the generated variants
+var imageVariantSizes = []struct {
+	suffix string
+	size   int
+}{
+	{"thumb", 320},
+	{"medium", 1024},
+	{"large", 2048},
+}
+
+const (
+	// imageProcessTimeout bounds the processing of one image, including storage I/O
+	imageProcessTimeout = 2 * time.Minute
+	// imagePipelineWorkers limits how many images are decoded at once, since a
+	// decoded photo can take hundreds of megabytes
+	imagePipelineWorkers = 2
+	// maxProcessedBytes is the largest stored object the pipeline will read
+	maxProcessedBytes = 20 << 20
+)
+
+var errNotImage = errors.New("object is not a processable image")
+
+// ImagePipeline post-processes uploaded photos in the background: it strips EXIF
+// (including GPS coordinates) from the stored original, rotates it upright,
+// writes thumbnail, medium and large variants and computes a blurhash.
+type ImagePipeline struct {
+	db      *gorm.DB
+	storage storage.Storage
+	workers chan struct{}
+}
+
+func NewImagePipeline(db *gorm.DB, store storage.Storage) *ImagePipeline {
+	return &ImagePipeline{db: db, storage: store, workers: make(chan struct{}, imagePipelineWorkers)}
+}
+
+// ProcessTaskAttachments processes the given image attachments in the background
+// and records their variants.
+func (p *ImagePipeline) ProcessTaskAttachments(ids []uuid.UUID) {
+	if len(ids) == 0 {
+		return
+	}
+	go func() {
+		var attachments []models.TaskAttachment
+		if err := p.db.Where("id IN ? AND type = ?", ids, "image").Find(&attachments).Error; err != nil {
+			log.Printf("[Images] Failed to load attachments: %v", err)
+			return
+		}
+
+		for _, attachment := range attachments {
+			if attachment.Blurhash != "" {
+				continue
+			}
+			variants, err := p.process(attachment.URL, true)
+			if err != nil {
+				log.Printf("[Images] Failed to process attachment %s: %v", attachment.ID, err)
+				continue
+			}
+			if variants == nil {
+				continue
+			}
+			err = p.db.Model(&models.TaskAttachment{}).Where("id = ?", attachment.ID).Updates(map[string]interface{}{
+				"thumbnail_url": variants.ThumbnailURL,
+				"medium_url":    variants.MediumURL,
+				"large_url":     variants.LargeURL,
+				"blurhash":      variants.Blurhash,
+				"width":         variants.Width,
+				"height":        variants.Height,
+			}).Error
+			if err != nil {
+				log.Printf("[Images] Failed to save variants of attachment %s: %v", attachment.ID, err)
+			}
+		}
+	}()
+}
+
+// ProcessInventoryItem processes any photos of an inventory item that have no
+// variants yet, in the background.
+func (p *ImagePipeline) ProcessInventoryItem(itemID uuid.UUID) {
+	go func() {
+		var item models.InventoryItem
+		if err := p.db.First(&item, "id = ?", itemID).Error; err != nil {
+			log.Printf("[Images] Failed to load inventory item %s: %v", itemID, err)
+			return
+		}
+
+		known := make(map[string]bool, len(item.PhotoVariants))
+		for _, v := range item.PhotoVariants {
+			known[v.URL] = true
+		}
+
+		var processed []models.PhotoVariant
+		current := 0
+		for _, url := range item.Photos {
+			if known[url] {
+				current++
+				continue
+			}
+			variants, err := p.process(url, true)
+			if err != nil {
+				log.Printf("[Images] Failed to process photo %s of inventory item %s: %v", url, itemID, err)
+				continue
+			}
+			if variants != nil {
+				processed = append(processed, models.PhotoVariant{URL: url, ImageVariants: *variants})
+			}
+		}
+		// Nothing new and no variants of removed photos to prune
+		if len(processed) == 0 && current == len(item.PhotoVariants) {
+			return
+		}
+
+		// Merge into the current row so photos changed while processing are respected
+		err := p.db.Transaction(func(tx *gorm.DB) error {
+			var current models.InventoryItem
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", itemID).Error; err != nil {
+				return err
+			}
+			byURL := map[string]models.PhotoVariant{}
+			for _, v := range append(current.PhotoVariants, processed...) {
+				byURL[v.URL] = v
+			}
+			variants := make([]models.PhotoVariant, 0, len(current.Photos))
+			for _, url := range current.Photos {
+				if v, ok := byURL[url]; ok {
+					variants = append(variants, v)
+				}
+			}
+			return tx.Model(&current).UpdateColumn("photo_variants", variants).Error
+		})
+		if err != nil {
+			log.Printf("[Images] Failed to save photo variants of inventory item %s: %v", itemID, err)
+		}
+	}()
+}
+
+// Sanitize strips metadata from stored images in the background without
+// generating variants. It is used for avatars, portfolio images and KYC
+// documents; URLs outside our storage and non-image documents are skipped.
+func (p *ImagePipeline) Sanitize(urls ...string) {
+	go func() {
+		for _, url := range urls {
+			if url == "" {
+				continue
+			}
+			if _, err := p.process(url, false); err != nil {
+				log.Printf("[Images] Failed to sanitize %s: %v", url, err)
+			}
+		}
+	}()
+}
+
+// DeleteVariants removes the stored variant files of an image. Variants that
+// point at the original (for images smaller than a variant size) are kept.
+func (p *ImagePipeline) DeleteVariants(ctx context.Context, originalURL string, variants models.ImageVariants) {
+	for _, url := range []string{variants.ThumbnailURL, variants.MediumURL, variants.LargeURL} {
+		if url == "" || url == originalURL {
+			continue
+		}
+		key, ok := p.storage.KeyFromURL(url)
+		if !ok {
+			continue
+		}
+		if err := p.storage.Delete(ctx, key); err != nil {
+			log.Printf("[Images] Failed to delete variant %s: %v", key, err)
+		}
+	}
+}
+
+// process sanitises the object at url in place and, when withVariants is set,
+// stores its variants. It returns nil variants for URLs that are not ours or
+// objects that are not images.
+func (p *ImagePipeline) process(url string, withVariants bool) (*models.ImageVariants, error) {
+	key, ok := p.storage.KeyFromURL(url)
+	if !ok {
+		return nil, nil
+	}
+
+	p.workers <- struct{}{}
+	defer func() { <-p.workers }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), imageProcessTimeout)
+	defer cancel()
+
+	data, contentType, err := p.read(ctx, key)
+	if errors.Is(err, errNotImage) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	photo, err := imaging.Process(data, contentType)
+	if errors.Is(err, imaging.ErrUnsupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(photo.Data, data) {
+		if _, err := p.storage.Put(ctx, key, bytes.NewReader(photo.Data), int64(len(photo.Data)), photo.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to store sanitized original: %w", err)
+		}
+	}
+
+	if !withVariants || photo.Image == nil {
+		return nil, nil
+	}
+
+	variants := &models.ImageVariants{
+		Blurhash: imaging.Blurhash(photo.Image, 4, 3),
+		Width:    photo.Width,
+		Height:   photo.Height,
+	}
+	base := strings.TrimSuffix(key, path.Ext(key))
+	for _, size := range imageVariantSizes {
+		variantURL := url
+		if photo.Width > size.size || photo.Height > size.size {
+			encoded, variantType, ext, err := imaging.Encode(imaging.Fit(photo.Image, size.size))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s variant: %w", size.suffix, err)
+			}
+			object, err := p.storage.Put(ctx, base+"_"+size.suffix+ext, bytes.NewReader(encoded), int64(len(encoded)), variantType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to store %s variant: %w", size.suffix, err)
+			}
+			variantURL = object.URL
+		}
+
+		switch size.suffix {
+		case "thumb":
+			variants.ThumbnailURL = variantURL
+		case "medium":
+			variants.MediumURL = variantURL
+		case "large":
+			variants.LargeURL = variantURL
+		}
+	}
+	return variants, nil
+}
+
+// read loads a stored object, returning errNotImage for non-image content
+func (p *ImagePipeline) read(ctx context.Context, key string) ([]byte, string, error) {
+	body, err := p.storage.Get(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxProcessedBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxProcessedBytes {
+		return nil, "", errNotImage
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", errNotImage
+	}
+	return data, contentType, nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInstantBookUnavailable is returned when the item is not available for instant booking.
+	ErrInstantBookUnavailable = errors.New("this item cannot be booked instantly; post a task to request it instead")
+	// ErrOwnEquipment is returned when an owner tries to hire their own item.
+	ErrOwnEquipment = errors.New("you cannot hire your own equipment")
+)
+
+// InstantBookRequest is a hirer's booking of an item at its published rates
+type InstantBookRequest struct {
+	StartsAt         time.Time
+	HireDurationType string // hourly, daily, weekly, monthly
+	Duration         int
+	WithOperator     bool
+	FuelIncluded     bool
+	Location         string
+	Lat              *float64
+	Lng              *float64
+	Notes            string
+}
+
+// InstantBooking is the task, accepted offer, booking, escrow and conversation
+// created by an instant booking
+type InstantBooking struct {
+	Task    models.Task              `json:"task"`
+	Booking *models.EquipmentBooking `json:"booking"`
+	OfferAcceptance
+}
+
+// InstantBook hires an instant-book item in one transaction: it creates an
+// equipment task for the hirer, an offer from the owner priced by the quote engine,
+// and accepts it, which books the dates and holds the escrow. Any failure, such as
+// an overlapping booking, rolls everything back.
+//
+// A non-empty idempotencyKey makes retries safe, as for Accept.
+func (s *OfferService) InstantBook(inventoryID, hirerID uuid.UUID, req InstantBookRequest, idempotencyKey string) (*InstantBooking, error) {
+	scope := "instant_book:" + inventoryID.String()
+	if result, err := s.replayInstantBook(s.db, hirerID, idempotencyKey, scope); result != nil || err != nil {
+		return result, err
+	}
+
+	var (
+		result     *InstantBooking
+		transition *TaskTransition
+		item       models.InventoryItem
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// The item lock serialises instant bookings of the item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, "id = ?", inventoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryNotFound
+			}
+			return err
+		}
+
+		if replayed, err := s.replayInstantBook(tx, hirerID, idempotencyKey, scope); replayed != nil || err != nil {
+			result = replayed
+			return err
+		}
+
+		if item.UserID == hirerID {
+			return ErrOwnEquipment
+		}
+		if item.BookingMode != models.BookingModeInstant || !item.IsAvailable {
+			return ErrInstantBookUnavailable
+		}
+
+		startsAt := req.StartsAt
+		task := models.Task{
+			PosterID:           hirerID,
+			Title:              "Hire: " + item.Name,
+			Description:        req.Notes,
+			Category:           item.Category,
+			TaskType:           "equipment",
+			Currency:           item.Currency,
+			Location:           req.Location,
+			Lat:                req.Lat,
+			Lng:                req.Lng,
+			DateType:           "on_date",
+			Date:               &startsAt,
+			Status:             models.TaskStatusOpen,
+			HireDurationType:   req.HireDurationType,
+			FuelIncluded:       req.FuelIncluded,
+			OperatorPreference: "not_needed",
+			RequiredCapacityID: item.CapacityID,
+			OfferCount:         1,
+		}
+		if task.Description == "" {
+			task.Description = fmt.Sprintf("Instant booking of %s", item.Name)
+		}
+		duration := req.Duration
+		if req.HireDurationType == "hourly" {
+			task.EstimatedHours = &duration
+		} else {
+			task.EstimatedDuration = &duration
+		}
+		if req.WithOperator {
+			task.OperatorPreference = "required"
+		}
+
+		quote, err := BuildEquipmentQuote(&task, &item, item.UserID)
+		if err != nil {
+			return err
+		}
+		task.Budget = quote.Total
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		quote.TaskID = task.ID
+
+		offer := models.Offer{
+			TaskID:            task.ID,
+			TaskerID:          item.UserID,
+			Description:       task.Description,
+			EstimatedDuration: fmt.Sprintf("%d %s", duration, hireUnitLabel(req.HireDurationType)),
+			Status:            models.OfferStatusPending,
+		}
+		quote.Apply(&offer)
+		if err := tx.Create(&offer).Error; err != nil {
+			return err
+		}
+		if err := RecordOfferRevision(tx, &offer, models.OfferRevisionCreated, &offer.TaskerID, nil); err != nil {
+			return err
+		}
+
+		assigned, err := s.assignTx(tx, &task, &offer, hirerID)
+		if err != nil {
+			return err
+		}
+		transition = assigned.transition
+
+		if idempotencyKey != "" {
+			key := models.IdempotencyKey{UserID: hirerID, Key: idempotencyKey, Scope: scope, ResourceID: offer.ID}
+			if err := tx.Create(&key).Error; err != nil {
+				return err
+			}
+		}
+
+		result = &InstantBooking{Task: task, Booking: assigned.booking, OfferAcceptance: *assigned.acceptance(&offer)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Replayed {
+		return result, nil
+	}
+
+	// The instant_booking notification below covers the assignment
+	transition.SkipNotify = true
+	s.lifecycle.Publish(transition)
+
+	if _, err := Notify(s.db, s.fcm, item.UserID, "instant_booking", "New Instant Booking",
+		fmt.Sprintf("%s was booked from %s for %s.", item.Name, req.StartsAt.Format("2 Jan 2006 15:04"), result.Offer.AmountMoney()),
+		map[string]interface{}{
+			"task_id":         result.Task.ID.String(),
+			"inventory_id":    item.ID.String(),
+			"conversation_id": result.ConversationID.String(),
+		}); err != nil {
+		log.Printf("[Offers] Failed to notify owner %s of instant booking %s: %v", item.UserID, result.Task.ID, err)
+	}
+	return result, nil
+}
+
+// replayInstantBook returns the earlier instant booking recorded under key, if any
+func (s *OfferService) replayInstantBook(db *gorm.DB, userID uuid.UUID, key, scope string) (*InstantBooking, error) {
+	acceptance, err := s.replay(db, userID, key, scope)
+	if acceptance == nil || err != nil {
+		return nil, err
+	}
+
+	result := &InstantBooking{OfferAcceptance: *acceptance}
+	if err := db.First(&result.Task, "id = ?", acceptance.Offer.TaskID).Error; err != nil {
+		return nil, err
+	}
+	var booking models.EquipmentBooking
+	if err := db.Where("offer_id = ?", acceptance.Offer.ID).Order("created_at DESC").First(&booking).Error; err == nil {
+		result.Booking = &booking
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvoiceNotFound is returned when the invoice does not exist.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrNotInvoiceParty is returned when someone other than the poster or tasker views an invoice.
+	ErrNotInvoiceParty = errors.New("only the poster and tasker can view this invoice")
+	// ErrNotCreditable is returned when crediting a credit note rather than an invoice.
+	ErrNotCreditable = errors.New("only invoices can be credited")
+	// ErrInvalidCredit is returned for a credit that is not positive or exceeds what
+	// remains uncredited on the invoice.
+	ErrInvalidCredit = errors.New("credit must be positive and no more than the invoice's uncredited total")
+)
+
+// Invoice number series
+const (
+	invoiceSeries    = "INV"
+	creditNoteSeries = "CN"
+)
+
+// invoiceSchema stops issued invoices from being changed or deleted by anything,
+// not just the application. Statements are idempotent so they can run on each
+// startup alongside AutoMigrate.
+var invoiceSchema = []string{
+	`CREATE OR REPLACE FUNCTION invoice_immutable() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'invoices are immutable once issued; issue a credit note instead';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS invoices_immutable ON invoices`,
+	`CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+		FOR EACH ROW EXECUTE FUNCTION invoice_immutable()`,
+}
+
+// EnsureInvoiceSchema installs the trigger that makes invoices immutable.
+func EnsureInvoiceSchema(db *gorm.DB) error {
+	for _, stmt := range invoiceSchema {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvoiceService issues invoices for completed tasks and credit notes against
+// them. Issuer details and the tax rate come from configuration and are copied
+// onto each document.
+type InvoiceService struct {
+	db  *gorm.DB
+	fcm *FCMService
+	cfg config.InvoiceConfig
+}
+
+func NewInvoiceService(db *gorm.DB, fcm *FCMService, cfg config.InvoiceConfig) *InvoiceService {
+	return &InvoiceService{db: db, fcm: fcm, cfg: cfg}
+}
+
+// nextInvoiceNumberTx takes the next number in series for the year of at. The
+// counter row stays locked until tx ends, so numbers are sequential without gaps.
+func nextInvoiceNumberTx(tx *gorm.DB, series string, at time.Time) (string, error) {
+	var seq models.InvoiceSequence
+	if err := tx.Raw(`INSERT INTO invoice_sequences (series, year, last_number) VALUES (?, ?, 1)
+		ON CONFLICT (series, year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING series, year, last_number`, series, at.Year()).Scan(&seq).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%06d", seq.Series, seq.Year, seq.LastNumber), nil
+}
+
+// inclusiveTax is the tax contained in a tax-inclusive total
+func inclusiveTax(total, rate decimal.Decimal) decimal.Decimal {
+	if !rate.IsPositive() {
+		return decimal.Zero
+	}
+	hundred := decimal.NewFromInt(100)
+	return total.Mul(rate).Div(hundred.Add(rate)).Round(2)
+}
+
+// sumQuoteLines totals lines
+func sumQuoteLines(lines []models.QuoteLine) decimal.Decimal {
+	total := decimal.Zero
+	for _, line := range lines {
+		total = total.Add(line.Amount)
+	}
+	return total
+}
+
+// invoiceLines itemises amount: a metered hire's billed lines, else the accepted
+// offer's quote, else one line for the task. Itemised lines are used only when
+// they add up to what was charged, as after a negotiated price they may not.
+func invoiceLines(task *models.Task, offer *models.Offer, settlement *models.HireSettlement, amount decimal.Decimal) []models.QuoteLine {
+	if settlement != nil && len(settlement.Lines) > 0 && sumQuoteLines(settlement.Lines).Equal(amount) {
+		return settlement.Lines
+	}
+	if len(offer.QuoteLines) > 0 && sumQuoteLines(offer.QuoteLines).Equal(amount) {
+		return offer.QuoteLines
+	}
+	return []models.QuoteLine{{
+		Kind:        models.QuoteLineBase,
+		Description: task.Title,
+		Quantity:    decimal.NewFromInt(1),
+		UnitPrice:   amount,
+		Amount:      amount,
+	}}
+}
+
+// partyNames returns the names of the poster and tasker
+func partyNames(tx *gorm.DB, posterID, taskerID uuid.UUID) (string, string, error) {
+	var users []models.User
+	if err := tx.Select("id", "name").Where("id IN ?", []uuid.UUID{posterID, taskerID}).Find(&users).Error; err != nil {
+		return "", "", err
+	}
+	var poster, tasker string
+	for _, u := range users {
+		if u.ID == posterID {
+			poster = u.Name
+		}
+		if u.ID == taskerID {
+			tasker = u.Name
+		}
+	}
+	return poster, tasker, nil
+}
+
+// IssueTx issues the invoice for a completed task's released escrow, itemised from
+// the hire settlement or accepted offer. Issuing twice for the same escrow returns
+// the first invoice.
+func (s *InvoiceService) IssueTx(tx *gorm.DB, task *models.Task, offer *models.Offer, escrow *models.EscrowTransaction, settlement *models.HireSettlement) (*models.Invoice, error) {
+	var existing models.Invoice
+	err := tx.Where("escrow_id = ? AND kind = ?", escrow.ID, models.InvoiceKindInvoice).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	posterName, taskerName, err := partyNames(tx, escrow.PosterID, escrow.TaskerID)
+	if err != nil {
+		return nil, err
+	}
+	issuedAt := time.Now()
+	number, err := nextInvoiceNumberTx(tx, invoiceSeries, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	fees := EscrowFeeQuote(escrow)
+	taxRate := decimal.NewFromFloat(s.cfg.TaxRate).Round(2)
+	invoice := models.Invoice{
+		Number:          number,
+		Kind:            models.InvoiceKindInvoice,
+		TaskID:          task.ID,
+		EscrowID:        &escrow.ID,
+		PosterID:        escrow.PosterID,
+		TaskerID:        escrow.TaskerID,
+		IssuerName:      s.cfg.IssuerName,
+		IssuerAddress:   s.cfg.IssuerAddress,
+		IssuerTaxNumber: s.cfg.IssuerTaxNumber,
+		PosterName:      posterName,
+		TaskerName:      taskerName,
+		TaskTitle:       task.Title,
+		Currency:        escrow.Currency,
+		Lines:           invoiceLines(task, offer, settlement, fees.Amount),
+		Subtotal:        fees.Amount,
+		ServiceFee:      fees.ServiceFee,
+		Commission:      fees.Commission,
+		Fees:            fees.Lines,
+		Total:           fees.PosterTotal,
+		TaskerPayout:    fees.TaskerPayout,
+		TaxName:         s.cfg.TaxName,
+		TaxRate:         taxRate,
+		Tax:             inclusiveTax(fees.PosterTotal, taxRate),
+		PaymentMethod:   escrow.PaymentMethod,
+		IssuedAt:        issuedAt,
+	}
+	if err := tx.Create(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// IssueCreditNote credits amount of an invoice back, or whatever remains
+// uncredited when amount is nil. A partial credit splits between the tasker's
+// price and the service fee in the invoice's proportions. The credit note is a
+// document only; money is returned through payments. Both parties are notified.
+func (s *InvoiceService) IssueCreditNote(invoiceID uuid.UUID, amount *decimal.Decimal, reason string) (*models.Invoice, error) {
+	var note models.Invoice
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var invoice models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", invoiceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvoiceNotFound
+			}
+			return err
+		}
+		if invoice.Kind != models.InvoiceKindInvoice {
+			return ErrNotCreditable
+		}
+
+		var credited decimal.Decimal
+		if err := tx.Model(&models.Invoice{}).Select("COALESCE(SUM(total), 0)").
+			Where("credited_invoice_id = ?", invoice.ID).Scan(&credited).Error; err != nil {
+			return err
+		}
+		remaining := invoice.Total.Sub(credited)
+		credit := remaining
+		if amount != nil {
+			credit = amount.Round(2)
+		}
+		if !credit.IsPositive() || credit.GreaterThan(remaining) {
+			return ErrInvalidCredit
+		}
+
+		issuedAt := time.Now()
+		number, err := nextInvoiceNumberTx(tx, creditNoteSeries, issuedAt)
+		if err != nil {
+			return err
+		}
+
+		note = models.Invoice{
+			Number:                number,
+			Kind:                  models.InvoiceKindCreditNote,
+			TaskID:                invoice.TaskID,
+			EscrowID:              invoice.EscrowID,
+			CreditedInvoiceID:     &invoice.ID,
+			CreditedInvoiceNumber: invoice.Number,
+			PosterID:              invoice.PosterID,
+			TaskerID:              invoice.TaskerID,
+			IssuerName:            invoice.IssuerName,
+			IssuerAddress:         invoice.IssuerAddress,
+			IssuerTaxNumber:       invoice.IssuerTaxNumber,
+			PosterName:            invoice.PosterName,
+			TaskerName:            invoice.TaskerName,
+			TaskTitle:             invoice.TaskTitle,
+			Currency:              invoice.Currency,
+			TaxName:               invoice.TaxName,
+			TaxRate:               invoice.TaxRate,
+			Tax:                   inclusiveTax(credit, invoice.TaxRate),
+			PaymentMethod:         invoice.PaymentMethod,
+			Reason:                reason,
+			Total:                 credit,
+			IssuedAt:              issuedAt,
+		}
+		if credit.Equal(invoice.Total) {
+			note.Lines = invoice.Lines
+			note.Subtotal = invoice.Subtotal
+			note.ServiceFee = invoice.ServiceFee
+			note.Commission = invoice.Commission
+			note.Fees = invoice.Fees
+		} else {
+			ratio := credit.Div(invoice.Total)
+			note.ServiceFee = invoice.ServiceFee.Mul(ratio).Round(2)
+			note.Subtotal = credit.Sub(note.ServiceFee)
+			note.Commission = decimal.Min(invoice.Commission.Mul(ratio).Round(2), note.Subtotal)
+			note.Lines = []models.QuoteLine{{
+				Kind:        models.QuoteLineAdjustment,
+				Description: "Credit against " + invoice.Number,
+				Quantity:    decimal.NewFromInt(1),
+				UnitPrice:   note.Subtotal,
+				Amount:      note.Subtotal,
+			}}
+		}
+		note.TaskerPayout = note.Subtotal.Sub(note.Commission)
+		return tx.Create(&note).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range []uuid.UUID{note.PosterID, note.TaskerID} {
+		if _, err := Notify(s.db, s.fcm, userID, "credit_note_issued", "Credit Note Issued",
+			fmt.Sprintf("Credit note %s was issued against invoice %s for %s.", note.Number, note.CreditedInvoiceNumber, note.TaskTitle),
+			map[string]interface{}{"task_id": note.TaskID.String(), "invoice_id": note.ID.String()}); err != nil {
+			log.Printf("[Invoices] Failed to notify %s of credit note %s: %v", userID, note.Number, err)
+		}
+	}
+	return &note, nil
+}
+
+// Get returns an invoice or credit note to its poster or tasker
+func (s *InvoiceService) Get(invoiceID, userID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := s.db.First(&invoice, "id = ?", invoiceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+	if userID != invoice.PosterID && userID != invoice.TaskerID {
+		return nil, ErrNotInvoiceParty
+	}
+	return &invoice, nil
+}
+
+// TaskInvoices lists a task's invoice and credit notes, oldest first, for its
+// poster or tasker; anyone else gets none
+func (s *InvoiceService) TaskInvoices(taskID, userID uuid.UUID) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	err := s.db.Where("task_id = ? AND (poster_id = ? OR tasker_id = ?)", taskID, userID, userID).
+		Order("issued_at ASC").Find(&invoices).Error
+	return invoices, err
+}
+
+// InvoiceListQuery selects the invoices and credit notes where userID is the
+// poster or tasker; kind optionally limits it to one kind
+func InvoiceListQuery(db *gorm.DB, userID uuid.UUID, kind string) *gorm.DB {
+	query := db.Model(&models.Invoice{}).Where("(poster_id = ? OR tasker_id = ?)", userID, userID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	return query
+}
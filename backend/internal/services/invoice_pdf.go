@@ -0,0 +1,195 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/pdf"
+	"github.com/shopspring/decimal"
+)
+
+// Invoice layout, in points
+const (
+	invoiceMargin     = 50.0
+	invoiceRight      = pdf.PageWidth - invoiceMargin
+	invoiceBottom     = pdf.PageHeight - 70
+	invoiceQtyX       = 350.0
+	invoiceUnitX      = 445.0
+	invoiceBodySize   = 10.0
+	invoiceLineHeight = 14.0
+)
+
+// invoiceRenderer lays an invoice out top to bottom, starting a page whenever
+// the next block would run past the bottom margin
+type invoiceRenderer struct {
+	doc     *pdf.Document
+	invoice *models.Invoice
+	y       float64
+}
+
+// RenderInvoicePDF renders an invoice or credit note as a PDF
+func RenderInvoicePDF(invoice *models.Invoice) []byte {
+	r := &invoiceRenderer{doc: pdf.New(invoiceTitle(invoice) + " " + invoice.Number), invoice: invoice}
+	r.header()
+	r.parties()
+	r.lines()
+	r.totals()
+	r.footer()
+	return r.doc.Bytes()
+}
+
+func invoiceTitle(invoice *models.Invoice) string {
+	if invoice.Kind == models.InvoiceKindCreditNote {
+		return "Credit Note"
+	}
+	return "Tax Invoice"
+}
+
+func (r *invoiceRenderer) money(amount decimal.Decimal) string {
+	return r.invoice.Currency + " " + amount.StringFixed(2)
+}
+
+// ensure starts a new page unless height more points fit on this one
+func (r *invoiceRenderer) ensure(height float64) bool {
+	if r.y+height <= invoiceBottom {
+		return false
+	}
+	r.doc.AddPage()
+	r.y = 60
+	return true
+}
+
+func (r *invoiceRenderer) header() {
+	inv := r.invoice
+	r.doc.Text(invoiceMargin, 70, pdf.Bold, 22, strings.ToUpper(invoiceTitle(inv)))
+	r.doc.TextRight(invoiceRight, 62, pdf.Bold, 12, inv.Number)
+	r.doc.TextRight(invoiceRight, 78, pdf.Regular, invoiceBodySize, "Issued "+inv.IssuedAt.UTC().Format("2 January 2006"))
+
+	r.y = 105
+	r.doc.Text(invoiceMargin, r.y, pdf.Bold, 11, inv.IssuerName)
+	for _, line := range strings.Split(inv.IssuerAddress, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			r.y += invoiceLineHeight
+			r.doc.Text(invoiceMargin, r.y, pdf.Regular, invoiceBodySize, line)
+		}
+	}
+	if inv.IssuerTaxNumber != "" {
+		r.y += invoiceLineHeight
+		r.doc.Text(invoiceMargin, r.y, pdf.Regular, invoiceBodySize, "Tax number: "+inv.IssuerTaxNumber)
+	}
+	r.y += 20
+	r.doc.Line(invoiceMargin, r.y, invoiceRight, r.y, 0.5)
+}
+
+func (r *invoiceRenderer) parties() {
+	inv := r.invoice
+	r.y += 22
+	top := r.y
+	r.doc.Text(invoiceMargin, r.y, pdf.Bold, invoiceBodySize, "Billed to")
+	r.doc.Text(invoiceMargin, r.y+invoiceLineHeight, pdf.Regular, invoiceBodySize, inv.PosterName)
+	r.doc.Text(230, r.y, pdf.Bold, invoiceBodySize, "Service provider")
+	r.doc.Text(230, r.y+invoiceLineHeight, pdf.Regular, invoiceBodySize, inv.TaskerName)
+
+	details := [][2]string{{"Currency", inv.Currency}}
+	if inv.PaymentMethod != "" {
+		details = append(details, [2]string{"Paid by", inv.PaymentMethod})
+	}
+	if inv.CreditedInvoiceNumber != "" {
+		details = append(details, [2]string{"Credits", inv.CreditedInvoiceNumber})
+	}
+	for i, d := range details {
+		y := top + float64(i)*invoiceLineHeight
+		r.doc.Text(400, y, pdf.Bold, invoiceBodySize, d[0])
+		r.doc.TextRight(invoiceRight, y, pdf.Regular, invoiceBodySize, d[1])
+	}
+	r.y = top + float64(max(2, len(details)))*invoiceLineHeight + 10
+
+	for _, line := range pdf.Wrap(pdf.Regular, invoiceBodySize, invoiceRight-invoiceMargin, "Task: "+inv.TaskTitle) {
+		r.y += invoiceLineHeight
+		r.doc.Text(invoiceMargin, r.y, pdf.Regular, invoiceBodySize, line)
+	}
+	if inv.Reason != "" {
+		for _, line := range pdf.Wrap(pdf.Regular, invoiceBodySize, invoiceRight-invoiceMargin, "Reason: "+inv.Reason) {
+			r.y += invoiceLineHeight
+			r.doc.Text(invoiceMargin, r.y, pdf.Regular, invoiceBodySize, line)
+		}
+	}
+	r.y += 20
+}
+
+func (r *invoiceRenderer) tableHeader() {
+	r.doc.FillRect(invoiceMargin, r.y, invoiceRight-invoiceMargin, 20, 0.92)
+	r.doc.Text(invoiceMargin+6, r.y+14, pdf.Bold, invoiceBodySize, "Description")
+	r.doc.TextRight(invoiceQtyX+40, r.y+14, pdf.Bold, invoiceBodySize, "Qty")
+	r.doc.TextRight(invoiceUnitX+40, r.y+14, pdf.Bold, invoiceBodySize, "Unit price")
+	r.doc.TextRight(invoiceRight-6, r.y+14, pdf.Bold, invoiceBodySize, "Amount")
+	r.y += 20
+}
+
+func (r *invoiceRenderer) lines() {
+	r.tableHeader()
+	for _, line := range r.invoice.Lines {
+		wrapped := pdf.Wrap(pdf.Regular, invoiceBodySize, invoiceQtyX-invoiceMargin-20, line.Description)
+		if r.ensure(float64(len(wrapped))*invoiceLineHeight + 8) {
+			r.tableHeader()
+		}
+		r.y += invoiceLineHeight
+		r.doc.TextRight(invoiceQtyX+40, r.y, pdf.Regular, invoiceBodySize, line.Quantity.String())
+		r.doc.TextRight(invoiceUnitX+40, r.y, pdf.Regular, invoiceBodySize, line.UnitPrice.StringFixed(2))
+		r.doc.TextRight(invoiceRight-6, r.y, pdf.Regular, invoiceBodySize, line.Amount.StringFixed(2))
+		for i, text := range wrapped {
+			if i > 0 {
+				r.y += invoiceLineHeight
+			}
+			r.doc.Text(invoiceMargin+6, r.y, pdf.Regular, invoiceBodySize, text)
+		}
+		r.y += 6
+		r.doc.Line(invoiceMargin, r.y, invoiceRight, r.y, 0.25)
+	}
+}
+
+func (r *invoiceRenderer) totals() {
+	inv := r.invoice
+	type row struct {
+		label  string
+		amount decimal.Decimal
+		bold   bool
+	}
+	rows := []row{{label: "Subtotal", amount: inv.Subtotal}}
+	if !inv.ServiceFee.IsZero() {
+		rows = append(rows, row{label: "Service fee", amount: inv.ServiceFee})
+	}
+	total := "Total"
+	if inv.Kind == models.InvoiceKindCreditNote {
+		total = "Total credited"
+	}
+	rows = append(rows, row{label: total, amount: inv.Total, bold: true})
+	if inv.TaxRate.IsPositive() {
+		rows = append(rows, row{label: "Includes " + inv.TaxName + " at " + inv.TaxRate.String() + "%", amount: inv.Tax})
+	}
+	if !inv.Commission.IsZero() {
+		rows = append(rows,
+			row{label: "Platform commission", amount: inv.Commission},
+			row{label: "Paid to service provider", amount: inv.TaskerPayout})
+	}
+
+	r.ensure(float64(len(rows))*16 + 20)
+	r.y += 12
+	for _, row := range rows {
+		r.y += 16
+		font := pdf.Regular
+		if row.bold {
+			font = pdf.Bold
+			r.doc.Line(330, r.y-12, invoiceRight, r.y-12, 0.5)
+		}
+		r.doc.Text(330, r.y, font, invoiceBodySize, row.label)
+		r.doc.TextRight(invoiceRight-6, r.y, font, invoiceBodySize, r.money(row.amount))
+	}
+}
+
+// footer closes the last page with the immutability note
+func (r *invoiceRenderer) footer() {
+	r.doc.Text(invoiceMargin, pdf.PageHeight-40, pdf.Regular, 8,
+		"This document cannot be altered once issued; corrections are made by credit note.")
+	r.doc.TextRight(invoiceRight, pdf.PageHeight-40, pdf.Regular, 8, r.invoice.Number)
+}
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/payments"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnbalancedEntry is returned when a journal entry's debits and credits differ.
+var ErrUnbalancedEntry = errors.New("journal entry does not balance")
+
+// ledgerSchema stops journal entries and postings from being changed or deleted
+// by anything, not just the application. Statements are idempotent so they can
+// run on each startup alongside AutoMigrate.
+var ledgerSchema = []string{
+	`CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'ledger rows are immutable; post a reversing entry instead';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS journal_entries_immutable ON journal_entries`,
+	`CREATE TRIGGER journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries
+		FOR EACH ROW EXECUTE FUNCTION ledger_immutable()`,
+	`DROP TRIGGER IF EXISTS ledger_postings_immutable ON ledger_postings`,
+	`CREATE TRIGGER ledger_postings_immutable BEFORE UPDATE OR DELETE ON ledger_postings
+		FOR EACH ROW EXECUTE FUNCTION ledger_immutable()`,
+}
+
+// EnsureLedgerSchema installs the triggers that make the journal append-only.
+func EnsureLedgerSchema(db *gorm.DB) error {
+	for _, stmt := range ledgerSchema {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ledgerLeg is one side of a journal entry: positive amounts debit the account,
+// negative amounts credit it
+type ledgerLeg struct {
+	account *models.LedgerAccount
+	amount  decimal.Decimal
+}
+
+func debit(account *models.LedgerAccount, amount decimal.Decimal) ledgerLeg {
+	return ledgerLeg{account: account, amount: amount}
+}
+
+func credit(account *models.LedgerAccount, amount decimal.Decimal) ledgerLeg {
+	return ledgerLeg{account: account, amount: amount.Neg()}
+}
+
+// creditNormal reports whether a positive balance of the account kind is a credit
+func creditNormal(kind string) bool {
+	return kind != models.LedgerProviderFunds
+}
+
+// accountBalance turns the sum of an account's postings into its balance
+func accountBalance(kind string, sum decimal.Decimal) decimal.Decimal {
+	if creditNormal(kind) {
+		return sum.Neg()
+	}
+	return sum
+}
+
+// ledgerAccountTx returns the account, opening it on first use
+func ledgerAccountTx(tx *gorm.DB, kind string, userID *uuid.UUID, provider, currency string) (*models.LedgerAccount, error) {
+	code := kind + ":" + currency
+	switch {
+	case userID != nil:
+		code = kind + ":" + userID.String() + ":" + currency
+	case provider != "":
+		code = kind + ":" + provider + ":" + currency
+	}
+
+	account := models.LedgerAccount{Code: code, Kind: kind, UserID: userID, Provider: provider, Currency: currency}
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+		Create(&account).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("code = ?", code).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func walletAccountTx(tx *gorm.DB, userID uuid.UUID, currency string) (*models.LedgerAccount, error) {
+	return ledgerAccountTx(tx, models.LedgerWallet, &userID, "", currency)
+}
+
+// postEntryTx records entry with legs. Entries whose amounts are all zero are
+// skipped, and an entry whose reference was already posted is not posted again.
+func postEntryTx(tx *gorm.DB, entry *models.JournalEntry, legs ...ledgerLeg) error {
+	sum, moved := decimal.Zero, false
+	for _, leg := range legs {
+		sum = sum.Add(leg.amount)
+		moved = moved || !leg.amount.IsZero()
+	}
+	if !moved {
+		return nil
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("%w: %s is off by %s", ErrUnbalancedEntry, entry.Reference, sum.StringFixed(2))
+	}
+
+	var posted int64
+	if err := tx.Model(&models.JournalEntry{}).Where("reference = ?", entry.Reference).Count(&posted).Error; err != nil {
+		return err
+	}
+	if posted > 0 {
+		return nil
+	}
+
+	for _, leg := range legs {
+		if !leg.amount.IsZero() {
+			entry.Postings = append(entry.Postings, models.LedgerPosting{AccountID: leg.account.ID, Amount: leg.amount})
+		}
+	}
+	return tx.Create(entry).Error
+}
+
+// escrowAmount is the escrow's amount to the cent
+func escrowAmount(escrow *models.EscrowTransaction) decimal.Decimal {
+	return escrow.Amount.Round(2)
+}
+
+// escrowTotal is what the poster pays into escrow: the amount plus the service fee
+func escrowTotal(escrow *models.EscrowTransaction) decimal.Decimal {
+	return escrowAmount(escrow).Add(escrow.ServiceFee)
+}
+
+// escrowPayout is what the tasker receives on release: the amount less commission
+func escrowPayout(escrow *models.EscrowTransaction) decimal.Decimal {
+	return escrowAmount(escrow).Sub(escrow.Commission)
+}
+
+// postEscrowTx posts an entry moving amount between the escrow account and a
+// user's wallet: from the wallet into escrow when amount is positive, back out
+// when negative
+func postEscrowTx(tx *gorm.DB, escrow *models.EscrowTransaction, kind, reference string, userID uuid.UUID, amount decimal.Decimal) error {
+	wallet, err := walletAccountTx(tx, userID, escrow.Currency)
+	if err != nil {
+		return err
+	}
+	escrowAccount, err := ledgerAccountTx(tx, models.LedgerEscrow, nil, "", escrow.Currency)
+	if err != nil {
+		return err
+	}
+	return postEntryTx(tx, &models.JournalEntry{
+		Reference: reference,
+		Kind:      kind,
+		TaskID:    &escrow.TaskID,
+		EscrowID:  &escrow.ID,
+	}, debit(wallet, amount), credit(escrowAccount, amount))
+}
+
+// postEscrowOpenedTx commits the poster to the escrow: their wallet owes the
+// amount until they pay. Escrow from before the ledger is opened the first time
+// it is touched.
+func postEscrowOpenedTx(tx *gorm.DB, escrow *models.EscrowTransaction, amount decimal.Decimal) error {
+	return postEscrowTx(tx, escrow, models.JournalEscrowOpened, "escrow_opened:"+escrow.ID.String(), escrow.PosterID, amount)
+}
+
+// postEscrowAdjustedTx records a change to the escrow total, such as a metered
+// hire billed above or below its estimate
+func postEscrowAdjustedTx(tx *gorm.DB, escrow *models.EscrowTransaction, reference string, from, to decimal.Decimal) error {
+	if err := postEscrowOpenedTx(tx, escrow, from); err != nil {
+		return err
+	}
+	return postEscrowTx(tx, escrow, models.JournalEscrowAdjusted, "escrow_adjusted:"+reference, escrow.PosterID, to.Sub(from))
+}
+
+// postEscrowReturnedTx returns the escrow, service fee included, to the poster's
+// wallet when the task is reopened or cancelled; kind is escrow_refunded or
+// escrow_cancelled
+func postEscrowReturnedTx(tx *gorm.DB, escrow *models.EscrowTransaction, kind string) error {
+	total := escrowTotal(escrow)
+	if err := postEscrowOpenedTx(tx, escrow, total); err != nil {
+		return err
+	}
+	return postEscrowTx(tx, escrow, kind, kind+":"+escrow.ID.String(), escrow.PosterID, total.Neg())
+}
+
+// postEscrowReleasedTx pays the escrow out on completion: the tasker's wallet
+// gets the amount less commission and the fees account keeps the commission and
+// the poster's service fee. For cash tasks the poster paid the tasker the total
+// in person, which settles the poster's debt against the tasker, who then owes
+// the platform its fees.
+func postEscrowReleasedTx(tx *gorm.DB, escrow *models.EscrowTransaction) error {
+	total := escrowTotal(escrow)
+	if err := postEscrowOpenedTx(tx, escrow, total); err != nil {
+		return err
+	}
+
+	taskerWallet, err := walletAccountTx(tx, escrow.TaskerID, escrow.Currency)
+	if err != nil {
+		return err
+	}
+	escrowAccount, err := ledgerAccountTx(tx, models.LedgerEscrow, nil, "", escrow.Currency)
+	if err != nil {
+		return err
+	}
+	fees, err := ledgerAccountTx(tx, models.LedgerFees, nil, "", escrow.Currency)
+	if err != nil {
+		return err
+	}
+	if err := postEntryTx(tx, &models.JournalEntry{
+		Reference: "escrow_released:" + escrow.ID.String(),
+		Kind:      models.JournalEscrowReleased,
+		TaskID:    &escrow.TaskID,
+		EscrowID:  &escrow.ID,
+	}, debit(escrowAccount, total), credit(taskerWallet, escrowPayout(escrow)),
+		credit(fees, escrow.ServiceFee.Add(escrow.Commission))); err != nil {
+		return err
+	}
+	if escrow.PaymentMethod != payments.MethodCash {
+		return nil
+	}
+
+	posterWallet, err := walletAccountTx(tx, escrow.PosterID, escrow.Currency)
+	if err != nil {
+		return err
+	}
+	return postEntryTx(tx, &models.JournalEntry{
+		Reference: "cash_settled:" + escrow.ID.String(),
+		Kind:      models.JournalCashSettled,
+		TaskID:    &escrow.TaskID,
+		EscrowID:  &escrow.ID,
+	}, debit(taskerWallet, total), credit(posterWallet, total))
+}
+
+// postPaymentTx records a provider payment reaching status. Collected money
+// lands in the provider's funds and pays the payer's wallet; a queued payout or
+// refund leaves the recipient's wallet for payout clearing, and clears to the
+// provider once sent or back to the wallet if it failed. Cash moves no money
+// through the platform and is not posted.
+func postPaymentTx(tx *gorm.DB, payment *models.PaymentTransaction, status string) error {
+	if payment.Provider == payments.MethodCash {
+		return nil
+	}
+
+	wallet, err := walletAccountTx(tx, payment.UserID, payment.Currency)
+	if err != nil {
+		return err
+	}
+	funds, err := ledgerAccountTx(tx, models.LedgerProviderFunds, nil, payment.Provider, payment.Currency)
+	if err != nil {
+		return err
+	}
+	clearing, err := ledgerAccountTx(tx, models.LedgerPayoutClearing, nil, "", payment.Currency)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.JournalEntry{
+		Reference: status + ":" + payment.ID.String(),
+		TaskID:    &payment.TaskID,
+		EscrowID:  &payment.EscrowID,
+		PaymentID: &payment.ID,
+	}
+	payout := payment.Kind == models.PaymentPayout
+	var legs []ledgerLeg
+	switch {
+	case payment.Kind == models.PaymentCollection && status == models.PaymentSucceeded:
+		entry.Kind = models.JournalPaymentCollected
+		legs = []ledgerLeg{debit(funds, payment.Amount), credit(wallet, payment.Amount)}
+	case payment.Kind == models.PaymentCollection:
+		// Nothing moved
+		return nil
+	case status == models.PaymentQueued:
+		entry.Kind = pick(payout, models.JournalPayoutQueued, models.JournalRefundQueued)
+		legs = []ledgerLeg{debit(wallet, payment.Amount), credit(clearing, payment.Amount)}
+	case status == models.PaymentSucceeded:
+		entry.Kind = pick(payout, models.JournalPayoutSent, models.JournalRefundSent)
+		legs = []ledgerLeg{debit(clearing, payment.Amount), credit(funds, payment.Amount)}
+	case status == models.PaymentFailed:
+		entry.Kind = pick(payout, models.JournalPayoutFailed, models.JournalRefundFailed)
+		legs = []ledgerLeg{debit(clearing, payment.Amount), credit(wallet, payment.Amount)}
+	default:
+		return nil
+	}
+	return postEntryTx(tx, entry, legs...)
+}
+
+func pick(cond bool, yes, no string) string {
+	if cond {
+		return yes
+	}
+	return no
+}
+
+// WalletBalance is a user's wallet in one currency. A negative balance is owed
+// by the user, such as escrow they have yet to pay or commission on a cash task.
+type WalletBalance struct {
+	AccountID uuid.UUID       `json:"account_id"`
+	Currency  string          `json:"currency"`
+	Balance   decimal.Decimal `json:"balance"`
+}
+
+// WalletBalances returns the user's wallet balance in each currency they have used
+func WalletBalances(db *gorm.DB, userID uuid.UUID) ([]WalletBalance, error) {
+	balances := []WalletBalance{}
+	if err := db.Model(&models.LedgerAccount{}).
+		Select("ledger_accounts.id AS account_id, ledger_accounts.currency, -COALESCE(SUM(p.amount), 0) AS balance").
+		Joins("LEFT JOIN ledger_postings p ON p.account_id = ledger_accounts.id").
+		Where("ledger_accounts.kind = ? AND ledger_accounts.user_id = ?", models.LedgerWallet, userID).
+		Group("ledger_accounts.id, ledger_accounts.currency").
+		Order("ledger_accounts.currency").
+		Scan(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// StatementLine is one movement on a user's wallet. Amount is from the user's
+// side: positive when the platform owes them more.
+type StatementLine struct {
+	ID        uuid.UUID       `json:"id"`
+	EntryID   uuid.UUID       `json:"entry_id"`
+	Kind      string          `json:"kind"`
+	TaskID    *uuid.UUID      `json:"task_id,omitempty"`
+	TaskTitle string          `json:"task_title,omitempty"`
+	PaymentID *uuid.UUID      `json:"payment_id,omitempty"`
+	Currency  string          `json:"currency"`
+	Amount    decimal.Decimal `json:"amount"`
+	// Balance is the wallet balance after this line
+	Balance   decimal.Decimal `json:"balance"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// WalletStatementQuery selects the lines of the user's wallet statement, with
+// their running balance, as a table of StatementLine rows named "statement".
+// An empty currency covers every currency.
+func WalletStatementQuery(db *gorm.DB, userID uuid.UUID, currency string) *gorm.DB {
+	lines := db.Table("ledger_postings p").
+		Select(`p.id, p.entry_id, j.kind, j.task_id, t.title AS task_title, j.payment_id, a.currency,
+			-p.amount AS amount,
+			-SUM(p.amount) OVER (PARTITION BY p.account_id ORDER BY p.created_at, p.id) AS balance,
+			p.created_at`).
+		Joins("JOIN ledger_accounts a ON a.id = p.account_id").
+		Joins("JOIN journal_entries j ON j.id = p.entry_id").
+		Joins("LEFT JOIN tasks t ON t.id = j.task_id").
+		Where("a.kind = ? AND a.user_id = ?", models.LedgerWallet, userID)
+	if currency != "" {
+		lines = lines.Where("a.currency = ?", currency)
+	}
+	return db.Table("(?) AS statement", lines)
+}
+
+// AccountTotal is the trial balance of every account of one kind and currency
+type AccountTotal struct {
+	Kind     string          `json:"kind"`
+	Currency string          `json:"currency"`
+	Debits   decimal.Decimal `json:"debits"`
+	Credits  decimal.Decimal `json:"credits"`
+	Balance  decimal.Decimal `json:"balance"`
+}
+
+// EscrowMismatch is an escrow whose ledger balance differs from what its status
+// says it should hold
+type EscrowMismatch struct {
+	EscrowID uuid.UUID       `json:"escrow_id"`
+	TaskID   uuid.UUID       `json:"task_id"`
+	Status   string          `json:"status"`
+	Currency string          `json:"currency"`
+	Expected decimal.Decimal `json:"expected"`
+	Ledger   decimal.Decimal `json:"ledger"`
+}
+
+// ProviderCheck compares an account with the payment records behind it: payout
+// clearing against payouts and refunds still in flight, and provider funds
+// against what was collected less what was sent
+type ProviderCheck struct {
+	Account  string          `json:"account"`
+	Currency string          `json:"currency"`
+	Ledger   decimal.Decimal `json:"ledger"`
+	Expected decimal.Decimal `json:"expected"`
+	Matches  bool            `json:"matches"`
+}
+
+// ReconciliationReport checks the ledger against itself and against escrow and
+// payment records. Balanced is set when every check passes.
+type ReconciliationReport struct {
+	GeneratedAt       time.Time        `json:"generated_at"`
+	Balanced          bool             `json:"balanced"`
+	TrialBalance      []AccountTotal   `json:"trial_balance"`
+	UnbalancedEntries []uuid.UUID      `json:"unbalanced_entries"`
+	EscrowMismatches  []EscrowMismatch `json:"escrow_mismatches"`
+	Providers         []ProviderCheck  `json:"providers"`
+}
+
+// reconciliationLimit bounds the problems listed in each section of the report
+const reconciliationLimit = 100
+
+// Reconcile builds the reconciliation report
+func Reconcile(db *gorm.DB, now time.Time) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{
+		GeneratedAt:       now,
+		TrialBalance:      []AccountTotal{},
+		UnbalancedEntries: []uuid.UUID{},
+		EscrowMismatches:  []EscrowMismatch{},
+		Providers:         []ProviderCheck{},
+	}
+
+	if err := db.Table("ledger_postings p").
+		Select(`a.kind, a.currency,
+			COALESCE(SUM(CASE WHEN p.amount > 0 THEN p.amount ELSE 0 END), 0) AS debits,
+			COALESCE(SUM(CASE WHEN p.amount < 0 THEN -p.amount ELSE 0 END), 0) AS credits`).
+		Joins("JOIN ledger_accounts a ON a.id = p.account_id").
+		Group("a.kind, a.currency").
+		Order("a.currency, a.kind").
+		Scan(&report.TrialBalance).Error; err != nil {
+		return nil, err
+	}
+	totals := map[string]decimal.Decimal{}
+	for i := range report.TrialBalance {
+		total := &report.TrialBalance[i]
+		total.Balance = accountBalance(total.Kind, total.Debits.Sub(total.Credits))
+		totals[total.Currency] = totals[total.Currency].Add(total.Debits).Sub(total.Credits)
+	}
+
+	if err := db.Model(&models.LedgerPosting{}).
+		Group("entry_id").Having("SUM(amount) <> 0").
+		Limit(reconciliationLimit).
+		Pluck("entry_id", &report.UnbalancedEntries).Error; err != nil {
+		return nil, err
+	}
+
+	escrowBalances := db.Table("ledger_postings p").
+		Select("j.escrow_id, -SUM(p.amount) AS balance").
+		Joins("JOIN journal_entries j ON j.id = p.entry_id").
+		Joins("JOIN ledger_accounts a ON a.id = p.account_id").
+		Where("a.kind = ?", models.LedgerEscrow).
+		Group("j.escrow_id")
+	if err := db.Table("escrow_transactions e").
+		Select(`e.id AS escrow_id, e.task_id, e.status, e.currency,
+			CASE WHEN e.status IN ? THEN e.amount + e.service_fee ELSE 0 END AS expected,
+			COALESCE(l.balance, 0) AS ledger`, []string{models.EscrowPending, models.EscrowHeld}).
+		Joins("LEFT JOIN (?) l ON l.escrow_id = e.id", escrowBalances).
+		Where("COALESCE(l.balance, 0) <> CASE WHEN e.status IN ? THEN e.amount + e.service_fee ELSE 0 END",
+			[]string{models.EscrowPending, models.EscrowHeld}).
+		Order("e.created_at").
+		Limit(reconciliationLimit).
+		Scan(&report.EscrowMismatches).Error; err != nil {
+		return nil, err
+	}
+
+	if err := reconcileProviders(db, report); err != nil {
+		return nil, err
+	}
+
+	report.Balanced = len(report.UnbalancedEntries) == 0 && len(report.EscrowMismatches) == 0
+	for _, total := range totals {
+		report.Balanced = report.Balanced && total.IsZero()
+	}
+	for _, check := range report.Providers {
+		report.Balanced = report.Balanced && check.Matches
+	}
+	return report, nil
+}
+
+// reconcileProviders compares payout clearing and each provider's funds with the
+// payment transactions
+func reconcileProviders(db *gorm.DB, report *ReconciliationReport) error {
+	type row struct {
+		Kind     string
+		Provider string
+		Currency string
+		Amount   decimal.Decimal
+	}
+
+	var ledger []row
+	if err := db.Table("ledger_postings p").
+		Select("a.kind, a.provider, a.currency, SUM(p.amount) AS amount").
+		Joins("JOIN ledger_accounts a ON a.id = p.account_id").
+		Where("a.kind IN ?", []string{models.LedgerPayoutClearing, models.LedgerProviderFunds}).
+		Group("a.kind, a.provider, a.currency").
+		Scan(&ledger).Error; err != nil {
+		return err
+	}
+
+	// In-flight payouts and refunds sit in clearing; funds are collections less
+	// payouts and refunds that went out
+	var expected []row
+	if err := db.Model(&models.PaymentTransaction{}).
+		Select(`?::text AS kind, '' AS provider, currency, SUM(amount) AS amount`, models.LedgerPayoutClearing).
+		Where("provider <> ? AND kind IN ? AND status IN ?", payments.MethodCash,
+			[]string{models.PaymentPayout, models.PaymentRefund}, []string{models.PaymentQueued, models.PaymentPending}).
+		Group("currency").
+		Scan(&expected).Error; err != nil {
+		return err
+	}
+	var funds []row
+	if err := db.Model(&models.PaymentTransaction{}).
+		Select(`?::text AS kind, provider, currency,
+			SUM(CASE WHEN kind = ? THEN amount ELSE -amount END) AS amount`, models.LedgerProviderFunds, models.PaymentCollection).
+		Where("provider <> ? AND status = ?", payments.MethodCash, models.PaymentSucceeded).
+		Group("provider, currency").
+		Scan(&funds).Error; err != nil {
+		return err
+	}
+	expected = append(expected, funds...)
+
+	checks := map[string]*ProviderCheck{}
+	var keys []string
+	check := func(r row) *ProviderCheck {
+		account := r.Kind
+		if r.Provider != "" {
+			account += ":" + r.Provider
+		}
+		key := r.Currency + ":" + account
+		if checks[key] == nil {
+			checks[key] = &ProviderCheck{Account: account, Currency: r.Currency}
+			keys = append(keys, key)
+		}
+		return checks[key]
+	}
+	for _, r := range ledger {
+		check(r).Ledger = accountBalance(r.Kind, r.Amount)
+	}
+	for _, r := range expected {
+		check(r).Expected = r.Amount
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := checks[key]
+		c.Matches = c.Ledger.Equal(c.Expected)
+		report.Providers = append(report.Providers, *c)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// The cases below are all settled before postEntryTx touches the database.
+func TestPostEntryTxBalance(t *testing.T) {
+	funds := &models.LedgerAccount{Kind: models.LedgerProviderFunds}
+	escrow := &models.LedgerAccount{Kind: models.LedgerEscrow}
+	fees := &models.LedgerAccount{Kind: models.LedgerFees}
+	amount := func(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+	tests := []struct {
+		name    string
+		legs    []ledgerLeg
+		wantErr error
+	}{
+		{"no legs", nil, nil},
+		{"all zero", []ledgerLeg{debit(funds, decimal.Zero), credit(escrow, decimal.Zero)}, nil},
+		{"debit only", []ledgerLeg{debit(funds, amount("10.00"))}, ErrUnbalancedEntry},
+		{"short credit", []ledgerLeg{debit(funds, amount("10.00")), credit(escrow, amount("9.99"))}, ErrUnbalancedEntry},
+		{"fee left out", []ledgerLeg{debit(funds, amount("11.50")), credit(escrow, amount("10.00")), credit(fees, decimal.Zero)}, ErrUnbalancedEntry},
+	}
+	for _, tt := range tests {
+		entry := &models.JournalEntry{Reference: "test:" + tt.name}
+		err := postEntryTx(nil, entry, tt.legs...)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if len(entry.Postings) != 0 {
+			t.Errorf("%s: %d postings recorded", tt.name, len(entry.Postings))
+		}
+	}
+}
+
+func TestAccountBalance(t *testing.T) {
+	sum := decimal.RequireFromString("-25.00")
+	tests := []struct {
+		kind string
+		want string
+	}{
+		{models.LedgerWallet, "25"},
+		{models.LedgerEscrow, "25"},
+		{models.LedgerFees, "25"},
+		{models.LedgerPayoutClearing, "25"},
+		{models.LedgerProviderFunds, "-25"},
+	}
+	for _, tt := range tests {
+		if got := accountBalance(tt.kind, sum); !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("accountBalance(%s) = %s, want %s", tt.kind, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CertificateExpiryWarning is how long before it lapses a certificate shows as expiring
+const CertificateExpiryWarning = 30 * 24 * time.Hour
+
+var (
+	// ErrMaintenanceRecordNotFound is returned for a maintenance record that is not on the item.
+	ErrMaintenanceRecordNotFound = errors.New("maintenance record not found")
+	// ErrCertificateNotFound is returned for a certificate that is not on the item.
+	ErrCertificateNotFound = errors.New("certificate not found")
+	// ErrCertificateExpired is returned when a new certificate has already lapsed.
+	ErrCertificateExpired = errors.New("certificate has already expired")
+)
+
+// requiredCertificates lapse the item off the market when they expire
+var requiredCertificates = map[string]bool{
+	models.CertificateInspection: true,
+	models.CertificateInsurance:  true,
+}
+
+// ComplianceChange is the effect of RefreshCompliance on an item
+type ComplianceChange struct {
+	Item models.InventoryItem
+	From string
+	To   string
+	// Held is set when an expired required certificate took the item off the
+	// market, Released when a renewal put it back
+	Held     bool
+	Released bool
+}
+
+// AddMaintenanceRecord logs maintenance on the owner's item
+func AddMaintenanceRecord(db *gorm.DB, inventoryID, ownerID uuid.UUID, record *models.MaintenanceRecord) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockInventoryItem(tx, inventoryID)
+		if err != nil {
+			return err
+		}
+		if item.UserID != ownerID {
+			return ErrInventoryNotOwned
+		}
+
+		record.InventoryID = inventoryID
+		record.CreatedByID = ownerID
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		_, err = RefreshCompliance(tx, inventoryID, time.Now())
+		return err
+	})
+}
+
+// DeleteMaintenanceRecord removes an entry from the owner's maintenance log
+func DeleteMaintenanceRecord(db *gorm.DB, inventoryID, recordID, ownerID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockInventoryItem(tx, inventoryID)
+		if err != nil {
+			return err
+		}
+		if item.UserID != ownerID {
+			return ErrInventoryNotOwned
+		}
+
+		result := tx.Where("id = ? AND inventory_id = ?", recordID, inventoryID).Delete(&models.MaintenanceRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMaintenanceRecordNotFound
+		}
+		_, err = RefreshCompliance(tx, inventoryID, time.Now())
+		return err
+	})
+}
+
+// AddCertificate uploads a certificate for the owner's item. A renewal of an
+// expired required certificate puts an item held for compliance back on the market.
+func AddCertificate(db *gorm.DB, inventoryID, ownerID uuid.UUID, certificate *models.EquipmentCertificate) (*ComplianceChange, error) {
+	now := time.Now()
+	if !certificate.ExpiresAt.After(now) {
+		return nil, ErrCertificateExpired
+	}
+
+	var change *ComplianceChange
+	err := db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockInventoryItem(tx, inventoryID)
+		if err != nil {
+			return err
+		}
+		if item.UserID != ownerID {
+			return ErrInventoryNotOwned
+		}
+
+		certificate.InventoryID = inventoryID
+		certificate.CreatedByID = ownerID
+		if err := tx.Create(certificate).Error; err != nil {
+			return err
+		}
+		change, err = RefreshCompliance(tx, inventoryID, now)
+		return err
+	})
+	return change, err
+}
+
+// DeleteCertificate removes a certificate uploaded in error
+func DeleteCertificate(db *gorm.DB, inventoryID, certificateID, ownerID uuid.UUID) (*ComplianceChange, error) {
+	var change *ComplianceChange
+	err := db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockInventoryItem(tx, inventoryID)
+		if err != nil {
+			return err
+		}
+		if item.UserID != ownerID {
+			return ErrInventoryNotOwned
+		}
+
+		result := tx.Where("id = ? AND inventory_id = ?", certificateID, inventoryID).Delete(&models.EquipmentCertificate{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCertificateNotFound
+		}
+		change, err = RefreshCompliance(tx, inventoryID, time.Now())
+		return err
+	})
+	return change, err
+}
+
+// RefreshCompliance recomputes the item's maintenance summary and certificate
+// status as of now. The latest certificate of each kind counts. When a required
+// certificate has lapsed the item is made unavailable and held; once none has,
+// a held item is made available again.
+func RefreshCompliance(tx *gorm.DB, inventoryID uuid.UUID, now time.Time) (*ComplianceChange, error) {
+	var item models.InventoryItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, "id = ?", inventoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventoryNotFound
+		}
+		return nil, err
+	}
+
+	var certificates []models.EquipmentCertificate
+	if err := tx.Where("inventory_id = ?", inventoryID).Find(&certificates).Error; err != nil {
+		return nil, err
+	}
+	current := make(map[string]models.EquipmentCertificate)
+	for _, certificate := range certificates {
+		if latest, ok := current[certificate.Kind]; !ok || certificate.ExpiresAt.After(latest.ExpiresAt) {
+			current[certificate.Kind] = certificate
+		}
+	}
+
+	var lastInspected *time.Time
+	status := ""
+	var expiresAt *time.Time
+	lapsed := false
+	for kind, certificate := range current {
+		expiry := certificate.ExpiresAt
+		if expiresAt == nil || expiry.Before(*expiresAt) {
+			expiresAt = &expiry
+		}
+		switch {
+		case !expiry.After(now):
+			status = models.ComplianceExpired
+			lapsed = lapsed || requiredCertificates[kind]
+		case expiry.Before(now.Add(CertificateExpiryWarning)) && status != models.ComplianceExpired:
+			status = models.ComplianceExpiring
+		case status == "":
+			status = models.ComplianceValid
+		}
+		if kind == models.CertificateInspection && certificate.IssuedAt != nil {
+			issued := *certificate.IssuedAt
+			lastInspected = &issued
+		}
+	}
+
+	var inspection, service, metered models.MaintenanceRecord
+	if found, err := latestMaintenance(tx.Where("kind = ?", models.MaintenanceInspection), inventoryID, &inspection); err != nil {
+		return nil, err
+	} else if found && (lastInspected == nil || inspection.PerformedAt.After(*lastInspected)) {
+		lastInspected = &inspection.PerformedAt
+	}
+	var lastServiced *time.Time
+	if found, err := latestMaintenance(tx.Where("kind = ?", models.MaintenanceService), inventoryID, &service); err != nil {
+		return nil, err
+	} else if found {
+		lastServiced = &service.PerformedAt
+	}
+	if _, err := latestMaintenance(tx.Where("hour_meter IS NOT NULL"), inventoryID, &metered); err != nil {
+		return nil, err
+	}
+
+	change := &ComplianceChange{From: item.ComplianceStatus, To: status}
+	updates := map[string]interface{}{
+		"last_inspected_at":      lastInspected,
+		"last_serviced_at":       lastServiced,
+		"last_hour_meter":        metered.HourMeter,
+		"compliance_status":      status,
+		"certificates_expire_at": expiresAt,
+	}
+	switch {
+	case lapsed && item.IsAvailable:
+		updates["is_available"] = false
+		updates["compliance_hold"] = true
+		change.Held = true
+	case !lapsed && item.ComplianceHold:
+		updates["is_available"] = true
+		updates["compliance_hold"] = false
+		change.Released = true
+	}
+	if err := tx.Model(&item).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	change.Item = item
+	return change, nil
+}
+
+// latestMaintenance loads the most recent record matching query, reporting whether there was one
+func latestMaintenance(query *gorm.DB, inventoryID uuid.UUID, record *models.MaintenanceRecord) (bool, error) {
+	err := query.Where("inventory_id = ?", inventoryID).Order("performed_at DESC").First(record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NotifyComplianceChange tells the owner when an item's certificates start to
+// expire, lapse and take it off the market, or a renewal puts it back
+func NotifyComplianceChange(db *gorm.DB, fcm *FCMService, change *ComplianceChange) {
+	item := change.Item
+	var notificationType, title, message string
+	switch {
+	case change.Held:
+		notificationType, title = "certificate_expired", "Equipment Unavailable"
+		message = fmt.Sprintf("A required certificate for %s has expired, so it is hidden from hirers until you upload a renewal.", item.Name)
+	case change.Released:
+		notificationType, title = "certificate_renewed", "Equipment Available Again"
+		message = fmt.Sprintf("%s is available for hire again.", item.Name)
+	case change.To == models.ComplianceExpired && change.From != models.ComplianceExpired:
+		notificationType, title = "certificate_expired", "Certificate Expired"
+		message = fmt.Sprintf("A certificate for %s has expired.", item.Name)
+	case change.To == models.ComplianceExpiring && change.From != models.ComplianceExpiring:
+		notificationType, title = "certificate_expiring", "Certificate Expiring"
+		message = fmt.Sprintf("A certificate for %s expires within %d days.", item.Name, int(CertificateExpiryWarning.Hours()/24))
+	default:
+		return
+	}
+
+	if _, err := Notify(db, fcm, item.UserID, notificationType, title, message,
+		map[string]interface{}{"inventory_id": item.ID.String()}); err != nil {
+		log.Printf("[Inventory] Failed to notify owner %s about item %s compliance: %v", item.UserID, item.ID, err)
+	}
+}
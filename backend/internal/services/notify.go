@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notify persists an in-app notification, pushes it to the user's open
+// websocket connections and sends an FCM push in the background.
+// fcm may be nil, in which case only the database row is written.
+func Notify(db *gorm.DB, fcm *FCMService, userID uuid.UUID, notificationType, title, message string, data map[string]interface{}) (*models.Notification, error) {
+	dataJSON, _ := json.Marshal(data)
+	notification := models.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+		Data:    dataJSON,
+	}
+	if err := db.Create(&notification).Error; err != nil {
+		log.Printf("[Notify] Failed to create %s notification for user %s: %v", notificationType, userID, err)
+		return nil, err
+	}
+
+	if fcm == nil {
+		return &notification, nil
+	}
+
+	fcm.BroadcastNotification(&notification)
+
+	pushData := map[string]string{"type": notificationType}
+	for k, v := range data {
+		pushData[k] = fmt.Sprint(v)
+	}
+	go func() {
+		if err := fcm.SendNotification(userID, title, message, pushData); err != nil {
+			log.Printf("[Notify] Failed to send push notification to user %s: %v", userID, err)
+		}
+	}()
+
+	return &notification, nil
+}
@@ -0,0 +1,493 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrOfferNotFound is returned when the offer does not exist.
+	ErrOfferNotFound = errors.New("offer not found")
+	// ErrOfferNotPending is returned when acting on an offer that is no longer pending.
+	ErrOfferNotPending = errors.New("offer is no longer pending")
+	// ErrNotTaskPoster is returned when someone other than the poster accepts an offer.
+	ErrNotTaskPoster = errors.New("only the task poster can accept offers")
+	// ErrIdempotencyKeyReused is returned when an Idempotency-Key is replayed for a
+	// different operation than the one it was first used for.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrNotOfferTasker is returned when someone other than the offer's tasker edits or withdraws it.
+	ErrNotOfferTasker = errors.New("only the tasker who made an offer can edit or withdraw it")
+	// ErrOfferAmountLocked is returned when changing the amount of an offer the poster has viewed.
+	ErrOfferAmountLocked = errors.New("the amount cannot be changed after the poster has viewed the offer; propose a counter-offer instead")
+	// ErrStructuredOfferAmount is returned when editing the amount of a structured
+	// quote, which is priced from its quote lines.
+	ErrStructuredOfferAmount = errors.New("a structured quote's amount is priced from its lines and cannot be edited; withdraw it and quote again")
+)
+
+// OfferAcceptance is the outcome of accepting an offer.
+type OfferAcceptance struct {
+	Offer          models.Offer             `json:"offer"`
+	ConversationID uuid.UUID                `json:"conversation_id"`
+	Escrow         models.EscrowTransaction `json:"escrow"`
+	// Fees breaks down what the poster pays and the tasker receives
+	Fees *models.FeeQuote `json:"fees"`
+	// RejectedOfferIDs are the competing offers closed by this acceptance
+	RejectedOfferIDs []uuid.UUID `json:"rejected_offer_ids"`
+	// Replayed is set when the result comes from an earlier request with the same key
+	Replayed bool `json:"replayed"`
+}
+
+// rejectedOffer is a competing offer closed when another is accepted
+type rejectedOffer struct {
+	ID       uuid.UUID
+	TaskerID uuid.UUID
+}
+
+// OfferService performs offer state changes that span several tables.
+type OfferService struct {
+	db          *gorm.DB
+	fcm         *FCMService
+	hub         *Hub
+	lifecycle   *TaskLifecycle
+	eligibility *EligibilityService
+}
+
+func NewOfferService(db *gorm.DB, fcm *FCMService, hub *Hub, lifecycle *TaskLifecycle, eligibility *EligibilityService) *OfferService {
+	return &OfferService{db: db, fcm: fcm, hub: hub, lifecycle: lifecycle, eligibility: eligibility}
+}
+
+// Accept assigns the offer's task to its tasker in a single transaction: the task is
+// row-locked and must still be open, the offer must be pending, competing pending
+// offers are rejected, the quoted equipment is booked, and the conversation and the
+// escrow the poster then pays are created. Any failure rolls back every change. Notifications go out only
+// after commit.
+//
+// A non-empty idempotencyKey makes retries safe: repeating the request with the same
+// key returns the original acceptance instead of an error.
+func (s *OfferService) Accept(offerID, posterID uuid.UUID, idempotencyKey string) (*OfferAcceptance, error) {
+	scope := "offer_accept:" + offerID.String()
+	if result, err := s.replay(s.db, posterID, idempotencyKey, scope); result != nil || err != nil {
+		return result, err
+	}
+
+	var (
+		result     *OfferAcceptance
+		rejected   []rejectedOffer
+		transition *TaskTransition
+		task       *models.Task
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var offer models.Offer
+		if err := tx.Select("task_id").First(&offer, "id = ?", offerID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOfferNotFound
+			}
+			return err
+		}
+
+		// The task lock serialises every acceptance for the task
+		var err error
+		task, err = LockTask(tx, offer.TaskID)
+		if err != nil {
+			return err
+		}
+
+		// A concurrent request with the same key may have committed while we waited
+		if replayed, err := s.replay(tx, posterID, idempotencyKey, scope); replayed != nil || err != nil {
+			result = replayed
+			return err
+		}
+
+		if task.PosterID != posterID {
+			return ErrNotTaskPoster
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, "id = ?", offerID).Error; err != nil {
+			return err
+		}
+		if offer.Status != models.OfferStatusPending {
+			return ErrOfferNotPending
+		}
+
+		assigned, err := s.assignTx(tx, task, &offer, posterID)
+		if err != nil {
+			return err
+		}
+		transition, rejected = assigned.transition, assigned.rejected
+
+		if idempotencyKey != "" {
+			key := models.IdempotencyKey{UserID: posterID, Key: idempotencyKey, Scope: scope, ResourceID: offer.ID}
+			if err := tx.Create(&key).Error; err != nil {
+				return err
+			}
+		}
+
+		result = assigned.acceptance(&offer)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Replayed {
+		return result, nil
+	}
+
+	// The offer_accepted notifications below cover the assignment
+	transition.SkipNotify = true
+	s.lifecycle.Publish(transition)
+	s.publishAcceptance(task, result, rejected)
+	return result, nil
+}
+
+// assignment is everything assignTx changed when accepting an offer
+type assignment struct {
+	transition   *TaskTransition
+	rejected     []rejectedOffer
+	booking      *models.EquipmentBooking
+	conversation models.Conversation
+	escrow       models.EscrowTransaction
+}
+
+func (a *assignment) acceptance(offer *models.Offer) *OfferAcceptance {
+	result := &OfferAcceptance{
+		Offer:            *offer,
+		ConversationID:   a.conversation.ID,
+		Escrow:           a.escrow,
+		Fees:             offer.Fees,
+		RejectedOfferIDs: make([]uuid.UUID, len(a.rejected)),
+	}
+	for i, r := range a.rejected {
+		result.RejectedOfferIDs[i] = r.ID
+	}
+	return result
+}
+
+// assignTx accepts a pending offer on a task locked by tx: the task moves to
+// assigned, the quoted equipment is booked, competing offers and negotiations are
+// closed, and the conversation and escrow awaiting the poster's payment are
+// created. The caller publishes the returned transition after commit.
+func (s *OfferService) assignTx(tx *gorm.DB, task *models.Task, offer *models.Offer, posterID uuid.UUID) (*assignment, error) {
+	var (
+		a   assignment
+		err error
+	)
+
+	// Assign the task through the lifecycle; this fails unless the task is still open
+	if err := tx.Model(task).Update("accepted_offer_id", offer.ID).Error; err != nil {
+		return nil, err
+	}
+	task.AcceptedOfferID = &offer.ID
+	a.transition, err = s.lifecycle.TransitionTx(tx, task, models.TaskStatusAssigned, &posterID, "")
+	if err != nil {
+		return nil, err
+	}
+	if a.booking, err = BookEquipmentTx(tx, task, offer); err != nil {
+		return nil, err
+	}
+
+	offer.Status = models.OfferStatusAccepted
+	if err := tx.Model(offer).Update("status", offer.Status).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.Offer{}).
+		Select("id", "tasker_id").
+		Where("task_id = ? AND id <> ? AND status = ?", task.ID, offer.ID, models.OfferStatusPending).
+		Find(&a.rejected).Error; err != nil {
+		return nil, err
+	}
+	if len(a.rejected) > 0 {
+		if err := tx.Model(&models.Offer{}).
+			Where("task_id = ? AND id <> ? AND status = ?", task.ID, offer.ID, models.OfferStatusPending).
+			Update("status", models.OfferStatusRejected).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Negotiations on the task end with the acceptance
+	if err := tx.Model(&models.OfferCounter{}).
+		Where("status = ? AND offer_id IN (?)", models.OfferCounterPending,
+			tx.Model(&models.Offer{}).Select("id").Where("task_id = ?", task.ID)).
+		Update("status", models.OfferCounterSuperseded).Error; err != nil {
+		return nil, err
+	}
+
+	a.conversation = models.Conversation{TaskID: &task.ID}
+	if err := tx.Create(&a.conversation).Error; err != nil {
+		return nil, err
+	}
+	participants := []models.ConversationParticipant{
+		{ConversationID: a.conversation.ID, UserID: posterID},
+		{ConversationID: a.conversation.ID, UserID: offer.TaskerID},
+	}
+	if err := tx.Create(&participants).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(task).Update("conversation_id", a.conversation.ID).Error; err != nil {
+		return nil, err
+	}
+	task.ConversationID = &a.conversation.ID
+
+	// The escrow is priced with the fee rules in force now and keeps that price
+	fees, err := QuoteFees(tx, task, offer.Amount, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	offer.Fees = fees
+	a.escrow = models.EscrowTransaction{
+		TaskID:   task.ID,
+		OfferID:  offer.ID,
+		PosterID: posterID,
+		TaskerID: offer.TaskerID,
+		Amount:   offer.Amount,
+		Currency: offer.Currency,
+		Status:   models.EscrowPending,
+	}
+	ApplyEscrowFees(&a.escrow, fees)
+	if err := tx.Create(&a.escrow).Error; err != nil {
+		return nil, err
+	}
+	if err := postEscrowOpenedTx(tx, &a.escrow, escrowTotal(&a.escrow)); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// replay returns the earlier acceptance recorded under key, if any
+func (s *OfferService) replay(db *gorm.DB, userID uuid.UUID, key, scope string) (*OfferAcceptance, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	var record models.IdempotencyKey
+	err := db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.Scope != scope {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	result := &OfferAcceptance{Replayed: true, RejectedOfferIDs: []uuid.UUID{}}
+	if err := db.First(&result.Offer, "id = ?", record.ResourceID).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("offer_id = ?", record.ResourceID).Order("created_at DESC").First(&result.Escrow).Error; err != nil {
+		return nil, err
+	}
+	result.Fees = EscrowFeeQuote(&result.Escrow)
+	result.Offer.Fees = result.Fees
+	var task models.Task
+	if err := db.Select("conversation_id").First(&task, "id = ?", result.Offer.TaskID).Error; err != nil {
+		return nil, err
+	}
+	if task.ConversationID != nil {
+		result.ConversationID = *task.ConversationID
+	}
+	return result, nil
+}
+
+// publishAcceptance notifies the accepted tasker, the poster and every tasker whose
+// offer was rejected, and broadcasts the outcome on the task's room
+func (s *OfferService) publishAcceptance(task *models.Task, result *OfferAcceptance, rejected []rejectedOffer) {
+	offer := result.Offer
+	taskID := task.ID.String()
+	conversationID := result.ConversationID.String()
+
+	if _, err := Notify(s.db, s.fcm, offer.TaskerID, "offer_accepted", "Offer Accepted!",
+		"Your offer for "+task.Title+" has been accepted.",
+		map[string]interface{}{"task_id": taskID, "conversation_id": conversationID}); err != nil {
+		log.Printf("[Offers] Failed to notify tasker %s of accepted offer %s: %v", offer.TaskerID, offer.ID, err)
+	}
+
+	if _, err := Notify(s.db, s.fcm, task.PosterID, "offer_accepted_by_you", "Offer Accepted",
+		fmt.Sprintf("You accepted an offer for %s. Your task is now assigned.", task.Title),
+		map[string]interface{}{"task_id": taskID, "conversation_id": conversationID, "amount": offer.Amount}); err != nil {
+		log.Printf("[Offers] Failed to notify poster %s of accepted offer %s: %v", task.PosterID, offer.ID, err)
+	}
+
+	for _, r := range rejected {
+		if _, err := Notify(s.db, s.fcm, r.TaskerID, "offer_rejected", "Offer Not Selected",
+			fmt.Sprintf("Another offer was accepted for %s.", task.Title),
+			map[string]interface{}{"task_id": taskID, "offer_id": r.ID.String()}); err != nil {
+			log.Printf("[Offers] Failed to notify tasker %s of rejected offer %s: %v", r.TaskerID, r.ID, err)
+		}
+	}
+
+	if s.hub != nil {
+		s.hub.BroadcastToRoom("task_updates:"+taskID, map[string]interface{}{
+			"type":               "offer_accepted",
+			"task_id":            task.ID,
+			"offer_id":           offer.ID,
+			"rejected_offer_ids": result.RejectedOfferIDs,
+		})
+	}
+}
+
+// OfferEdit holds the fields a tasker may change on their own offer; nil fields are kept.
+type OfferEdit struct {
+	Amount            *decimal.Decimal
+	Description       *string
+	EstimatedDuration *string
+	Availability      *string
+}
+
+// Edit changes the whitelisted terms of a pending offer (its tasker only) and records
+// a revision. Once the poster has viewed the offer its amount is locked, and any
+// other edit sets EditedAt so clients can flag it.
+func (s *OfferService) Edit(offerID, taskerID uuid.UUID, edit OfferEdit) (*models.Offer, error) {
+	var (
+		task     *models.Task
+		offer    *models.Offer
+		changed  bool
+		previous *decimal.Decimal
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, offer, err = lockNegotiation(tx, offerID, taskerID)
+		if err != nil {
+			return err
+		}
+		if offer.TaskerID != taskerID {
+			return ErrNotOfferTasker
+		}
+
+		updates := map[string]interface{}{}
+		if edit.Amount != nil && !edit.Amount.Equal(offer.Amount) {
+			if offer.ViewedByPosterAt != nil {
+				return ErrOfferAmountLocked
+			}
+			if len(offer.QuoteLines) > 0 {
+				return ErrStructuredOfferAmount
+			}
+			was := offer.Amount
+			previous = &was
+			offer.PreviousAmount = previous
+			offer.Amount = edit.Amount.Round(2)
+			updates["previous_amount"] = was
+			updates["amount"] = offer.Amount
+		}
+		if edit.Description != nil && *edit.Description != offer.Description {
+			offer.Description = *edit.Description
+			updates["description"] = offer.Description
+		}
+		if edit.EstimatedDuration != nil && *edit.EstimatedDuration != offer.EstimatedDuration {
+			offer.EstimatedDuration = *edit.EstimatedDuration
+			updates["estimated_duration"] = offer.EstimatedDuration
+		}
+		if edit.Availability != nil && *edit.Availability != offer.Availability {
+			offer.Availability = *edit.Availability
+			updates["availability"] = offer.Availability
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		changed = true
+
+		// Offers created before revisions were tracked get their original terms recorded first
+		if err := ensureInitialRevision(tx, offer); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		offer.EditedAt = &now
+		offer.Version++
+		updates["edited_at"] = now
+		updates["version"] = offer.Version
+		if err := tx.Model(offer).Updates(updates).Error; err != nil {
+			return err
+		}
+		return RecordOfferRevision(tx, offer, models.OfferRevisionEdit, &taskerID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return offer, nil
+	}
+
+	message := fmt.Sprintf("An offer for %s was edited.", task.Title)
+	if previous != nil {
+		message = fmt.Sprintf("An offer for %s was edited to %s (was %s).", task.Title, offer.AmountMoney(), money.New(*previous, offer.Currency))
+	}
+	data := map[string]interface{}{"task_id": task.ID.String(), "offer_id": offer.ID.String()}
+	if _, err := Notify(s.db, s.fcm, task.PosterID, "offer_edited", "Offer Updated", message, data); err != nil {
+		log.Printf("[Offers] Failed to notify poster %s of edited offer %s: %v", task.PosterID, offer.ID, err)
+	}
+	if s.hub != nil {
+		s.hub.BroadcastToRoom("task_updates:"+task.ID.String(), map[string]interface{}{
+			"type":  "offer_updated",
+			"offer": offer,
+		})
+	}
+	return offer, nil
+}
+
+// Withdraw withdraws a pending offer on an open task (its tasker only) under the
+// task lock, so it cannot race an acceptance, and supersedes its pending counters.
+func (s *OfferService) Withdraw(offerID, taskerID uuid.UUID) (*models.Offer, error) {
+	var (
+		task  *models.Task
+		offer *models.Offer
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, offer, err = lockNegotiation(tx, offerID, taskerID)
+		if err != nil {
+			return err
+		}
+		if offer.TaskerID != taskerID {
+			return ErrNotOfferTasker
+		}
+
+		if err := tx.Model(&models.OfferCounter{}).
+			Where("offer_id = ? AND status = ?", offer.ID, models.OfferCounterPending).
+			Update("status", models.OfferCounterSuperseded).Error; err != nil {
+			return err
+		}
+		offer.Status = models.OfferStatusWithdrawn
+		return tx.Model(offer).Update("status", offer.Status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.hub != nil {
+		s.hub.BroadcastToRoom("task_updates:"+task.ID.String(), map[string]interface{}{
+			"type":  "offer_updated",
+			"offer": offer,
+		})
+	}
+	return offer, nil
+}
+
+// MarkViewed records that the task's poster has seen the offer. Only the first view
+// is kept; views by anyone else are ignored.
+func (s *OfferService) MarkViewed(offer *models.Offer, viewerID uuid.UUID) error {
+	if offer.ViewedByPosterAt != nil || offer.Task == nil || offer.Task.PosterID != viewerID {
+		return nil
+	}
+	now := time.Now()
+	result := s.db.Model(&models.Offer{}).
+		Where("id = ? AND viewed_by_poster_at IS NULL", offer.ID).
+		Update("viewed_by_poster_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		offer.ViewedByPosterAt = &now
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotOfferParty is returned when someone other than the poster or tasker negotiates an offer.
+	ErrNotOfferParty = errors.New("only the task poster and the offer's tasker can negotiate it")
+	// ErrTaskNotOpen is returned when negotiating an offer on a task that is no longer open.
+	ErrTaskNotOpen = errors.New("task is no longer open for offers")
+	// ErrCounterNotFound is returned when the counter-offer does not exist on the offer.
+	ErrCounterNotFound = errors.New("counter-offer not found")
+	// ErrCounterNotPending is returned when responding to a counter-offer that was already settled.
+	ErrCounterNotPending = errors.New("counter-offer is no longer pending")
+	// ErrOwnCounter is returned when the proposer tries to respond to their own counter-offer.
+	ErrOwnCounter = errors.New("only the other party can respond to a counter-offer")
+	// ErrEmptyCounter is returned for a counter-offer that changes nothing.
+	ErrEmptyCounter = errors.New("a counter-offer must change the amount, duration or availability")
+)
+
+// CounterProposal holds the terms a counter-offer would change; nil fields are kept.
+type CounterProposal struct {
+	Amount            *decimal.Decimal
+	EstimatedDuration *string
+	Availability      *string
+	Message           string
+}
+
+// ProposeCounter records a counter-offer by the poster or tasker of a pending offer,
+// superseding any counter still awaiting a response.
+func (s *OfferService) ProposeCounter(offerID, userID uuid.UUID, proposal CounterProposal) (*models.OfferCounter, error) {
+	if proposal.Amount == nil && proposal.EstimatedDuration == nil && proposal.Availability == nil {
+		return nil, ErrEmptyCounter
+	}
+
+	var (
+		task    *models.Task
+		offer   *models.Offer
+		counter models.OfferCounter
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, offer, err = lockNegotiation(tx, offerID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.OfferCounter{}).
+			Where("offer_id = ? AND status = ?", offer.ID, models.OfferCounterPending).
+			Update("status", models.OfferCounterSuperseded).Error; err != nil {
+			return err
+		}
+
+		counter = models.OfferCounter{
+			OfferID:           offer.ID,
+			ProposedByID:      userID,
+			Amount:            proposal.Amount,
+			EstimatedDuration: proposal.EstimatedDuration,
+			Availability:      proposal.Availability,
+			Message:           proposal.Message,
+			Status:            models.OfferCounterPending,
+		}
+		return tx.Create(&counter).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	title := "New Counter-Offer"
+	message := fmt.Sprintf("You received a counter-offer for %s", task.Title)
+	if counter.Amount != nil {
+		message = fmt.Sprintf("You received a counter-offer of %s for %s", money.New(*counter.Amount, offer.Currency), task.Title)
+	}
+	s.publishCounter(task, offer, &counter, "offer_counter_proposed", otherParty(task, offer, userID), "counter_offer_received", title, message)
+	return &counter, nil
+}
+
+// RespondToCounter accepts or declines a pending counter-offer. Only the party who
+// did not propose it may respond. Accepting applies the counter's terms to the
+// offer, bumps its version and records a revision.
+func (s *OfferService) RespondToCounter(offerID, counterID, userID uuid.UUID, accept bool) (*models.OfferCounter, *models.Offer, error) {
+	var (
+		task    *models.Task
+		offer   *models.Offer
+		counter models.OfferCounter
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, offer, err = lockNegotiation(tx, offerID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&counter, "id = ? AND offer_id = ?", counterID, offer.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCounterNotFound
+			}
+			return err
+		}
+		if counter.Status != models.OfferCounterPending {
+			return ErrCounterNotPending
+		}
+		if counter.ProposedByID == userID {
+			return ErrOwnCounter
+		}
+
+		now := time.Now()
+		counter.RespondedAt = &now
+		counter.Status = models.OfferCounterDeclined
+		if accept {
+			counter.Status = models.OfferCounterAccepted
+		}
+		if err := tx.Model(&counter).Updates(map[string]interface{}{
+			"status":       counter.Status,
+			"responded_at": counter.RespondedAt,
+		}).Error; err != nil {
+			return err
+		}
+		if !accept {
+			return nil
+		}
+
+		// Offers created before revisions were tracked get their original terms recorded first
+		if err := ensureInitialRevision(tx, offer); err != nil {
+			return err
+		}
+
+		if counter.Amount != nil {
+			offer.Amount = *counter.Amount
+		}
+		if counter.EstimatedDuration != nil {
+			offer.EstimatedDuration = *counter.EstimatedDuration
+		}
+		if counter.Availability != nil {
+			offer.Availability = *counter.Availability
+		}
+		offer.Version++
+		if err := tx.Model(offer).Updates(map[string]interface{}{
+			"amount":             offer.Amount,
+			"estimated_duration": offer.EstimatedDuration,
+			"availability":       offer.Availability,
+			"version":            offer.Version,
+		}).Error; err != nil {
+			return err
+		}
+		return RecordOfferRevision(tx, offer, models.OfferRevisionCounter, &counter.ProposedByID, &counter.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if accept {
+		s.publishCounter(task, offer, &counter, "offer_counter_accepted", counter.ProposedByID, "counter_offer_accepted",
+			"Counter-Offer Accepted", fmt.Sprintf("Your counter-offer for %s was accepted.", task.Title))
+	} else {
+		s.publishCounter(task, offer, &counter, "offer_counter_declined", counter.ProposedByID, "counter_offer_declined",
+			"Counter-Offer Declined", fmt.Sprintf("Your counter-offer for %s was declined.", task.Title))
+	}
+	return &counter, offer, nil
+}
+
+// RecordOfferRevision snapshots the offer's current terms as its current version.
+func RecordOfferRevision(tx *gorm.DB, offer *models.Offer, source string, changedByID, counterID *uuid.UUID) error {
+	revision := models.OfferRevision{
+		OfferID:           offer.ID,
+		Version:           max(offer.Version, 1),
+		Amount:            offer.Amount,
+		EstimatedDuration: offer.EstimatedDuration,
+		Availability:      offer.Availability,
+		Source:            source,
+		CounterID:         counterID,
+		ChangedByID:       changedByID,
+	}
+	return tx.Create(&revision).Error
+}
+
+// ensureInitialRevision records the offer's current terms as its first revision
+// when it has none yet
+func ensureInitialRevision(tx *gorm.DB, offer *models.Offer) error {
+	var revisions int64
+	if err := tx.Model(&models.OfferRevision{}).Where("offer_id = ?", offer.ID).Count(&revisions).Error; err != nil {
+		return err
+	}
+	if revisions > 0 {
+		return nil
+	}
+	return RecordOfferRevision(tx, offer, models.OfferRevisionCreated, &offer.TaskerID, nil)
+}
+
+// lockNegotiation locks the offer's task and then the offer, in the same order as
+// Accept, and checks the offer can still be negotiated by userID
+func lockNegotiation(tx *gorm.DB, offerID, userID uuid.UUID) (*models.Task, *models.Offer, error) {
+	var offer models.Offer
+	if err := tx.Select("task_id").First(&offer, "id = ?", offerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrOfferNotFound
+		}
+		return nil, nil, err
+	}
+
+	task, err := LockTask(tx, offer.TaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, "id = ?", offerID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if task.PosterID != userID && offer.TaskerID != userID {
+		return nil, nil, ErrNotOfferParty
+	}
+	if offer.Status != models.OfferStatusPending {
+		return nil, nil, ErrOfferNotPending
+	}
+	if task.Status != models.TaskStatusOpen {
+		return nil, nil, ErrTaskNotOpen
+	}
+	return task, &offer, nil
+}
+
+// otherParty returns the poster when the tasker acts and vice versa
+func otherParty(task *models.Task, offer *models.Offer, userID uuid.UUID) uuid.UUID {
+	if userID == task.PosterID {
+		return offer.TaskerID
+	}
+	return task.PosterID
+}
+
+// publishCounter notifies the recipient of a negotiation step and broadcasts it on the task's room
+func (s *OfferService) publishCounter(task *models.Task, offer *models.Offer, counter *models.OfferCounter, event string, recipient uuid.UUID, notificationType, title, message string) {
+	data := map[string]interface{}{
+		"task_id":    task.ID.String(),
+		"offer_id":   offer.ID.String(),
+		"counter_id": counter.ID.String(),
+	}
+	if _, err := Notify(s.db, s.fcm, recipient, notificationType, title, message, data); err != nil {
+		log.Printf("[Offers] Failed to notify user %s of counter-offer %s: %v", recipient, counter.ID, err)
+	}
+
+	if s.hub != nil {
+		s.hub.BroadcastToRoom("task_updates:"+task.ID.String(), map[string]interface{}{
+			"type":    event,
+			"task_id": task.ID,
+			"offer":   offer,
+			"counter": counter,
+		})
+	}
+}
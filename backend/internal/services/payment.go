@@ -0,0 +1,556 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/airmassxpress/backend/internal/payments"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paymentPollAfter is how long a pending payment waits for its webhook before
+// the provider is polled
+const paymentPollAfter = 2 * time.Minute
+
+var (
+	// ErrEscrowNotPayable is returned when paying for a task whose escrow is not awaiting payment.
+	ErrEscrowNotPayable = errors.New("task has no escrow awaiting payment")
+	// ErrPaymentInProgress is returned when paying while an earlier payment is still pending.
+	ErrPaymentInProgress = errors.New("a payment for this task is already in progress")
+	// ErrPaymentNotFound is returned for an unknown payment or webhook reference.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrNotTaskPayer is returned when someone other than the poster pays for a task.
+	ErrNotTaskPayer = errors.New("only the task poster can pay for it")
+	// ErrNotPaymentParty is returned when someone other than the poster or tasker views a payment.
+	ErrNotPaymentParty = errors.New("only the poster and tasker can view this payment")
+	// ErrPayoutNumberRequired is returned when releasing collected escrow to a tasker
+	// with no valid EcoCash number to pay out to.
+	ErrPayoutNumberRequired = errors.New("add a valid EcoCash number to your tasker profile to receive the payout")
+)
+
+// PaymentService moves escrow money through the payment providers. The poster
+// pays the escrow after acceptance; completion pays it out to the tasker and a
+// cancellation refunds it. Outcomes arrive on the webhook or by polling.
+type PaymentService struct {
+	db        *gorm.DB
+	fcm       *FCMService
+	hub       *Hub
+	providers *payments.Registry
+}
+
+func NewPaymentService(db *gorm.DB, fcm *FCMService, hub *Hub, providers *payments.Registry) *PaymentService {
+	return &PaymentService{db: db, fcm: fcm, hub: hub, providers: providers}
+}
+
+// Methods lists the payment methods a poster can choose
+func (s *PaymentService) Methods() []string {
+	return s.providers.Methods()
+}
+
+// Pay collects the task's pending escrow from the poster with method. Mobile
+// money stays pending until the poster approves the prompt on their phone; cash
+// is agreed at once and paid in person.
+func (s *PaymentService) Pay(ctx context.Context, taskID, posterID uuid.UUID, method, phone string) (*models.PaymentTransaction, error) {
+	provider, err := s.providers.Get(method)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment models.PaymentTransaction
+	var task *models.Task
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = LockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if task.PosterID != posterID {
+			return ErrNotTaskPayer
+		}
+
+		var escrow models.EscrowTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("task_id = ? AND status = ?", taskID, models.EscrowPending).
+			First(&escrow).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEscrowNotPayable
+			}
+			return err
+		}
+
+		var inFlight int64
+		if err := tx.Model(&models.PaymentTransaction{}).
+			Where("escrow_id = ? AND kind = ? AND status IN ?", escrow.ID, models.PaymentCollection,
+				[]string{models.PaymentQueued, models.PaymentPending}).
+			Count(&inFlight).Error; err != nil {
+			return err
+		}
+		if inFlight > 0 {
+			return ErrPaymentInProgress
+		}
+
+		payment = models.PaymentTransaction{
+			EscrowID:  escrow.ID,
+			TaskID:    taskID,
+			UserID:    posterID,
+			Kind:      models.PaymentCollection,
+			Provider:  method,
+			Reference: newPaymentReference(models.PaymentCollection),
+			Amount:    escrowTotal(&escrow),
+			Currency:  escrow.Currency,
+			Phone:     phone,
+			Status:    models.PaymentQueued,
+		}
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := provider.Collect(ctx, s.request(&payment, "Payment for "+task.Title))
+	if err != nil {
+		s.fail(&payment, err)
+		return &payment, err
+	}
+	return s.settle(method, payment.Reference, result)
+}
+
+// HandleWebhook applies a provider callback whose signature has been verified
+func (s *PaymentService) HandleWebhook(method string, body []byte) (*models.PaymentTransaction, error) {
+	provider, err := s.providers.Get(method)
+	if err != nil {
+		return nil, err
+	}
+	event, err := provider.ParseWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+	return s.settle(method, event.Reference, &payments.Result{ProviderRef: event.ProviderRef, Status: event.Status, Message: event.Message})
+}
+
+// Refresh polls the provider for a pending payment the poster or tasker is waiting on
+func (s *PaymentService) Refresh(ctx context.Context, paymentID, userID uuid.UUID) (*models.PaymentTransaction, error) {
+	var payment models.PaymentTransaction
+	if err := s.db.First(&payment, "id = ?", paymentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	var escrow models.EscrowTransaction
+	if err := s.db.First(&escrow, "id = ?", payment.EscrowID).Error; err != nil {
+		return nil, err
+	}
+	if userID != escrow.PosterID && userID != escrow.TaskerID {
+		return nil, ErrNotPaymentParty
+	}
+	if payment.Status != models.PaymentPending {
+		return &payment, nil
+	}
+	return s.poll(ctx, &payment)
+}
+
+// TaskPayments lists the payments for a task, oldest first, with its escrow. Only
+// the poster and assigned tasker can see them.
+func (s *PaymentService) TaskPayments(taskID, userID uuid.UUID) (*models.EscrowTransaction, []models.PaymentTransaction, error) {
+	var escrow models.EscrowTransaction
+	if err := s.db.Where("task_id = ?", taskID).Order("created_at DESC").First(&escrow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+	if userID != escrow.PosterID && userID != escrow.TaskerID {
+		return nil, nil, ErrNotPaymentParty
+	}
+
+	var list []models.PaymentTransaction
+	if err := s.db.Where("task_id = ?", taskID).Order("created_at ASC").Find(&list).Error; err != nil {
+		return nil, nil, err
+	}
+	return &escrow, list, nil
+}
+
+// ProcessQueued sends queued payouts and refunds to their providers and polls
+// payments left pending past paymentPollAfter. It returns how many it acted on.
+func (s *PaymentService) ProcessQueued(ctx context.Context, now time.Time, limit int) (int, error) {
+	var queued []models.PaymentTransaction
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND kind IN ?", models.PaymentQueued, []string{models.PaymentPayout, models.PaymentRefund}).
+		Order("created_at ASC").Limit(limit).
+		Find(&queued).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range queued {
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+		if _, err := s.send(ctx, &queued[i]); err != nil {
+			log.Printf("[Payments] Failed to send %s %s: %v", queued[i].Kind, queued[i].Reference, err)
+		}
+		processed++
+	}
+
+	var pending []models.PaymentTransaction
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", models.PaymentPending, now.Add(-paymentPollAfter)).
+		Order("updated_at ASC").Limit(limit).
+		Find(&pending).Error; err != nil {
+		return processed, err
+	}
+	for i := range pending {
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+		if _, err := s.poll(ctx, &pending[i]); err != nil {
+			log.Printf("[Payments] Failed to poll %s %s: %v", pending[i].Kind, pending[i].Reference, err)
+			continue
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// send submits a queued payout or refund
+func (s *PaymentService) send(ctx context.Context, payment *models.PaymentTransaction) (*models.PaymentTransaction, error) {
+	provider, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		s.fail(payment, err)
+		return payment, err
+	}
+
+	var result *payments.Result
+	if payment.Kind == models.PaymentPayout {
+		result, err = provider.Payout(ctx, s.request(payment, "Payout"))
+	} else {
+		result, err = provider.Refund(ctx, s.request(payment, "Refund"))
+	}
+	if err != nil {
+		s.fail(payment, err)
+		return payment, err
+	}
+	return s.settle(payment.Provider, payment.Reference, result)
+}
+
+// poll asks the provider how a pending payment ended
+func (s *PaymentService) poll(ctx context.Context, payment *models.PaymentTransaction) (*models.PaymentTransaction, error) {
+	provider, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	result, err := provider.Status(ctx, payment.ProviderRef)
+	if err != nil {
+		return nil, err
+	}
+	return s.settle(payment.Provider, payment.Reference, result)
+}
+
+func (s *PaymentService) request(payment *models.PaymentTransaction, description string) payments.Request {
+	req := payments.Request{
+		Reference:   payment.Reference,
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Phone:       payment.Phone,
+		Description: description,
+	}
+	if payment.Kind == models.PaymentRefund {
+		// Refunds name the collection they return
+		var collection models.PaymentTransaction
+		if err := s.db.Select("provider_ref").
+			Where("escrow_id = ? AND kind = ? AND status = ?", payment.EscrowID, models.PaymentCollection, models.PaymentSucceeded).
+			First(&collection).Error; err == nil {
+			req.ProviderRef = collection.ProviderRef
+		}
+	}
+	return req
+}
+
+// fail records that the provider rejected a request outright
+func (s *PaymentService) fail(payment *models.PaymentTransaction, cause error) {
+	if _, err := s.settle(payment.Provider, payment.Reference, &payments.Result{Status: payments.StatusFailed, Message: cause.Error()}); err != nil {
+		log.Printf("[Payments] Failed to record failure of %s: %v", payment.Reference, err)
+	}
+	payment.Status, payment.FailureReason = models.PaymentFailed, cause.Error()
+}
+
+// settle applies a provider outcome to the payment with reference. Outcomes for
+// payments that already succeeded or failed are ignored, so webhooks and polls
+// may repeat. A successful collection moves the escrow from pending to held; one
+// that lands after the task was cancelled is refunded.
+func (s *PaymentService) settle(provider, reference string, result *payments.Result) (*models.PaymentTransaction, error) {
+	var payment models.PaymentTransaction
+	var escrow models.EscrowTransaction
+	changed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ? AND provider = ?", reference, provider).
+			First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+		if payment.Status == models.PaymentSucceeded || payment.Status == models.PaymentFailed {
+			return nil
+		}
+
+		updates := map[string]interface{}{}
+		if result.ProviderRef != "" {
+			updates["provider_ref"] = result.ProviderRef
+		}
+		switch result.Status {
+		case payments.StatusSucceeded:
+			updates["status"] = models.PaymentSucceeded
+		case payments.StatusFailed:
+			updates["status"] = models.PaymentFailed
+			updates["failure_reason"] = result.Message
+		default:
+			updates["status"] = models.PaymentPending
+		}
+		if updates["status"] != models.PaymentPending {
+			updates["completed_at"] = time.Now()
+			changed = true
+		}
+		if err := tx.Model(&payment).Updates(updates).Error; err != nil {
+			return err
+		}
+		if changed {
+			if err := postPaymentTx(tx, &payment, payment.Status); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&escrow, "id = ?", payment.EscrowID).Error; err != nil {
+			return err
+		}
+		if payment.Kind != models.PaymentCollection || payment.Status != models.PaymentSucceeded {
+			return nil
+		}
+		if escrow.Status != models.EscrowPending {
+			// The task was reopened or cancelled while the poster was paying
+			return queuePaymentTx(tx, &payment, models.PaymentRefund, payment.UserID, payment.Phone, payment.Amount)
+		}
+		escrow.Status, escrow.PaymentMethod = models.EscrowHeld, payment.Provider
+		return tx.Model(&escrow).Updates(map[string]interface{}{
+			"status":         escrow.Status,
+			"payment_method": escrow.PaymentMethod,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		s.publish(&payment, &escrow)
+	}
+	return &payment, nil
+}
+
+// publish tells the parties how a payment ended
+func (s *PaymentService) publish(payment *models.PaymentTransaction, escrow *models.EscrowTransaction) {
+	var task models.Task
+	if err := s.db.Select("id", "title").First(&task, "id = ?", payment.TaskID).Error; err != nil {
+		log.Printf("[Payments] Failed to load task %s for payment %s: %v", payment.TaskID, payment.Reference, err)
+		return
+	}
+	amount := money.New(payment.Amount, payment.Currency).String()
+	data := map[string]interface{}{
+		"task_id":    task.ID.String(),
+		"payment_id": payment.ID.String(),
+		"kind":       payment.Kind,
+		"status":     payment.Status,
+	}
+	notify := func(userID uuid.UUID, notificationType, title, message string) {
+		if _, err := Notify(s.db, s.fcm, userID, notificationType, title, message, data); err != nil {
+			log.Printf("[Payments] Failed to notify user %s of payment %s: %v", userID, payment.Reference, err)
+		}
+	}
+
+	succeeded := payment.Status == models.PaymentSucceeded
+	switch {
+	case payment.Kind == models.PaymentCollection && succeeded && escrow.Status == models.EscrowHeld:
+		notify(escrow.PosterID, "payment_received", "Payment Received",
+			fmt.Sprintf("Your payment of %s for %s is held until the task is complete.", amount, task.Title))
+		notify(escrow.TaskerID, "task_paid", "Task Paid",
+			fmt.Sprintf("The poster has paid %s for %s. It is released to you when the task is complete.", amount, task.Title))
+	case payment.Kind == models.PaymentCollection && succeeded:
+		notify(escrow.PosterID, "payment_refunding", "Payment Being Refunded",
+			fmt.Sprintf("Your payment of %s for %s arrived after the task was closed, so it is being refunded.", amount, task.Title))
+	case payment.Kind == models.PaymentCollection:
+		notify(escrow.PosterID, "payment_failed", "Payment Failed",
+			fmt.Sprintf("Your payment of %s for %s did not go through: %s", amount, task.Title, payment.FailureReason))
+	case payment.Kind == models.PaymentPayout && succeeded:
+		notify(payment.UserID, "payout_sent", "Payout Sent",
+			fmt.Sprintf("%s for %s has been sent to you.", amount, task.Title))
+	case payment.Kind == models.PaymentPayout:
+		notify(payment.UserID, "payout_failed", "Payout Failed",
+			fmt.Sprintf("We could not send %s for %s: %s. Check your EcoCash number in your tasker profile.", amount, task.Title, payment.FailureReason))
+	case payment.Kind == models.PaymentRefund && succeeded:
+		notify(payment.UserID, "refund_sent", "Refund Sent",
+			fmt.Sprintf("%s for %s has been refunded to you.", amount, task.Title))
+	default:
+		log.Printf("[Payments] Refund %s for task %s failed: %s", payment.Reference, task.ID, payment.FailureReason)
+	}
+
+	if s.hub != nil {
+		s.hub.BroadcastToRoom("task_updates:"+task.ID.String(), map[string]interface{}{
+			"type":    "payment_updated",
+			"task_id": task.ID,
+			"payment": payment,
+			"escrow":  escrow,
+		})
+	}
+}
+
+// RefundEscrowTx unwinds the escrow of a task being reopened or cancelled. Held
+// escrow is refunded, queuing a refund for money collected through a provider;
+// unpaid escrow is cancelled. Either way the poster's wallet is credited back.
+func RefundEscrowTx(tx *gorm.DB, taskID uuid.UUID) error {
+	var open []models.EscrowTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("task_id = ? AND status IN ?", taskID, []string{models.EscrowPending, models.EscrowHeld}).
+		Find(&open).Error; err != nil {
+		return err
+	}
+	for i := range open {
+		escrow := &open[i]
+		status, kind := models.EscrowRefunded, models.JournalEscrowRefunded
+		if escrow.Status == models.EscrowPending {
+			status, kind = models.EscrowCancelled, models.JournalEscrowCancelled
+		}
+		if err := tx.Model(escrow).Update("status", status).Error; err != nil {
+			return err
+		}
+		if err := postEscrowReturnedTx(tx, escrow, kind); err != nil {
+			return err
+		}
+		if status == models.EscrowCancelled {
+			continue
+		}
+		collection, err := escrowCollection(tx, escrow)
+		if err != nil || collection == nil {
+			return err
+		}
+		if err := queuePaymentTx(tx, collection, models.PaymentRefund, escrow.PosterID, collection.Phone, collection.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SettleEscrowTx releases a completed task's escrow to the tasker. Escrow the
+// poster never paid through the platform is settled in cash, as before payments
+// existed. Money collected through a provider, less the platform's fees, is
+// queued for payout to the tasker's EcoCash number; if the final bill came in
+// under what was collected, the difference is queued for refund. Collected
+// escrow stays held, with ErrPayoutNumberRequired, until the tasker has a valid
+// number.
+func SettleEscrowTx(tx *gorm.DB, escrow *models.EscrowTransaction) error {
+	switch escrow.Status {
+	case models.EscrowPending:
+		escrow.PaymentMethod = payments.MethodCash
+	case models.EscrowHeld:
+	default:
+		return nil
+	}
+
+	collection, err := escrowCollection(tx, escrow)
+	if err != nil {
+		return err
+	}
+	var profile models.TaskerProfile
+	if collection != nil {
+		if err := tx.Select("ecocash_number").Where("user_id = ?", escrow.TaskerID).First(&profile).Error; err != nil &&
+			!errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if _, err := payments.NormalizeMSISDN(profile.EcocashNumber); err != nil {
+			return ErrPayoutNumberRequired
+		}
+	}
+
+	escrow.Status = models.EscrowReleased
+	if err := tx.Model(escrow).Updates(map[string]interface{}{
+		"status":         escrow.Status,
+		"payment_method": escrow.PaymentMethod,
+	}).Error; err != nil {
+		return err
+	}
+	if err := postEscrowReleasedTx(tx, escrow); err != nil {
+		return err
+	}
+	if collection == nil {
+		return nil
+	}
+
+	// The platform keeps the fees out of the collection; anything collected above
+	// the escrow total, as when a metered hire was billed below its estimate, goes
+	// back to the poster
+	payout := decimal.Min(escrowPayout(escrow), collection.Amount)
+	if err := queuePaymentTx(tx, collection, models.PaymentPayout, escrow.TaskerID, profile.EcocashNumber, payout); err != nil {
+		return err
+	}
+	if change := collection.Amount.Sub(escrowTotal(escrow)); change.IsPositive() {
+		return queuePaymentTx(tx, collection, models.PaymentRefund, escrow.PosterID, collection.Phone, change)
+	}
+	return nil
+}
+
+// escrowCollection returns the provider collection that paid the escrow, or nil
+// when it was paid in cash or not at all
+func escrowCollection(tx *gorm.DB, escrow *models.EscrowTransaction) (*models.PaymentTransaction, error) {
+	if escrow.PaymentMethod == "" || escrow.PaymentMethod == payments.MethodCash {
+		return nil, nil
+	}
+	var collection models.PaymentTransaction
+	err := tx.Where("escrow_id = ? AND kind = ? AND status = ?", escrow.ID, models.PaymentCollection, models.PaymentSucceeded).
+		First(&collection).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// queuePaymentTx queues a payout or refund of money taken by collection, for the
+// process_payments job to send through the same provider
+func queuePaymentTx(tx *gorm.DB, collection *models.PaymentTransaction, kind string, userID uuid.UUID, phone string, amount decimal.Decimal) error {
+	payment := models.PaymentTransaction{
+		EscrowID:  collection.EscrowID,
+		TaskID:    collection.TaskID,
+		UserID:    userID,
+		Kind:      kind,
+		Provider:  collection.Provider,
+		Reference: newPaymentReference(kind),
+		Amount:    amount,
+		Currency:  collection.Currency,
+		Phone:     phone,
+		Status:    models.PaymentQueued,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return err
+	}
+	return postPaymentTx(tx, &payment, models.PaymentQueued)
+}
+
+// newPaymentReference returns a unique reference such as COL-3F9A... for a collection
+func newPaymentReference(kind string) string {
+	prefix := map[string]string{
+		models.PaymentCollection: "COL",
+		models.PaymentPayout:     "PAY",
+		models.PaymentRefund:     "REF",
+	}[kind]
+	return prefix + "-" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:20])
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/airmassxpress/backend/internal/money"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotEquipmentTask is returned when quoting a task that is not an equipment hire.
+	ErrNotEquipmentTask = errors.New("quotes can only be computed for equipment tasks")
+	// ErrInventoryNotFound is returned when the quoted inventory item does not exist.
+	ErrInventoryNotFound = errors.New("inventory item not found")
+	// ErrInventoryNotOwned is returned when a tasker quotes with someone else's equipment.
+	ErrInventoryNotOwned = errors.New("inventory item does not belong to you")
+	// ErrNoRateForDuration is returned when the item has no rate usable for the task's hire duration.
+	ErrNoRateForDuration = errors.New("inventory item has no rate for the task's hire duration")
+	// ErrOperatorUnavailable is returned when the task requires an operator the item does not come with.
+	ErrOperatorUnavailable = errors.New("task requires an operator but this item is offered without one")
+	// ErrFuelRateMissing is returned when the task wants fuel included but the item has no fuel rate.
+	ErrFuelRateMissing = errors.New("task requires fuel to be included but this item has no fuel rate")
+	// ErrOfferCurrency is returned for an offer or quote in a currency other than the task's.
+	ErrOfferCurrency = fmt.Errorf("%w: offers must be in the task's currency", money.ErrCurrencyMismatch)
+)
+
+// Machine hours per hire unit. A day is one 8-hour shift, a week five working
+// days and a month four weeks; these convert between rate units and price fuel.
+var hireUnitHours = map[string]int64{
+	"hourly":  1,
+	"daily":   8,
+	"weekly":  40,
+	"monthly": 160,
+}
+
+// rateUnits lists the units an item can be priced in, largest first
+var rateUnits = []string{"weekly", "daily", "hourly"}
+
+// Quote is a server-computed price for hiring an inventory item on a task
+type Quote struct {
+	TaskID           uuid.UUID          `json:"task_id"`
+	InventoryID      uuid.UUID          `json:"inventory_id"`
+	HireDurationType string             `json:"hire_duration_type"`
+	Duration         decimal.Decimal    `json:"duration"`
+	RateType         string             `json:"rate_type"`
+	BaseRate         decimal.Decimal    `json:"base_rate"`
+	Quantity         decimal.Decimal    `json:"quantity"`
+	IncludesOperator bool               `json:"includes_operator"`
+	Lines            []models.QuoteLine `json:"lines"`
+	Total            decimal.Decimal    `json:"total"`
+	Currency         string             `json:"currency"`
+}
+
+// Quote loads the task and the tasker's inventory item and prices the hire
+func (s *OfferService) Quote(taskID, inventoryID, taskerID uuid.UUID) (*Quote, error) {
+	var task models.Task
+	if err := s.db.First(&task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return s.QuoteTask(&task, inventoryID, taskerID)
+}
+
+// QuoteTask prices hiring the tasker's inventory item on an already loaded task,
+// returning an *IneligibleError when the item does not qualify for it
+func (s *OfferService) QuoteTask(task *models.Task, inventoryID, taskerID uuid.UUID) (*Quote, error) {
+	var item models.InventoryItem
+	if err := s.db.First(&item, "id = ?", inventoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventoryNotFound
+		}
+		return nil, err
+	}
+	if task.TaskType != "equipment" {
+		return nil, ErrNotEquipmentTask
+	}
+	if item.UserID != taskerID {
+		return nil, ErrInventoryNotOwned
+	}
+	if err := s.eligibility.Check(task, &item); err != nil {
+		return nil, err
+	}
+
+	return BuildEquipmentQuote(task, &item, taskerID)
+}
+
+// BuildEquipmentQuote prices hiring item for task from the item's rates and the
+// task's hire duration. Eligibility is checked separately by EligibilityService.
+func BuildEquipmentQuote(task *models.Task, item *models.InventoryItem, taskerID uuid.UUID) (*Quote, error) {
+	if task.TaskType != "equipment" {
+		return nil, ErrNotEquipmentTask
+	}
+	if item.UserID != taskerID {
+		return nil, ErrInventoryNotOwned
+	}
+	if item.Currency != task.Currency {
+		return nil, ErrOfferCurrency
+	}
+
+	unit, duration := hireDuration(task)
+	hours := duration.Mul(decimal.NewFromInt(hireUnitHours[unit]))
+
+	rateType, rate, ok := itemRate(item, unit)
+	if !ok {
+		return nil, ErrNoRateForDuration
+	}
+	quantity := hours.Div(decimal.NewFromInt(hireUnitHours[rateType]))
+
+	quote := &Quote{
+		TaskID:           task.ID,
+		InventoryID:      item.ID,
+		HireDurationType: unit,
+		Duration:         duration,
+		RateType:         rateType,
+		BaseRate:         rate,
+		Quantity:         quantity,
+		Currency:         task.Currency,
+	}
+	quote.addLine(models.QuoteLineBase, fmt.Sprintf("%s hire (%s %s)", item.Name, quantity.String(), hireUnitLabel(rateType)), quantity, rate)
+
+	if item.DeliveryFee != nil && item.DeliveryFee.IsPositive() {
+		quote.addLine(models.QuoteLineDelivery, "Delivery and collection", decimal.NewFromInt(1), *item.DeliveryFee)
+	}
+
+	switch task.OperatorPreference {
+	case "not_needed":
+	case "required":
+		if !item.WithOperator {
+			return nil, ErrOperatorUnavailable
+		}
+		quote.IncludesOperator = true
+	default:
+		quote.IncludesOperator = item.WithOperator
+	}
+	if quote.IncludesOperator {
+		if item.OperatorBundled || item.OperatorFee == nil {
+			quote.addLine(models.QuoteLineOperator, "Operator (included in hire rate)", quantity, decimal.Zero)
+		} else {
+			quote.addLine(models.QuoteLineOperator, fmt.Sprintf("Operator (%s %s)", quantity.String(), hireUnitLabel(rateType)), quantity, *item.OperatorFee)
+		}
+	}
+
+	if task.FuelIncluded {
+		if item.FuelRate == nil {
+			return nil, ErrFuelRateMissing
+		}
+		quote.addLine(models.QuoteLineFuel, fmt.Sprintf("Fuel (%s machine hours)", hours.String()), hours, *item.FuelRate)
+	}
+
+	return quote, nil
+}
+
+// Apply copies the quote onto a structured offer
+func (q *Quote) Apply(offer *models.Offer) {
+	inventoryID := q.InventoryID
+	offer.QuoteType = "structured"
+	offer.RateType = q.RateType
+	offer.Amount = q.Total
+	offer.Currency = q.Currency
+	offer.BaseRate = decimalPtr(q.BaseRate)
+	offer.IncludesOperator = q.IncludesOperator
+	offer.InventoryID = &inventoryID
+	offer.QuoteLines = q.Lines
+	offer.DeliveryFee = nil
+	offer.OperatorFee = nil
+	for _, line := range q.Lines {
+		switch line.Kind {
+		case models.QuoteLineDelivery:
+			offer.DeliveryFee = decimalPtr(line.Amount)
+		case models.QuoteLineOperator:
+			offer.OperatorFee = decimalPtr(line.Amount)
+		}
+	}
+}
+
+func (q *Quote) addLine(kind, description string, quantity, unitPrice decimal.Decimal) {
+	amount := quantity.Mul(unitPrice).Round(2)
+	q.Lines = append(q.Lines, models.QuoteLine{
+		Kind:        kind,
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		Amount:      amount,
+	})
+	q.Total = q.Total.Add(amount)
+}
+
+// hireDuration returns the task's hire unit and how many of them it needs,
+// defaulting to a single unit when the poster gave no estimate
+func hireDuration(task *models.Task) (string, decimal.Decimal) {
+	unit := task.HireDurationType
+	if _, ok := hireUnitHours[unit]; !ok {
+		unit = "daily"
+		if task.EstimatedHours != nil {
+			unit = "hourly"
+		}
+	}
+
+	count := task.EstimatedDuration
+	if unit == "hourly" && task.EstimatedHours != nil {
+		count = task.EstimatedHours
+	}
+	if count == nil || *count <= 0 {
+		return unit, decimal.NewFromInt(1)
+	}
+	return unit, decimal.NewFromInt(int64(*count))
+}
+
+// itemRate picks the item's rate for unit, falling back to smaller units so a
+// weekly hire can be priced from a daily rate but never the other way round
+func itemRate(item *models.InventoryItem, unit string) (string, decimal.Decimal, bool) {
+	rates := map[string]*decimal.Decimal{
+		"hourly": item.HourlyRate,
+		"daily":  item.DailyRate,
+		"weekly": item.WeeklyRate,
+	}
+	for _, rateType := range rateUnits {
+		if hireUnitHours[rateType] > hireUnitHours[unit] {
+			continue
+		}
+		if rate := rates[rateType]; rate != nil && rate.IsPositive() {
+			return rateType, *rate, true
+		}
+	}
+	return "", decimal.Zero, false
+}
+
+func hireUnitLabel(unit string) string {
+	switch unit {
+	case "hourly":
+		return "hours"
+	case "weekly":
+		return "weeks"
+	case "monthly":
+		return "months"
+	default:
+		return "days"
+	}
+}
+
+func decimalPtr(d decimal.Decimal) *decimal.Decimal {
+	return &d
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestBuildEquipmentQuote(t *testing.T) {
+	taskerID := uuid.New()
+	count := func(n int) *int { return &n }
+
+	tests := []struct {
+		name         string
+		task         models.Task
+		item         models.InventoryItem
+		wantErr      error
+		wantRateType string
+		wantQuantity string
+		wantTotal    string
+		wantLines    int
+	}{
+		{"not equipment", models.Task{TaskType: "in_person", Currency: "USD"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, ErrNotEquipmentTask, "", "", "", 0},
+		{"someone else's item", models.Task{TaskType: "equipment", Currency: "USD"},
+			models.InventoryItem{UserID: uuid.New(), Currency: "USD", DailyRate: decPtr("100")}, ErrInventoryNotOwned, "", "", "", 0},
+		{"other currency", models.Task{TaskType: "equipment", Currency: "ZWG"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, ErrOfferCurrency, "", "", "", 0},
+		{"no rate small enough", models.Task{TaskType: "equipment", Currency: "USD", HireDurationType: "hourly", EstimatedHours: count(4)},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, ErrNoRateForDuration, "", "", "", 0},
+		{"operator required", models.Task{TaskType: "equipment", Currency: "USD", OperatorPreference: "required"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, ErrOperatorUnavailable, "", "", "", 0},
+		{"fuel without rate", models.Task{TaskType: "equipment", Currency: "USD", FuelIncluded: true},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, ErrFuelRateMissing, "", "", "", 0},
+		{"defaults to one day", models.Task{TaskType: "equipment", Currency: "USD"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, nil, "daily", "1", "100", 1},
+		{"days plus delivery", models.Task{TaskType: "equipment", Currency: "USD", HireDurationType: "daily", EstimatedDuration: count(3)},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100"), DeliveryFee: decPtr("20")}, nil, "daily", "3", "320", 2},
+		{"weeks priced from a daily rate", models.Task{TaskType: "equipment", Currency: "USD", HireDurationType: "weekly", EstimatedDuration: count(2)},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100")}, nil, "daily", "10", "1000", 1},
+		{"hours with a paid operator", models.Task{TaskType: "equipment", Currency: "USD", HireDurationType: "hourly", EstimatedHours: count(4), OperatorPreference: "preferred"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", HourlyRate: decPtr("25"), WithOperator: true, OperatorFee: decPtr("10")}, nil, "hourly", "4", "140", 2},
+		{"bundled operator", models.Task{TaskType: "equipment", Currency: "USD", OperatorPreference: "required"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100"), WithOperator: true, OperatorBundled: true, OperatorFee: decPtr("50")}, nil, "daily", "1", "100", 2},
+		{"operator not needed", models.Task{TaskType: "equipment", Currency: "USD", OperatorPreference: "not_needed"},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100"), WithOperator: true, OperatorFee: decPtr("50")}, nil, "daily", "1", "100", 1},
+		{"fuel per machine hour", models.Task{TaskType: "equipment", Currency: "USD", HireDurationType: "daily", EstimatedDuration: count(2), FuelIncluded: true},
+			models.InventoryItem{UserID: taskerID, Currency: "USD", DailyRate: decPtr("100"), FuelRate: decPtr("3")}, nil, "daily", "2", "248", 2},
+	}
+	for _, tt := range tests {
+		quote, err := BuildEquipmentQuote(&tt.task, &tt.item, taskerID)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if quote.RateType != tt.wantRateType || !quote.Quantity.Equal(dec(tt.wantQuantity)) {
+			t.Errorf("%s: priced %s %s, want %s %s", tt.name, quote.Quantity, quote.RateType, tt.wantQuantity, tt.wantRateType)
+		}
+		if !quote.Total.Equal(dec(tt.wantTotal)) {
+			t.Errorf("%s: total = %s, want %s", tt.name, quote.Total, tt.wantTotal)
+		}
+		if len(quote.Lines) != tt.wantLines {
+			t.Errorf("%s: %d lines, want %d", tt.name, len(quote.Lines), tt.wantLines)
+		}
+	}
+}
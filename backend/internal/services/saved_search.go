@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// digestInterval is the minimum time between two digests for the same saved search.
+const digestInterval = 24 * time.Hour
+
+// SavedSearchMatcher alerts taskers when new tasks match their saved searches.
+type SavedSearchMatcher struct {
+	db  *gorm.DB
+	fcm *FCMService
+}
+
+func NewSavedSearchMatcher(db *gorm.DB, fcm *FCMService) *SavedSearchMatcher {
+	return &SavedSearchMatcher{db: db, fcm: fcm}
+}
+
+// MatchTask records matches for a newly created task and sends instant alerts.
+// Daily searches are left for SendDigests. A user is alerted at most once per task,
+// however many of their searches match it.
+func (m *SavedSearchMatcher) MatchTask(task *models.Task) {
+	var searches []models.SavedSearch
+	err := m.db.
+		Where("active = ? AND user_id <> ?", true, task.PosterID).
+		Where("category = '' OR category IS NULL OR category = ?", task.Category).
+		Where("task_type = '' OR task_type IS NULL OR task_type = ?", task.TaskType).
+		Where("(min_budget IS NULL AND max_budget IS NULL) OR currency = ?", task.Currency).
+		Where("min_budget IS NULL OR min_budget <= ?", task.Budget).
+		Where("max_budget IS NULL OR max_budget >= ?", task.Budget).
+		Find(&searches).Error
+	if err != nil {
+		log.Printf("[SavedSearch] Failed to load saved searches for task %s: %v", task.ID, err)
+		return
+	}
+
+	alerted := map[uuid.UUID]bool{}
+	for i := range searches {
+		search := &searches[i]
+		if !m.matches(search, task) {
+			continue
+		}
+
+		match := models.SavedSearchMatch{SavedSearchID: search.ID, TaskID: task.ID}
+		result := m.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&match)
+		if result.Error != nil {
+			log.Printf("[SavedSearch] Failed to record match of task %s for search %s: %v", task.ID, search.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 || search.Frequency != models.SavedSearchInstant {
+			continue
+		}
+
+		now := time.Now()
+		m.db.Model(&match).Update("notified_at", now)
+		if alerted[search.UserID] {
+			continue
+		}
+		alerted[search.UserID] = true
+
+		data := map[string]interface{}{
+			"task_id":         task.ID.String(),
+			"saved_search_id": search.ID.String(),
+			"url":             "/tasks/" + task.ID.String(),
+		}
+		message := fmt.Sprintf("'%s' matches your saved search", task.Title)
+		if search.Name != "" {
+			message = fmt.Sprintf("'%s' matches your saved search \"%s\"", task.Title, search.Name)
+		}
+		if _, err := Notify(m.db, m.fcm, search.UserID, "saved_search_match", "New Task Alert", message, data); err != nil {
+			log.Printf("[SavedSearch] Failed to alert user %s about task %s: %v", search.UserID, task.ID, err)
+		}
+	}
+}
+
+// matches applies the checks that are not expressed in MatchTask's SQL: radius and keywords.
+func (m *SavedSearchMatcher) matches(search *models.SavedSearch, task *models.Task) bool {
+	if search.Lat != nil && search.Lng != nil && search.RadiusKm != nil {
+		if task.Lat == nil || task.Lng == nil {
+			return false
+		}
+		if HaversineKm(*search.Lat, *search.Lng, *task.Lat, *task.Lng) > *search.RadiusKm {
+			return false
+		}
+	}
+
+	if query := ParseTaskSearch(search.Keywords); query != nil {
+		var found bool
+		if err := query.Apply(m.db.Model(&models.Task{}).Select("count(*) > 0").Where("tasks.id = ?", task.ID)).Scan(&found).Error; err != nil {
+			log.Printf("[SavedSearch] Failed to match keywords for search %s: %v", search.ID, err)
+			return false
+		}
+		return found
+	}
+
+	return true
+}
+
+// SendDigests sends one notification per user summarising un-notified matches on
+// their daily searches. Searches digested within the last day are skipped. It
+// returns the number of digests sent.
+func (m *SavedSearchMatcher) SendDigests(now time.Time) (int, error) {
+	var searches []models.SavedSearch
+	err := m.db.
+		Where("active = ? AND frequency = ?", true, models.SavedSearchDaily).
+		Where("last_digest_at IS NULL OR last_digest_at <= ?", now.Add(-digestInterval)).
+		Where("EXISTS (SELECT 1 FROM saved_search_matches WHERE saved_search_matches.saved_search_id = saved_searches.id AND saved_search_matches.notified_at IS NULL)").
+		Find(&searches).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	byUser := map[uuid.UUID][]uuid.UUID{}
+	for _, search := range searches {
+		byUser[search.UserID] = append(byUser[search.UserID], search.ID)
+	}
+
+	for userID, searchIDs := range byUser {
+		// Tasks that closed since matching are no longer worth alerting about
+		var taskIDs []uuid.UUID
+		err := m.db.Model(&models.SavedSearchMatch{}).
+			Joins("JOIN tasks ON tasks.id = saved_search_matches.task_id").
+			Where("saved_search_matches.saved_search_id IN ? AND saved_search_matches.notified_at IS NULL", searchIDs).
+			Where("tasks.status = ? AND tasks.deleted_at IS NULL", models.TaskStatusOpen).
+			Distinct().Pluck("saved_search_matches.task_id", &taskIDs).Error
+		if err != nil {
+			log.Printf("[SavedSearch] Failed to load digest matches for user %s: %v", userID, err)
+			continue
+		}
+
+		err = m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.SavedSearchMatch{}).
+				Where("saved_search_id IN ? AND notified_at IS NULL", searchIDs).
+				Update("notified_at", now).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.SavedSearch{}).Where("id IN ?", searchIDs).Update("last_digest_at", now).Error
+		})
+		if err != nil {
+			log.Printf("[SavedSearch] Failed to mark digest for user %s: %v", userID, err)
+			continue
+		}
+
+		if len(taskIDs) == 0 {
+			continue
+		}
+
+		message := "1 new task matches your saved searches"
+		if len(taskIDs) > 1 {
+			message = fmt.Sprintf("%d new tasks match your saved searches", len(taskIDs))
+		}
+		data := map[string]interface{}{
+			"count": len(taskIDs),
+			"url":   "/browse",
+		}
+		if _, err := Notify(m.db, m.fcm, userID, "saved_search_digest", "Your Daily Task Digest", message, data); err != nil {
+			log.Printf("[SavedSearch] Failed to send digest to user %s: %v", userID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
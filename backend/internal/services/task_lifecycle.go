@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTaskNotFound is returned when the task being transitioned does not exist.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTransitionNotPermitted is returned when the actor's role on the task
+	// does not allow the requested transition.
+	ErrTransitionNotPermitted = errors.New("not permitted to change this task's status")
+	// ErrTaskDatePassed is returned when reopening a task whose date has passed
+	// without giving it a new one, since it would only expire again.
+	ErrTaskDatePassed = errors.New("the task's date has passed; give it a new date to reopen it")
+)
+
+// TransitionError reports a status change that the task state machine does not allow.
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move task from %s to %s", e.From, e.To)
+}
+
+// taskRole identifies who is attempting a transition.
+type taskRole int
+
+const (
+	rolePoster taskRole = 1 << iota
+	roleTasker
+)
+
+// taskTransitions lists every legal status change and the roles allowed to perform it.
+// The system (scheduled jobs, admin tooling) may perform any legal transition; a zero
+// role means only the system may.
+var taskTransitions = map[string]map[string]taskRole{
+	models.TaskStatusOpen: {
+		models.TaskStatusAssigned:  rolePoster,
+		models.TaskStatusCancelled: rolePoster,
+		models.TaskStatusExpired:   0,
+	},
+	models.TaskStatusAssigned: {
+		models.TaskStatusInProgress: roleTasker,
+		models.TaskStatusOpen:       rolePoster,
+		models.TaskStatusCancelled:  rolePoster,
+	},
+	models.TaskStatusInProgress: {
+		models.TaskStatusCompleted: roleTasker,
+		models.TaskStatusCancelled: rolePoster,
+	},
+	models.TaskStatusCancelled: {
+		models.TaskStatusOpen: rolePoster,
+	},
+	models.TaskStatusExpired: {
+		models.TaskStatusOpen: rolePoster,
+	},
+	models.TaskStatusCompleted: {},
+}
+
+// CanTransition reports whether the state machine allows moving from one status to another.
+func CanTransition(from, to string) bool {
+	_, ok := taskTransitions[from][to]
+	return ok
+}
+
+// TaskTransition is the result of a committed status change, ready to be published.
+type TaskTransition struct {
+	Task     *models.Task
+	History  models.TaskStatusHistory
+	TaskerID *uuid.UUID
+	// SkipNotify suppresses the generic status notification when the caller
+	// already sends a more specific one for the same event.
+	SkipNotify bool
+}
+
+// TaskLifecycle is the single entry point for changing a task's status. It validates
+// transitions, records TaskStatusHistory and publishes hub events and notifications.
+type TaskLifecycle struct {
+	db  *gorm.DB
+	fcm *FCMService
+	hub *Hub
+}
+
+func NewTaskLifecycle(db *gorm.DB, fcm *FCMService, hub *Hub) *TaskLifecycle {
+	return &TaskLifecycle{db: db, fcm: fcm, hub: hub}
+}
+
+// Transition moves a task to the given status in its own transaction and publishes
+// the change once committed. A nil actorID means the system is acting.
+func (l *TaskLifecycle) Transition(taskID uuid.UUID, to string, actorID *uuid.UUID, reason string) (*models.Task, error) {
+	var transition *TaskTransition
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		task, err := LockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		transition, err = l.TransitionTx(tx, task, to, actorID, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.Publish(transition)
+	return transition.Task, nil
+}
+
+// Reopen returns a task to open, first moving it to date when given. The date
+// may not be before today.
+func (l *TaskLifecycle) Reopen(taskID uuid.UUID, actorID *uuid.UUID, reason string, date *time.Time) (*models.Task, error) {
+	if date != nil && TaskDatePassed(date, time.Now()) {
+		return nil, ErrTaskDatePassed
+	}
+
+	var transition *TaskTransition
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		task, err := LockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		// A failed transition rolls the new date back with it
+		if date != nil {
+			if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Update("date", *date).Error; err != nil {
+				return err
+			}
+			task.Date = date
+		}
+		transition, err = l.TransitionTx(tx, task, models.TaskStatusOpen, actorID, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.Publish(transition)
+	return transition.Task, nil
+}
+
+// TaskDatePassed reports whether date is before today (UTC), which is when the
+// expire_tasks job expires open tasks.
+func TaskDatePassed(date *time.Time, now time.Time) bool {
+	return date != nil && date.Before(now.UTC().Truncate(24*time.Hour))
+}
+
+// LockTask loads a task with a row lock for the remainder of tx.
+func LockTask(tx *gorm.DB, taskID uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// TransitionTx performs a status change using tx, which should already hold a row
+// lock on the task (see LockTask). The caller must pass the returned transition to
+// Publish after the transaction commits.
+func (l *TaskLifecycle) TransitionTx(tx *gorm.DB, task *models.Task, to string, actorID *uuid.UUID, reason string) (*TaskTransition, error) {
+	from := task.Status
+	allowed, ok := taskTransitions[from][to]
+	if !ok {
+		return nil, &TransitionError{From: from, To: to}
+	}
+
+	var taskerID *uuid.UUID
+	if task.AcceptedOfferID != nil {
+		var offer models.Offer
+		if err := tx.Select("tasker_id").First(&offer, "id = ?", task.AcceptedOfferID).Error; err == nil {
+			taskerID = &offer.TaskerID
+		}
+	}
+
+	// For assignments the caller links the accepted offer before transitioning,
+	// so taskerID already refers to the incoming tasker.
+	if actorID != nil && allowed&actorRole(task, taskerID, *actorID) == 0 {
+		return nil, ErrTransitionNotPermitted
+	}
+	if to == models.TaskStatusOpen && TaskDatePassed(task.Date, time.Now()) {
+		return nil, ErrTaskDatePassed
+	}
+
+	updates := map[string]interface{}{"status": to}
+	if to == models.TaskStatusOpen && task.AcceptedOfferID != nil {
+		// Reopening releases the assigned tasker so new offers can be accepted.
+		if err := tx.Model(&models.Offer{}).Where("id = ?", task.AcceptedOfferID).Update("status", "rejected").Error; err != nil {
+			return nil, err
+		}
+		updates["accepted_offer_id"] = nil
+	}
+	if to == models.TaskStatusOpen {
+		// A future assignee gets their own reminder
+		updates["reminder_sent_at"] = nil
+	}
+	if to == models.TaskStatusExpired {
+		// Nobody can accept offers on an expired task, so they lapse with it.
+		if err := tx.Model(&models.Offer{}).
+			Where("task_id = ? AND status = ?", task.ID, models.OfferStatusPending).
+			Update("status", models.OfferStatusExpired).Error; err != nil {
+			return nil, err
+		}
+	}
+	if to == models.TaskStatusOpen || to == models.TaskStatusCancelled {
+		if err := RefundEscrowTx(tx, task.ID); err != nil {
+			return nil, err
+		}
+		if err := ReleaseTaskBookingsTx(tx, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Guard on the previous status so a concurrent writer cannot be overwritten.
+	result := tx.Model(&models.Task{}).Where("id = ? AND status = ?", task.ID, from).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, &TransitionError{From: from, To: to}
+	}
+
+	history := models.TaskStatusHistory{
+		TaskID:     task.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return nil, err
+	}
+
+	task.Status = to
+	if _, cleared := updates["accepted_offer_id"]; cleared {
+		task.AcceptedOfferID = nil
+	}
+
+	return &TaskTransition{Task: task, History: history, TaskerID: taskerID}, nil
+}
+
+// actorRole resolves the roles the actor holds on the task.
+func actorRole(task *models.Task, taskerID *uuid.UUID, actorID uuid.UUID) taskRole {
+	var role taskRole
+	if task.PosterID == actorID {
+		role |= rolePoster
+	}
+	if taskerID != nil && *taskerID == actorID {
+		role |= roleTasker
+	}
+	return role
+}
+
+var taskStatusNotifications = map[string]struct{ title, message string }{
+	models.TaskStatusOpen:       {"Task Reopened", "'%s' is open for offers again."},
+	models.TaskStatusAssigned:   {"Task Assigned", "'%s' has been assigned."},
+	models.TaskStatusInProgress: {"Task Started", "Work on '%s' has started."},
+	models.TaskStatusCompleted:  {"Task Completed", "'%s' has been marked as complete."},
+	models.TaskStatusCancelled:  {"Task Cancelled", "'%s' has been cancelled."},
+	models.TaskStatusExpired:    {"Task Expired", "'%s' has expired because its date has passed. Reopen it to receive offers again."},
+}
+
+// Publish broadcasts a committed transition on the task's room and notifies the
+// poster and assigned tasker, excluding whoever performed it.
+func (l *TaskLifecycle) Publish(t *TaskTransition) {
+	if t == nil {
+		return
+	}
+	task := t.Task
+
+	event := map[string]interface{}{
+		"type":    "task_status_changed",
+		"task_id": task.ID,
+		"from":    t.History.FromStatus,
+		"to":      t.History.ToStatus,
+		"reason":  t.History.Reason,
+		"task":    task,
+	}
+	if l.hub != nil {
+		l.hub.BroadcastToRoom("task_updates:"+task.ID.String(), event)
+		l.hub.BroadcastToRoom("browse_tasks", event)
+	}
+
+	if t.SkipNotify {
+		return
+	}
+
+	text, ok := taskStatusNotifications[t.History.ToStatus]
+	if !ok {
+		return
+	}
+
+	recipients := []uuid.UUID{task.PosterID}
+	if t.TaskerID != nil {
+		recipients = append(recipients, *t.TaskerID)
+	}
+
+	for _, userID := range recipients {
+		if t.History.ActorID != nil && *t.History.ActorID == userID {
+			continue
+		}
+		data := map[string]interface{}{
+			"task_id": task.ID.String(),
+			"from":    t.History.FromStatus,
+			"to":      t.History.ToStatus,
+			"url":     "/tasks/" + task.ID.String(),
+		}
+		if _, err := Notify(l.db, l.fcm, userID, "task_"+t.History.ToStatus, text.title, fmt.Sprintf(text.message, task.Title), data); err != nil {
+			log.Printf("[TaskLifecycle] Failed to notify user %s about task %s: %v", userID, task.ID, err)
+		}
+	}
+}
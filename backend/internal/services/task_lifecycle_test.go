@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{models.TaskStatusOpen, models.TaskStatusAssigned, true},
+		{models.TaskStatusOpen, models.TaskStatusCancelled, true},
+		{models.TaskStatusOpen, models.TaskStatusExpired, true},
+		{models.TaskStatusOpen, models.TaskStatusInProgress, false},
+		{models.TaskStatusOpen, models.TaskStatusCompleted, false},
+		{models.TaskStatusAssigned, models.TaskStatusInProgress, true},
+		{models.TaskStatusAssigned, models.TaskStatusOpen, true},
+		{models.TaskStatusAssigned, models.TaskStatusCompleted, false},
+		{models.TaskStatusInProgress, models.TaskStatusCompleted, true},
+		{models.TaskStatusInProgress, models.TaskStatusCancelled, true},
+		{models.TaskStatusInProgress, models.TaskStatusOpen, false},
+		{models.TaskStatusCancelled, models.TaskStatusOpen, true},
+		{models.TaskStatusCancelled, models.TaskStatusAssigned, false},
+		{models.TaskStatusExpired, models.TaskStatusOpen, true},
+		{models.TaskStatusExpired, models.TaskStatusAssigned, false},
+		{models.TaskStatusCompleted, models.TaskStatusOpen, false},
+		{models.TaskStatusCompleted, models.TaskStatusCancelled, false},
+		{"unknown", models.TaskStatusOpen, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestActorRole(t *testing.T) {
+	poster, tasker, stranger := uuid.New(), uuid.New(), uuid.New()
+	task := &models.Task{PosterID: poster}
+
+	tests := []struct {
+		name     string
+		taskerID *uuid.UUID
+		actor    uuid.UUID
+		want     taskRole
+	}{
+		{"poster", &tasker, poster, rolePoster},
+		{"tasker", &tasker, tasker, roleTasker},
+		{"stranger", &tasker, stranger, 0},
+		{"no tasker assigned", nil, tasker, 0},
+		{"poster is also tasker", &poster, poster, rolePoster | roleTasker},
+	}
+	for _, tt := range tests {
+		if got := actorRole(task, tt.taskerID, tt.actor); got != tt.want {
+			t.Errorf("%s: actorRole = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTaskDatePassed(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC)
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+
+	tests := []struct {
+		name string
+		date *time.Time
+		want bool
+	}{
+		{"undated", nil, false},
+		{"yesterday", day(17), true},
+		{"today", day(18), false},
+		{"tomorrow", day(19), false},
+	}
+	for _, tt := range tests {
+		if got := TaskDatePassed(tt.date, now); got != tt.want {
+			t.Errorf("%s: TaskDatePassed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// The cases below are all rejected before TransitionTx touches the database.
+func TestTransitionTxRejects(t *testing.T) {
+	lifecycle := &TaskLifecycle{}
+	poster, stranger := uuid.New(), uuid.New()
+	past := time.Now().AddDate(0, 0, -3)
+
+	tests := []struct {
+		name    string
+		status  string
+		date    *time.Time
+		to      string
+		actor   uuid.UUID
+		wantErr error
+	}{
+		{"illegal transition", models.TaskStatusCompleted, nil, models.TaskStatusOpen, poster, &TransitionError{}},
+		{"skipping assignment", models.TaskStatusOpen, nil, models.TaskStatusInProgress, poster, &TransitionError{}},
+		{"stranger cancels", models.TaskStatusOpen, nil, models.TaskStatusCancelled, stranger, ErrTransitionNotPermitted},
+		{"poster expires", models.TaskStatusOpen, nil, models.TaskStatusExpired, poster, ErrTransitionNotPermitted},
+		{"reopen past date", models.TaskStatusExpired, &past, models.TaskStatusOpen, poster, ErrTaskDatePassed},
+	}
+	for _, tt := range tests {
+		task := &models.Task{ID: uuid.New(), PosterID: poster, Status: tt.status, Date: tt.date}
+		actor := tt.actor
+		_, err := lifecycle.TransitionTx(nil, task, tt.to, &actor, "")
+
+		var transitionErr *TransitionError
+		if _, wantTransition := tt.wantErr.(*TransitionError); wantTransition {
+			if !errors.As(err, &transitionErr) {
+				t.Errorf("%s: err = %v, want a TransitionError", tt.name, err)
+			}
+		} else if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if task.Status != tt.status {
+			t.Errorf("%s: status changed to %s", tt.name, task.Status)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// taskSearchSchema keeps tasks.search_vector in sync with the searchable columns.
+// As a generated column, Postgres recomputes it on every insert and update. Statements
+// are idempotent so they can run on each startup alongside AutoMigrate.
+var taskSearchSchema = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(category, '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(suburb, '') || ' ' || coalesce(city, '')), 'C') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'D')
+	) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING gin (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_title_trgm ON tasks USING gin (title gin_trgm_ops)`,
+}
+
+// EnsureTaskSearchSchema creates the full-text search column and indexes if missing.
+func EnsureTaskSearchSchema(db *gorm.DB) error {
+	for _, stmt := range taskSearchSchema {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TaskSearchQuery is a parsed keyword search over task title, description,
+// category and suburb/city.
+type TaskSearchQuery struct {
+	// Raw is the trimmed user input, used for trigram (typo-tolerant) matching
+	Raw string
+	// TSQuery is a prefix-matching tsquery, e.g. "borehol:* & pump:*"
+	TSQuery string
+}
+
+// ParseTaskSearch builds a search query from user input. It returns nil when the
+// input contains no searchable terms.
+func ParseTaskSearch(q string) *TaskSearchQuery {
+	var terms []string
+	for _, field := range strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, strings.ToLower(field)+":*")
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	return &TaskSearchQuery{
+		Raw:     strings.TrimSpace(q),
+		TSQuery: strings.Join(terms, " & "),
+	}
+}
+
+// Apply restricts query to tasks matching the search terms by full text, or whose
+// title is a close trigram match for typo tolerance.
+func (s *TaskSearchQuery) Apply(query *gorm.DB) *gorm.DB {
+	return query.Where("(tasks.search_vector @@ to_tsquery('english', ?) OR ? <% tasks.title)", s.TSQuery, s.Raw)
+}
+
+// RankExpr returns an SQL relevance score combining full-text rank and title similarity.
+func (s *TaskSearchQuery) RankExpr() (string, []interface{}) {
+	return "(ts_rank_cd(tasks.search_vector, to_tsquery('english', ?)) + word_similarity(?, tasks.title))::float8",
+		[]interface{}{s.TSQuery, s.Raw}
+}
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=' … '"
+
+// SnippetExpr returns an SQL expression for a highlighted description excerpt.
+func (s *TaskSearchQuery) SnippetExpr() (string, []interface{}) {
+	return "ts_headline('english', tasks.description, to_tsquery('english', ?), ?)",
+		[]interface{}{s.TSQuery, headlineOptions}
+}
+
+// TitleHighlightExpr returns an SQL expression for the title with matches highlighted.
+func (s *TaskSearchQuery) TitleHighlightExpr() (string, []interface{}) {
+	return "ts_headline('english', tasks.title, to_tsquery('english', ?), ?)",
+		[]interface{}{s.TSQuery, "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"}
+}
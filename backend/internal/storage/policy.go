@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Policy limits what may be uploaded for a given purpose.
+type Policy struct {
+	MaxBytes int64
+	// Allowed maps permitted sniffed content types to the file extension used in keys
+	Allowed map[string]string
+}
+
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Upload policies
+var (
+	Images = Policy{MaxBytes: 10 << 20, Allowed: imageTypes}
+	Avatar = Policy{MaxBytes: 5 << 20, Allowed: imageTypes}
+	// Documents covers KYC and certification uploads, which may be scans or PDFs
+	Documents = Policy{MaxBytes: 15 << 20, Allowed: withTypes(imageTypes, map[string]string{
+		"application/pdf": ".pdf",
+	})}
+)
+
+func withTypes(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Check validates a declared content type and size, e.g. before signing an upload.
+func (p Policy) Check(contentType string, size int64) error {
+	if size <= 0 || size > p.MaxBytes {
+		return fmt.Errorf("%w: limit is %d MB", ErrTooLarge, p.MaxBytes>>20)
+	}
+	if _, ok := p.Allowed[contentType]; !ok {
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// Read reads an upload in full, enforcing the size limit and sniffing the real
+// content type from its first bytes rather than trusting the client.
+func (p Policy) Read(r io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, p.MaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > p.MaxBytes {
+		return nil, "", fmt.Errorf("%w: limit is %d MB", ErrTooLarge, p.MaxBytes>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := p.Allowed[contentType]; !ok {
+		return nil, "", ErrUnsupportedType
+	}
+	return data, contentType, nil
+}
+
+// NewKey returns a unique object key under prefix with the extension for contentType.
+func (p Policy) NewKey(prefix, contentType string) string {
+	return path.Join(prefix, time.Now().UTC().Format("2006/01"), uuid.New().String()+p.Allowed[contentType])
+}
+
+// UploadFile validates a multipart upload against p and stores it under prefix.
+func UploadFile(ctx context.Context, s Storage, p Policy, prefix string, fh *multipart.FileHeader) (Object, error) {
+	if fh.Size > p.MaxBytes {
+		return Object{}, fmt.Errorf("%w: limit is %d MB", ErrTooLarge, p.MaxBytes>>20)
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	data, contentType, err := p.Read(file)
+	if err != nil {
+		return Object{}, err
+	}
+
+	return s.Put(ctx, p.NewKey(prefix, contentType), bytes.NewReader(data), int64(len(data)), contentType)
+}
@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// S3 stores objects in an S3-compatible bucket (AWS S3, MinIO, Cloudflare R2, ...)
+// using Signature Version 4 authenticated requests.
+type S3 struct {
+	accessKey string
+	secretKey string
+	region    string
+	bucket    string
+	// endpoint is the scheme and host requests are sent to
+	endpoint  *url.URL
+	pathStyle bool
+	publicURL string
+	client    *http.Client
+}
+
+func NewS3(cfg config.AWSConfig) (*S3, error) {
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("s3 storage requires AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and S3_BUCKET")
+	}
+
+	rawEndpoint := cfg.Endpoint
+	if rawEndpoint == "" {
+		rawEndpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", rawEndpoint)
+	}
+
+	s := &S3{
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		region:    cfg.Region,
+		bucket:    cfg.S3Bucket,
+		endpoint:  endpoint,
+		pathStyle: cfg.ForcePathStyle,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}
+	s.publicURL = cfg.PublicURL
+	if s.publicURL == "" {
+		s.publicURL = s.objectURL("").String()
+	}
+	s.publicURL = strings.TrimSuffix(s.publicURL, "/")
+	return s, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (Object, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return Object{}, err
+	}
+	payloadHash := sha256.Sum256(data)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return Object{}, err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	s.signRequest(req, hex.EncodeToString(payloadHash[:]), time.Now())
+
+	if err := s.do(req); err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, URL: s.URL(key), ContentType: contentType, Size: int64(len(data))}, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	emptyHash := sha256.Sum256(nil)
+	s.signRequest(req, hex.EncodeToString(emptyHash[:]), time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("s3 api error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	emptyHash := sha256.Sum256(nil)
+	s.signRequest(req, hex.EncodeToString(emptyHash[:]), time.Now())
+	return s.do(req)
+}
+
+func (s *S3) URL(key string) string {
+	return s.publicURL + "/" + escapePath(key)
+}
+
+func (s *S3) KeyFromURL(rawURL string) (string, bool) {
+	escaped, ok := strings.CutPrefix(rawURL, s.publicURL+"/")
+	if !ok || escaped == "" {
+		return "", false
+	}
+	return unescapeKey(escaped)
+}
+
+// SignUpload presigns a PUT. Content-Type and Content-Length are signed headers,
+// so the client must upload exactly the declared type and size.
+func (s *S3) SignUpload(ctx context.Context, key, contentType string, size int64, expires time.Duration) (*SignedUpload, error) {
+	now := time.Now().UTC()
+	target := s.objectURL(key)
+	headers := map[string]string{
+		"content-length": strconv.FormatInt(size, 10),
+		"content-type":   contentType,
+		"host":           target.Host,
+	}
+	signedHeaders := sortedKeys(headers)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", strings.Join(signedHeaders, ";"))
+
+	canonical := canonicalRequest(http.MethodPut, target.EscapedPath(), query, headers, unsignedPayload)
+	query.Set("X-Amz-Signature", s.signature(canonical, now))
+	target.RawQuery = canonicalQuery(query)
+
+	return &SignedUpload{
+		Method: http.MethodPut,
+		URL:    target.String(),
+		Headers: map[string]string{
+			"Content-Type": contentType,
+		},
+		Key:       key,
+		PublicURL: s.URL(key),
+		ExpiresAt: now.Add(expires),
+	}, nil
+}
+
+// objectURL returns the request URL for key, in path or virtual-hosted style
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	prefix := ""
+	if s.pathStyle {
+		prefix = "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = prefix + "/" + key
+	u.RawPath = prefix + "/" + escapePath(key)
+	return &u
+}
+
+// signRequest adds SigV4 Authorization headers to req
+func (s *S3) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	signedHeaders := strings.Join(sortedKeys(headers), ";")
+
+	canonical := canonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.Query(), headers, payloadHash)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKey, s.scope(now), signedHeaders, s.signature(canonical, now)))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request with the derived SigV4 key
+func (s *S3) signature(canonical string, now time.Time) string {
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(amzDateFormat),
+		s.scope(now),
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3) do(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && !(req.Method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("s3 api error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func canonicalRequest(method, escapedPath string, query url.Values, headers map[string]string, payloadHash string) string {
+	var canonicalHeaders strings.Builder
+	keys := sortedKeys(headers)
+	for _, k := range keys {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+
+	return strings.Join([]string{
+		method,
+		escapedPath,
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		strings.Join(keys, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// escapePath encodes each segment of an object key, keeping the slashes
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// unescapeKey reverses escapePath
+func unescapeKey(escaped string) (string, bool) {
+	key, err := url.PathUnescape(escaped)
+	if err != nil || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
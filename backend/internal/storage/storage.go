@@ -0,0 +1,81 @@
+// Package storage stores uploaded files behind a driver-agnostic interface.
+// The driver is chosen from config: local disk, any S3-compatible service, or
+// Supabase Storage.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+)
+
+// Storage drivers
+const (
+	DriverLocal    = "local"
+	DriverS3       = "s3"
+	DriverSupabase = "supabase"
+)
+
+var (
+	// ErrTooLarge is returned when an upload exceeds its policy's size limit.
+	ErrTooLarge = errors.New("file is too large")
+	// ErrUnsupportedType is returned when an upload's sniffed content type is not allowed.
+	ErrUnsupportedType = errors.New("file type is not allowed")
+	// ErrInvalidSignature is returned when a signed upload URL is forged or expired.
+	ErrInvalidSignature = errors.New("invalid or expired upload signature")
+)
+
+// Object is a stored file.
+type Object struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// SignedUpload lets a client upload one object directly to storage without
+// streaming it through the API. The client sends Method to URL with Headers set.
+type SignedUpload struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	Key       string            `json:"key"`
+	PublicURL string            `json:"public_url"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Storage is implemented by each storage driver.
+type Storage interface {
+	// Put writes an object, replacing any existing object with the same key.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (Object, error)
+	// Get opens an object for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of an object.
+	URL(key string) string
+	// KeyFromURL recovers the key of an object from its public URL. It reports
+	// false for URLs that do not belong to this storage.
+	KeyFromURL(url string) (string, bool)
+	// SignUpload returns a short-lived URL for uploading an object of exactly
+	// size bytes and the given content type.
+	SignUpload(ctx context.Context, key, contentType string, size int64, expires time.Duration) (*SignedUpload, error)
+}
+
+// New builds the storage driver selected by cfg.Storage.Driver.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case DriverLocal:
+		return NewLocal(cfg.Storage.LocalDir, cfg.Storage.PublicBaseURL, cfg.Storage.SigningSecret), nil
+	case DriverS3:
+		return NewS3(cfg.AWS)
+	case DriverSupabase:
+		return NewSupabase(cfg.Supabase)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
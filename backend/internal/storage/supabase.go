@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/airmassxpress/backend/internal/config"
+)
+
+// Supabase stores objects in a public Supabase Storage bucket.
+type Supabase struct {
+	baseURL string
+	key     string
+	bucket  string
+	client  *http.Client
+}
+
+func NewSupabase(cfg config.SupabaseConfig) (*Supabase, error) {
+	if cfg.URL == "" || cfg.Key == "" {
+		return nil, errors.New("supabase storage requires SUPABASE_URL and SUPABASE_SERVICE_ROLE_KEY")
+	}
+	return &Supabase{
+		baseURL: strings.TrimSuffix(cfg.URL, "/"),
+		key:     cfg.Key,
+		bucket:  cfg.Bucket,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (s *Supabase) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (Object, error) {
+	// POST /storage/v1/object/{bucket}/{path}
+	req, err := s.newRequest(ctx, http.MethodPost, "/storage/v1/object/"+s.bucket+"/"+escapePath(key), body)
+	if err != nil {
+		return Object{}, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true")
+
+	if _, err := s.do(req); err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, URL: s.URL(key), ContentType: contentType, Size: size}, nil
+}
+
+func (s *Supabase) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GET /storage/v1/object/authenticated/{bucket}/{path}
+	req, err := s.newRequest(ctx, http.MethodGet, "/storage/v1/object/authenticated/"+s.bucket+"/"+escapePath(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("supabase api error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
+func (s *Supabase) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, "/storage/v1/object/"+s.bucket+"/"+escapePath(key), nil)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req)
+	return err
+}
+
+func (s *Supabase) URL(key string) string {
+	// {supabaseUrl}/storage/v1/object/public/{bucket}/{path}
+	return s.publicPrefix() + escapePath(key)
+}
+
+func (s *Supabase) KeyFromURL(rawURL string) (string, bool) {
+	escaped, ok := strings.CutPrefix(rawURL, s.publicPrefix())
+	if !ok || escaped == "" {
+		return "", false
+	}
+	return unescapeKey(escaped)
+}
+
+// SignUpload asks Supabase for a signed upload token. Supabase signed URLs do not
+// bind a size, so the bucket's own file size limit is the backstop.
+func (s *Supabase) SignUpload(ctx context.Context, key, contentType string, size int64, expires time.Duration) (*SignedUpload, error) {
+	req, err := s.newRequest(ctx, http.MethodPost, "/storage/v1/object/upload/sign/"+s.bucket+"/"+escapePath(key), strings.NewReader("{}"))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-upsert", "true")
+
+	body, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var signed struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(body, &signed); err != nil || signed.URL == "" {
+		return nil, fmt.Errorf("unexpected supabase sign response: %s", string(body))
+	}
+
+	return &SignedUpload{
+		Method:    http.MethodPut,
+		URL:       s.baseURL + "/storage/v1" + signed.URL,
+		Headers:   map[string]string{"Content-Type": contentType, "x-upsert": "true"},
+		Key:       key,
+		PublicURL: s.URL(key),
+		// Supabase upload tokens are valid for two hours regardless of the request
+		ExpiresAt: time.Now().Add(2 * time.Hour),
+	}, nil
+}
+
+func (s *Supabase) publicPrefix() string {
+	return s.baseURL + "/storage/v1/object/public/" + s.bucket + "/"
+}
+
+func (s *Supabase) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.key)
+	return req, nil
+}
+
+func (s *Supabase) do(req *http.Request) ([]byte, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated &&
+		!(req.Method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		return nil, fmt.Errorf("supabase api error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
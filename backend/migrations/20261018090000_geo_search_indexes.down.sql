@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_inventory_items_earth;
+DROP INDEX IF EXISTS idx_tasks_earth;
+DROP INDEX IF EXISTS idx_inventory_items_geography;
+DROP INDEX IF EXISTS idx_tasks_geography;
+DROP INDEX IF EXISTS idx_inventory_items_lat_lng;
+DROP INDEX IF EXISTS idx_tasks_lat_lng;
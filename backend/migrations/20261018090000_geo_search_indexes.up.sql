@@ -0,0 +1,21 @@
+-- Geospatial search: indexes backing services.GeoService.
+-- The service prefers PostGIS, then earthdistance, then plain haversine SQL,
+-- so only the index for the backend that is actually installed is created.
+
+-- Haversine fallback prefilters on a lat/lng bounding box
+CREATE INDEX IF NOT EXISTS idx_tasks_lat_lng ON tasks (lat, lng);
+CREATE INDEX IF NOT EXISTS idx_inventory_items_lat_lng ON inventory_items (lat, lng);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis') THEN
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_tasks_geography ON tasks USING gist (geography(ST_SetSRID(ST_MakePoint(lng::float8, lat::float8), 4326)))';
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_inventory_items_geography ON inventory_items USING gist (geography(ST_SetSRID(ST_MakePoint(lng::float8, lat::float8), 4326)))';
+    ELSIF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'earthdistance') THEN
+        CREATE EXTENSION IF NOT EXISTS cube;
+        CREATE EXTENSION IF NOT EXISTS earthdistance;
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_tasks_earth ON tasks USING gist (ll_to_earth(lat::float8, lng::float8))';
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_inventory_items_earth ON inventory_items USING gist (ll_to_earth(lat::float8, lng::float8))';
+    END IF;
+END
+$$;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_title_trgm;
+DROP INDEX IF EXISTS idx_tasks_search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
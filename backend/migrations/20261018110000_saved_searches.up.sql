@@ -0,0 +1,34 @@
+-- Saved searches and the tasks that matched them
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(100),
+    category VARCHAR(100),
+    task_type VARCHAR(20),
+    keywords VARCHAR(255),
+    min_budget DECIMAL(10,2),
+    max_budget DECIMAL(10,2),
+    lat DECIMAL(10,8),
+    lng DECIMAL(11,8),
+    radius_km DECIMAL(6,2),
+    frequency VARCHAR(20) NOT NULL DEFAULT 'instant',
+    active BOOLEAN DEFAULT TRUE,
+    last_digest_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user_id ON saved_searches(user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_searches_deleted_at ON saved_searches(deleted_at);
+
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    saved_search_id UUID NOT NULL,
+    task_id UUID NOT NULL,
+    notified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_search_match ON saved_search_matches(saved_search_id, task_id);
+CREATE INDEX IF NOT EXISTS idx_saved_search_matches_notified_at ON saved_search_matches(notified_at);
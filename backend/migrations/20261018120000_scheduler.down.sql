@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS reminder_sent_at;
+DROP TABLE IF EXISTS job_runs;
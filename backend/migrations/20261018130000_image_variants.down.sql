@@ -0,0 +1,8 @@
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS photo_variants;
+
+ALTER TABLE task_attachments DROP COLUMN IF EXISTS height;
+ALTER TABLE task_attachments DROP COLUMN IF EXISTS width;
+ALTER TABLE task_attachments DROP COLUMN IF EXISTS blurhash;
+ALTER TABLE task_attachments DROP COLUMN IF EXISTS large_url;
+ALTER TABLE task_attachments DROP COLUMN IF EXISTS medium_url;
+ALTER TABLE task_attachments DROP COLUMN IF EXISTS thumbnail_url;
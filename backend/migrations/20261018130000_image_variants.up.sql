@@ -0,0 +1,9 @@
+-- Image pipeline: generated variants and blurhash placeholders
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS medium_url TEXT;
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS large_url TEXT;
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS blurhash VARCHAR(64);
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS width INTEGER;
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS height INTEGER;
+
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS photo_variants JSONB;
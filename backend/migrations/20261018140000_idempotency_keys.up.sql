@@ -0,0 +1,11 @@
+-- Idempotency keys for retry-safe state changes such as accepting an offer
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    scope VARCHAR(100) NOT NULL,
+    resource_id UUID NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_user_key ON idempotency_keys(user_id, key);
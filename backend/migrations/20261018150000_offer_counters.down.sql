@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS offer_revisions;
+DROP TABLE IF EXISTS offer_counters;
+ALTER TABLE offers DROP COLUMN IF EXISTS version;
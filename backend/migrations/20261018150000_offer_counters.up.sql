@@ -0,0 +1,37 @@
+-- Structured counter-offers and offer version history
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS offer_counters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    offer_id UUID NOT NULL REFERENCES offers(id) ON DELETE CASCADE,
+    proposed_by_id UUID NOT NULL REFERENCES users(id),
+    amount DECIMAL(10,2),
+    estimated_duration TEXT,
+    availability TEXT,
+    message TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    responded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_offer_counters_offer_id ON offer_counters(offer_id);
+
+CREATE TABLE IF NOT EXISTS offer_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    offer_id UUID NOT NULL REFERENCES offers(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    estimated_duration TEXT,
+    availability TEXT,
+    source VARCHAR(20) NOT NULL,
+    counter_id UUID REFERENCES offer_counters(id),
+    changed_by_id UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_offer_revisions_offer_version ON offer_revisions(offer_id, version);
+
+-- Existing offers start their history at version 1
+INSERT INTO offer_revisions (offer_id, version, amount, estimated_duration, availability, source, changed_by_id, created_at)
+SELECT id, 1, amount, estimated_duration, availability, 'created', tasker_id, created_at FROM offers
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+ALTER TABLE offers DROP COLUMN IF EXISTS previous_amount;
+ALTER TABLE offers DROP COLUMN IF EXISTS edited_at;
+ALTER TABLE offers DROP COLUMN IF EXISTS viewed_by_poster_at;
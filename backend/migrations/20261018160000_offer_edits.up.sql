@@ -0,0 +1,4 @@
+-- Restricted offer edits: poster view tracking and edit flags
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS viewed_by_poster_at TIMESTAMPTZ;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS previous_amount DECIMAL(10,2);
@@ -0,0 +1,2 @@
+ALTER TABLE offers DROP COLUMN IF EXISTS quote_lines;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS fuel_rate;
@@ -0,0 +1,3 @@
+-- Server-computed equipment quotes
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS fuel_rate DECIMAL(10,2);
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS quote_lines JSONB;
@@ -0,0 +1,39 @@
+-- Equipment booking calendar: hires from accepted offers and owner blockouts
+CREATE TABLE IF NOT EXISTS equipment_bookings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inventory_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    task_id UUID REFERENCES tasks(id) ON DELETE SET NULL,
+    offer_id UUID REFERENCES offers(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_by_id UUID NOT NULL REFERENCES users(id),
+    released_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_equipment_bookings_window ON equipment_bookings(inventory_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_equipment_bookings_task_id ON equipment_bookings(task_id);
+
+-- Book equipment already hired on assigned or in-progress tasks
+INSERT INTO equipment_bookings (inventory_id, kind, status, starts_at, ends_at, task_id, offer_id, created_by_id)
+SELECT o.inventory_id, 'hire', 'active', w.starts_at,
+       w.starts_at + CASE
+           WHEN t.hire_duration_type = 'hourly'
+             OR (COALESCE(t.hire_duration_type, '') NOT IN ('daily', 'weekly', 'monthly') AND t.estimated_hours IS NOT NULL)
+             THEN make_interval(hours => GREATEST(COALESCE(t.estimated_hours, t.estimated_duration, 1), 1))
+           WHEN t.hire_duration_type = 'weekly' THEN make_interval(weeks => GREATEST(COALESCE(t.estimated_duration, 1), 1))
+           WHEN t.hire_duration_type = 'monthly' THEN make_interval(months => GREATEST(COALESCE(t.estimated_duration, 1), 1))
+           ELSE make_interval(days => GREATEST(COALESCE(t.estimated_duration, 1), 1))
+       END,
+       t.id, o.id, t.poster_id
+FROM tasks t
+JOIN offers o ON o.id = t.accepted_offer_id
+CROSS JOIN LATERAL (SELECT COALESCE(t.date, o.updated_at) AS starts_at) w
+WHERE o.inventory_id IS NOT NULL
+  AND t.status IN ('assigned', 'in_progress')
+  AND NOT EXISTS (SELECT 1 FROM equipment_bookings b WHERE b.task_id = t.id AND b.status = 'active');
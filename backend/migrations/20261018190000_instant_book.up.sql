@@ -0,0 +1,2 @@
+-- Instant-book: items can be hired directly at their published rates
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS booking_mode VARCHAR(20) NOT NULL DEFAULT 'request';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS hire_settlements;
+DROP TABLE IF EXISTS hire_events;
@@ -0,0 +1,36 @@
+-- Equipment hire check-in/check-out and metered settlements
+CREATE TABLE IF NOT EXISTS hire_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    lat DECIMAL(10,8),
+    lng DECIMAL(11,8),
+    engine_hours DECIMAL(10,1),
+    photos JSONB,
+    note TEXT,
+    recorded_by_id UUID NOT NULL REFERENCES users(id),
+    confirmed_by_id UUID REFERENCES users(id),
+    confirmed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hire_events_task_kind ON hire_events(task_id, kind);
+
+CREATE TABLE IF NOT EXISTS hire_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    offer_id UUID NOT NULL REFERENCES offers(id),
+    rate_type VARCHAR(20),
+    elapsed_hours DECIMAL(10,2) NOT NULL,
+    machine_hours DECIMAL(10,2) NOT NULL,
+    billed_quantity DECIMAL(10,2) NOT NULL,
+    estimated_amount DECIMAL(10,2) NOT NULL,
+    billed_amount DECIMAL(10,2) NOT NULL,
+    difference DECIMAL(10,2) NOT NULL,
+    lines JSONB,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hire_settlements_task_id ON hire_settlements(task_id);
@@ -0,0 +1,8 @@
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS compliance_hold;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS certificates_expire_at;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS compliance_status;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS last_hour_meter;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS last_serviced_at;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS last_inspected_at;
+DROP TABLE IF EXISTS equipment_certificates;
+DROP TABLE IF EXISTS maintenance_records;
@@ -0,0 +1,41 @@
+-- Equipment maintenance log and inspection/insurance certificates
+CREATE TABLE IF NOT EXISTS maintenance_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inventory_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    performed_at TIMESTAMPTZ NOT NULL,
+    hour_meter DECIMAL(10,1),
+    description TEXT NOT NULL,
+    parts JSONB,
+    cost DECIMAL(10,2),
+    performed_by TEXT,
+    created_by_id UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_records_inventory_id ON maintenance_records(inventory_id);
+
+CREATE TABLE IF NOT EXISTS equipment_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inventory_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    issuer TEXT,
+    number TEXT,
+    document_url TEXT NOT NULL,
+    issued_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_by_id UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_equipment_certificates_inventory_id ON equipment_certificates(inventory_id);
+CREATE INDEX IF NOT EXISTS idx_equipment_certificates_expires_at ON equipment_certificates(expires_at);
+
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS last_inspected_at TIMESTAMPTZ;
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS last_serviced_at TIMESTAMPTZ;
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS last_hour_meter DECIMAL(10,1);
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS compliance_status VARCHAR(20);
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS certificates_expire_at TIMESTAMPTZ;
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS compliance_hold BOOLEAN DEFAULT FALSE;
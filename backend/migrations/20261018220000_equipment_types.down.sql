@@ -0,0 +1,3 @@
+ALTER TABLE equipment_capacities DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE equipment_capacities DROP COLUMN IF EXISTS retired_at;
+DROP TABLE IF EXISTS equipment_types;
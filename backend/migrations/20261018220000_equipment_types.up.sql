@@ -0,0 +1,21 @@
+-- Admin-managed equipment types; capacity tiers can be retired without orphaning
+-- the tasks and inventory that reference them
+CREATE TABLE IF NOT EXISTS equipment_types (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT,
+    sort_order INT DEFAULT 0,
+    retired_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE equipment_capacities ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ;
+ALTER TABLE equipment_capacities ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+
+-- One type per existing capacity type, in the order they were first added
+INSERT INTO equipment_types (name, sort_order)
+SELECT equipment_type, ROW_NUMBER() OVER (ORDER BY MIN(created_at), equipment_type)
+FROM equipment_capacities
+GROUP BY equipment_type
+ON CONFLICT (name) DO NOTHING;
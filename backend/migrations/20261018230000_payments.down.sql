@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_transactions;
+ALTER TABLE escrow_transactions DROP COLUMN IF EXISTS payment_method;
@@ -0,0 +1,32 @@
+-- Payment providers: escrow is pending until the poster pays, and each
+-- collection, payout and refund is recorded against it
+ALTER TABLE escrow_transactions ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20);
+
+-- Escrow created before payments existed was settled in cash
+UPDATE escrow_transactions SET payment_method = 'cash' WHERE payment_method IS NULL AND status = 'released';
+
+CREATE TABLE IF NOT EXISTS payment_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    escrow_id UUID NOT NULL REFERENCES escrow_transactions(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    kind VARCHAR(20) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    reference VARCHAR(40) NOT NULL,
+    provider_ref VARCHAR(100),
+    amount DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    phone VARCHAR(20),
+    status VARCHAR(20) NOT NULL,
+    failure_reason TEXT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_transactions_reference ON payment_transactions(reference);
+CREATE INDEX IF NOT EXISTS idx_payment_transactions_escrow_id ON payment_transactions(escrow_id);
+CREATE INDEX IF NOT EXISTS idx_payment_transactions_task_id ON payment_transactions(task_id);
+CREATE INDEX IF NOT EXISTS idx_payment_transactions_user_id ON payment_transactions(user_id);
+CREATE INDEX IF NOT EXISTS idx_payment_transactions_provider_ref ON payment_transactions(provider_ref);
+CREATE INDEX IF NOT EXISTS idx_payment_transactions_status ON payment_transactions(status);
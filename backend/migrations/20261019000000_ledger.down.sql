@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP FUNCTION IF EXISTS ledger_immutable();
+ALTER TABLE escrow_transactions DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,59 @@
+-- Double-entry ledger behind escrow, wallets and payouts
+ALTER TABLE escrow_transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    user_id UUID REFERENCES users(id),
+    provider VARCHAR(20),
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_code ON ledger_accounts(code);
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_kind ON ledger_accounts(kind);
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_user_id ON ledger_accounts(user_id);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference VARCHAR(100) NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    task_id UUID,
+    escrow_id UUID,
+    payment_id UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_journal_entries_reference ON journal_entries(reference);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_kind ON journal_entries(kind);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_task_id ON journal_entries(task_id);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_escrow_id ON journal_entries(escrow_id);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_payment_id ON journal_entries(payment_id);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id UUID NOT NULL REFERENCES journal_entries(id),
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    amount DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry_id ON ledger_postings(entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_id ON ledger_postings(account_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_created_at ON ledger_postings(created_at);
+
+-- Journal rows are append-only
+CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger rows are immutable; post a reversing entry instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS journal_entries_immutable ON journal_entries;
+CREATE TRIGGER journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+DROP TRIGGER IF EXISTS ledger_postings_immutable ON ledger_postings;
+CREATE TRIGGER ledger_postings_immutable BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
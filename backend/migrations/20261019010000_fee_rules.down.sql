@@ -0,0 +1,4 @@
+ALTER TABLE escrow_transactions DROP COLUMN IF EXISTS fees;
+ALTER TABLE escrow_transactions DROP COLUMN IF EXISTS commission;
+ALTER TABLE escrow_transactions DROP COLUMN IF EXISTS service_fee;
+DROP TABLE IF EXISTS fee_rules;
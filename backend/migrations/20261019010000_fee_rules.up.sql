@@ -0,0 +1,25 @@
+-- Platform fee rules and the fees priced onto each escrow
+CREATE TABLE IF NOT EXISTS fee_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    side VARCHAR(10) NOT NULL,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    task_type VARCHAR(20) NOT NULL DEFAULT '',
+    percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    flat DECIMAL(10,2) NOT NULL DEFAULT 0,
+    min_fee DECIMAL(10,2),
+    max_fee DECIMAL(10,2),
+    promo BOOLEAN DEFAULT FALSE,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_rules_side ON fee_rules(side);
+CREATE INDEX IF NOT EXISTS idx_fee_rules_active ON fee_rules(active);
+
+ALTER TABLE escrow_transactions ADD COLUMN IF NOT EXISTS service_fee DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE escrow_transactions ADD COLUMN IF NOT EXISTS commission DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE escrow_transactions ADD COLUMN IF NOT EXISTS fees JSONB;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_sequences;
+DROP FUNCTION IF EXISTS invoice_immutable();
@@ -0,0 +1,61 @@
+-- Immutable invoices and credit notes with gap-free numbering
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    series VARCHAR(10) NOT NULL,
+    year INTEGER NOT NULL,
+    last_number INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (series, year)
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    number VARCHAR(30) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    task_id UUID NOT NULL REFERENCES tasks(id),
+    escrow_id UUID REFERENCES escrow_transactions(id),
+    credited_invoice_id UUID REFERENCES invoices(id),
+    credited_invoice_number VARCHAR(30),
+    poster_id UUID NOT NULL REFERENCES users(id),
+    tasker_id UUID NOT NULL REFERENCES users(id),
+    issuer_name VARCHAR(200) NOT NULL,
+    issuer_address TEXT,
+    issuer_tax_number VARCHAR(50),
+    poster_name VARCHAR(200),
+    tasker_name VARCHAR(200),
+    task_title VARCHAR(255),
+    currency VARCHAR(3) NOT NULL,
+    lines JSONB,
+    subtotal DECIMAL(12,2) NOT NULL,
+    service_fee DECIMAL(12,2) NOT NULL DEFAULT 0,
+    commission DECIMAL(12,2) NOT NULL DEFAULT 0,
+    fees JSONB,
+    total DECIMAL(12,2) NOT NULL,
+    tasker_payout DECIMAL(12,2) NOT NULL,
+    tax_name VARCHAR(20),
+    tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    tax DECIMAL(12,2) NOT NULL DEFAULT 0,
+    payment_method VARCHAR(20),
+    reason TEXT,
+    issued_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_number ON invoices(number);
+CREATE INDEX IF NOT EXISTS idx_invoices_kind ON invoices(kind);
+CREATE INDEX IF NOT EXISTS idx_invoices_task_id ON invoices(task_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_escrow_id ON invoices(escrow_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_credited_invoice_id ON invoices(credited_invoice_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_poster_id ON invoices(poster_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_tasker_id ON invoices(tasker_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_issued_at ON invoices(issued_at);
+-- One invoice per escrow; credit notes share their invoice's escrow
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_escrow_invoice ON invoices(escrow_id) WHERE kind = 'invoice';
+
+CREATE OR REPLACE FUNCTION invoice_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'invoices are immutable once issued; issue a credit note instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION invoice_immutable();
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE fee_rules DROP COLUMN IF EXISTS currency;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS currency;
+ALTER TABLE inventory_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE offers DROP COLUMN IF EXISTS currency;
+ALTER TABLE tasks DROP COLUMN IF EXISTS currency;
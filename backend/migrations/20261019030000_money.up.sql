@@ -0,0 +1,23 @@
+-- Currencies on tasks, offers, listings and saved searches, and exchange rates
+-- for showing amounts in another currency. Existing rows are all US dollars.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE inventory_items ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Flat fees and caps are amounts, so rules with them are pinned to US dollars
+ALTER TABLE fee_rules ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT '';
+UPDATE fee_rules SET currency = 'USD'
+WHERE currency = '' AND (flat <> 0 OR min_fee IS NOT NULL OR max_fee IS NOT NULL);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    base VARCHAR(3) NOT NULL,
+    quote VARCHAR(3) NOT NULL,
+    rate DECIMAL(18,8) NOT NULL CHECK (rate > 0),
+    effective_at TIMESTAMPTZ NOT NULL,
+    source VARCHAR(100),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_pair ON exchange_rates(base, quote, effective_at);
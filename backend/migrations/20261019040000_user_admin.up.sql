@@ -0,0 +1,3 @@
+-- Admin flag gating the admin API; grant it with
+-- UPDATE users SET is_admin = TRUE WHERE email = '...';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE;
@@ -0,0 +1,12 @@
+-- Audit trail of task status transitions, written by the task lifecycle
+CREATE TABLE IF NOT EXISTS task_status_histories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    actor_id UUID REFERENCES users(id),
+    reason TEXT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_status_histories_task_id ON task_status_histories(task_id);
@@ -0,0 +1 @@
+ALTER TABLE hire_settlements DROP COLUMN IF EXISTS uncollected_amount;
@@ -0,0 +1,2 @@
+-- The part of a metered bill above the escrow already collected
+ALTER TABLE hire_settlements ADD COLUMN IF NOT EXISTS uncollected_amount DECIMAL(10,2) NOT NULL DEFAULT 0;